@@ -1,12 +1,24 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/grpcserver"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/metrics"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/resilience"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/webserver"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/webserver/probes"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/database"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/tracing"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
 
 	"github.com/joho/godotenv"
@@ -25,26 +37,98 @@ func run() error {
 	if err != nil {
 		logger.Info(".env file not detected.... falling through to Kubernetes ✿✿")
 	}
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if cfg.PrintConfig {
+		// printed even when it is not valid, the problems are reported after it
+		fmt.Print(cfg)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err = logging.Init(cfg.LogLevel); err != nil {
+		return err
+	}
+	logger.WithField("config_file", cfg.File).Info("configuration loaded")
 
-	cbStorage, err := database.NewCouchbaseStorage()
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Name, cfg.Tracing.Exporter)
 	if err != nil {
-		logger.Errorf("Couchbase connection error: %v", err)
+		logger.WithError(err).Error("Tracing error")
 		return err
 	}
 
-	bookTrackingSvc := service.NewBookTracker(cbStorage)
+	cbStorage, err := database.NewCouchbaseStorage(cfg.Couchbase)
+	if err != nil {
+		logger.WithError(err).Error("Couchbase connection error")
+		return err
+	}
+
+	// the services go through the cache, the resilience decorator and then the metrics one, so that the cache hits never
+	// reach the circuit breaker and every attempt is timed. The probes and Close go through the storage itself
+	resilient := resilience.NewStorage(metrics.NewStorage(cbStorage), cfg.Resilience)
+	storage := cache.NewStorage(resilient, cache.NewLRU(cfg.Cache.Size), cfg.Cache)
+	bookTrackingSvc := service.NewBookTracker(storage)
+	goalTrackingSvc := service.NewGoalTracker(storage, bookTrackingSvc)
+	shelfTrackingSvc := service.NewShelfTracker(storage, bookTrackingSvc)
+	reviewTrackingSvc := metrics.NewReviewTracker(service.NewReviewTracker(storage, bookTrackingSvc))
+	highlightTrackingSvc := metrics.NewHighlightTracker(service.NewHighlightTracker(storage, bookTrackingSvc))
+	recommenderSvc := service.NewRecommender(bookTrackingSvc)
+	genreTrackingSvc := service.NewGenreTracker(storage)
+	if err = metrics.RegisterBooks(bookTrackingSvc); err != nil {
+		return err
+	}
+	if err = metrics.RegisterResilience(resilient); err != nil {
+		return err
+	}
+	if err = metrics.RegisterCache(storage); err != nil {
+		return err
+	}
 
 	services := webserver.Services{
-		BookTracker: bookTrackingSvc,
+		BookTracker:      bookTrackingSvc,
+		GoalTracker:      goalTrackingSvc,
+		ShelfTracker:     shelfTrackingSvc,
+		ReviewTracker:    reviewTrackingSvc,
+		HighlightTracker: highlightTrackingSvc,
+		Recommender:      recommenderSvc,
+		GenreTracker:     genreTrackingSvc,
 	}
 
-	server := webserver.NewServer(services)
+	server := webserver.NewServer(cfg, services,
+		probes.Check{Name: "couchbase-cluster", Ping: cbStorage.PingCluster},
+		probes.Check{Name: "couchbase-bucket", Ping: cbStorage.PingBucket},
+		probes.Check{Name: "couchbase-circuit", Ping: resilient.PingCircuit})
 
-	err = server.Run()
+	// both servers drain on SIGINT or SIGTERM, or when the other one fails
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	grpcErrs := make(chan error, 1)
+	if cfg.GRPC.Port > 0 {
+		grpcServer := grpcserver.NewServer(cfg, bookTrackingSvc)
+		go func() {
+			grpcErrs <- grpcServer.Serve(ctx)
+			stop()
+		}()
+	} else {
+		grpcErrs <- nil
+	}
 
-	if err != nil {
-		return err
+	err = server.Serve(ctx)
+	stop()
+	if grpcErr := <-grpcErrs; err == nil {
+		err = grpcErr
+	}
+
+	// the servers have drained, nothing uses the cluster anymore
+	if closeErr := cbStorage.Close(); closeErr != nil {
+		logger.WithError(closeErr).Error("Couchbase close error")
+	} else {
+		logger.Info("Couchbase connection closed")
+	}
+	// flushes the spans of the drained requests
+	if traceErr := shutdownTracing(context.Background()); traceErr != nil {
+		logger.WithError(traceErr).Error("Tracing shutdown error")
 	}
 
-	return nil
+	return err
 }
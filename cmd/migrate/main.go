@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/database"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+// migrate - rewrites the book collection to the current schema version.
+// The last migrated document id is written to the checkpoint file after every batch, so that an interrupted run
+// can continue with -resume.
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "migration error %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	batchSize := flag.Int("batch-size", 100, "number of documents migrated per batch")
+	checkpoint := flag.String("checkpoint", ".migrate-checkpoint", "file keeping the last migrated document id")
+	resume := flag.Bool("resume", false, "continue after the document id in the checkpoint file")
+
+	logger := logrus.StandardLogger()
+	err := godotenv.Load(".env")
+	if err != nil {
+		logger.Info(".env file not detected.... falling through to Kubernetes ✿✿")
+	}
+	// the flags of the configuration are parsed along with the ones of the migration
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if cfg.PrintConfig {
+		// printed even when it is not valid, the problems are reported after it
+		fmt.Print(cfg)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err = logging.Init(cfg.LogLevel); err != nil {
+		return err
+	}
+
+	after := ""
+	if *resume {
+		data, err := os.ReadFile(*checkpoint)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		after = strings.TrimSpace(string(data))
+		logger.WithField("after", after).Info("resuming migration")
+	}
+
+	storage, err := database.NewCouchbaseStorage(cfg.Couchbase)
+	if err != nil {
+		logger.WithError(err).Error("Couchbase connection error")
+		return err
+	}
+	cb, ok := storage.(*database.Couchbase)
+	if !ok {
+		return errors.New("storage does not support migrations")
+	}
+
+	logger.WithField("schema_version", database.CurrentSchemaVersion).Info("migrating books")
+	// an interrupted migration stops after the document in progress, the checkpoint allows to resume it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	progress, err := cb.MigrateAll(ctx, after, *batchSize, func(p database.MigrationProgress) {
+		logger.WithFields(logrus.Fields{"migrated": p.Migrated, "pending": p.Pending, "skipped": p.Skipped, "last_id": p.LastID}).Info("migrated batch")
+		if err := os.WriteFile(*checkpoint, []byte(p.LastID), 0644); err != nil {
+			logger.WithError(err).Error("failed to write checkpoint")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{"migrated": progress.Migrated, "skipped": progress.Skipped}).Info("migration complete")
+	return os.Remove(*checkpoint)
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store - where the cached values are kept, as encoded bytes so that a shared cache(e.g. Redis) can replace the
+// in-memory one. A store that fails answers a miss, the repository is then read as if nothing was cached
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+	DeletePrefix(ctx context.Context, prefix string)
+}
+
+// LRU - an in-memory Store of at most size entries, the least recently used one is evicted first. Expired entries are
+// removed when they are read or evicted
+type LRU struct {
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU - a size of 0 keeps nothing
+func NewLRU(size int) *LRU {
+	return &LRU{size: size, now: time.Now, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+func (l *LRU) Get(_ context.Context, key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !l.now().Before(element.Value.(*entry).expires) {
+		l.remove(element)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*entry).value, true
+}
+
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	if l.size <= 0 || ttl <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		element.Value = &entry{key: key, value: value, expires: l.now().Add(ttl)}
+		l.order.MoveToFront(element)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&entry{key: key, value: value, expires: l.now().Add(ttl)})
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *LRU) Delete(_ context.Context, keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.remove(element)
+		}
+	}
+}
+
+func (l *LRU) DeletePrefix(_ context.Context, prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, element := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(element)
+		}
+	}
+}
+
+// Len - the entries kept, expired ones included until they are removed
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*entry).key)
+}
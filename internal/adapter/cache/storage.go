@@ -0,0 +1,256 @@
+// Package cache keeps the books and the listings read from the repository, so that repeated reads do not reach
+// Couchbase. The book writes invalidate what they change
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+// BypassHeader - a request with this header set to true reads the repository instead of the cache, and caches what it
+// read
+const BypassHeader = "X-Cache-Bypass"
+
+const (
+	bookPrefix = "book:"
+	listPrefix = "list:"
+
+	KindBook = "book"
+	KindList = "list"
+
+	ResultHit    = "hit"
+	ResultMiss   = "miss"
+	ResultBypass = "bypass"
+)
+
+type bypassKey struct{}
+
+// WithBypass - the reads of the context skip the cache
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Bypassed - the reads of the context skip the cache
+func Bypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// Count - the cache lookups of a kind(book, list) with a result(hit, miss, bypass)
+type Count struct {
+	Kind   string
+	Result string
+	Value  uint64
+}
+
+// Storage - read-through cache of the books(Get) and of the listings(GetAll, GetAllSorted, Stats, GenreCounts,
+// StatusCounts, GenreBooks) the groups are built from. A book write invalidates the book and every listing, the tag and genre
+// remaps invalidate everything. The other methods are passed through as is.
+// Every invalidation bumps the generation of the kinds it drops, a load that started before is returned but not cached
+// so that it cannot put back the value the write replaced. The loads of the other books of the kind are not cached
+// either, which only costs a miss
+
+type Storage struct {
+	repository.Storage
+	store   Store
+	bookTTL time.Duration
+	listTTL time.Duration
+
+	mu          sync.Mutex
+	counts      map[Count]uint64
+	generations map[string]uint64
+}
+
+// NewStorage - decorates the repository with the cache kept in the store. A TTL of 0 does not cache that kind
+func NewStorage(s repository.Storage, store Store, cfg config.Cache) *Storage {
+	return &Storage{Storage: s, store: store, bookTTL: cfg.BookTTL, listTTL: cfg.ListTTL, counts: map[Count]uint64{},
+		generations: map[string]uint64{}}
+}
+
+// Counts - the lookups so far, by kind and result
+func (s *Storage) Counts() []Count {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var counts []Count
+	for _, kind := range []string{KindBook, KindList} {
+		for _, result := range []string{ResultHit, ResultMiss, ResultBypass} {
+			counts = append(counts, Count{Kind: kind, Result: result, Value: s.counts[Count{Kind: kind, Result: result}]})
+		}
+	}
+	return counts
+}
+
+func (s *Storage) count(kind string, result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[Count{Kind: kind, Result: result}]++
+}
+
+func (s *Storage) generation(kind string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations[kind]
+}
+
+// setCurrent - caches the value unless the kind was invalidated since the generation was read. The store is set under
+// the lock so that an invalidation cannot run between the check and the set
+func (s *Storage) setCurrent(ctx context.Context, kind string, generation uint64, key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generations[kind] != generation {
+		logging.FromContext(ctx).WithField("cache_key", key).Debug("cache invalidated during the read, not cached")
+		return
+	}
+	s.store.Set(ctx, key, value, ttl)
+}
+
+// bump - the loads of the kinds in flight are not cached anymore
+func (s *Storage) bump(kinds ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, kind := range kinds {
+		s.generations[kind]++
+	}
+}
+
+// read - the cached value of the key, or the one loaded from the repository, cached for the ttl when it did not fail.
+// A value that cannot be decoded is a miss
+func read[T any](ctx context.Context, s *Storage, kind string, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if ttl <= 0 {
+		return load()
+	}
+	if Bypassed(ctx) {
+		s.count(kind, ResultBypass)
+	} else if value, ok := s.store.Get(ctx, key); ok {
+		var decoded T
+		err := json.Unmarshal(value, &decoded)
+		if err == nil {
+			s.count(kind, ResultHit)
+			return decoded, nil
+		}
+		logging.FromContext(ctx).WithError(err).WithField("cache_key", key).Warn("cache decode error, reading the repository")
+		s.count(kind, ResultMiss)
+	} else {
+		s.count(kind, ResultMiss)
+	}
+
+	generation := s.generation(kind)
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("cache_key", key).Warn("cache encode error")
+		return value, nil
+	}
+	s.setCurrent(ctx, kind, generation, key, encoded, ttl)
+	return value, nil
+}
+
+// listKey - the key of a listing and its arguments
+func listKey(method string, args ...interface{}) string {
+	encoded, _ := json.Marshal(args)
+	return listPrefix + method + ":" + string(encoded)
+}
+
+// invalidate - the book, if any, and every listing
+func (s *Storage) invalidate(ctx context.Context, id string) {
+	if id != "" {
+		s.bump(KindBook)
+		s.store.Delete(ctx, bookPrefix+id)
+	}
+	s.bump(KindList)
+	s.store.DeletePrefix(ctx, listPrefix)
+}
+
+// invalidateAll - every book and listing, after a write whose books are not known
+func (s *Storage) invalidateAll(ctx context.Context) {
+	s.bump(KindBook, KindList)
+	s.store.DeletePrefix(ctx, bookPrefix)
+	s.store.DeletePrefix(ctx, listPrefix)
+}
+
+func (s *Storage) Get(ctx context.Context, id string) (*entity.Book, error) {
+	return read(ctx, s, KindBook, bookPrefix+id, s.bookTTL, func() (*entity.Book, error) {
+		return s.Storage.Get(ctx, id)
+	})
+}
+
+func (s *Storage) GetAll(ctx context.Context) ([]entity.Book, error) {
+	return read(ctx, s, KindList, listKey("GetAll"), s.listTTL, func() ([]entity.Book, error) {
+		return s.Storage.GetAll(ctx)
+	})
+}
+
+func (s *Storage) GetAllSorted(ctx context.Context, keys entity.BookSort) ([]entity.Book, error) {
+	return read(ctx, s, KindList, listKey("GetAllSorted", keys), s.listTTL, func() ([]entity.Book, error) {
+		return s.Storage.GetAllSorted(ctx, keys)
+	})
+}
+
+func (s *Storage) Stats(ctx context.Context, filter entity.StatsFilter) (*entity.ReadingStats, error) {
+	return read(ctx, s, KindList, listKey("Stats", filter), s.listTTL, func() (*entity.ReadingStats, error) {
+		return s.Storage.Stats(ctx, filter)
+	})
+}
+
+func (s *Storage) GenreCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	return read(ctx, s, KindList, listKey("GenreCounts"), s.listTTL, func() ([]entity.NamedCount, error) {
+		return s.Storage.GenreCounts(ctx)
+	})
+}
+
+func (s *Storage) StatusCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	return read(ctx, s, KindList, listKey("StatusCounts"), s.listTTL, func() ([]entity.NamedCount, error) {
+		return s.Storage.StatusCounts(ctx)
+	})
+}
+
+// genrePage - the books of a genre page and the total, cached together
+type genrePage struct {
+	Books []entity.Book `json:"books"`
+	Total int           `json:"total"`
+}
+
+func (s *Storage) GenreBooks(ctx context.Context, spellings []string, page entity.Page) ([]entity.Book, int, error) {
+	cached, err := read(ctx, s, KindList, listKey("GenreBooks", spellings, page), s.listTTL, func() (genrePage, error) {
+		books, total, err := s.Storage.GenreBooks(ctx, spellings, page)
+		return genrePage{Books: books, Total: total}, err
+	})
+	return cached.Books, cached.Total, err
+}
+
+// the writes invalidate even when they fail, they may have been applied
+
+func (s *Storage) Upsert(ctx context.Context, id string, doc interface{}) error {
+	err := s.Storage.Upsert(ctx, id, doc)
+	s.invalidate(ctx, id)
+	return err
+}
+
+func (s *Storage) RenameTag(ctx context.Context, from string, to string) (int, error) {
+	renamed, err := s.Storage.RenameTag(ctx, from, to)
+	s.invalidateAll(ctx)
+	return renamed, err
+}
+
+func (s *Storage) RemoveTag(ctx context.Context, tag string) (int, error) {
+	removed, err := s.Storage.RemoveTag(ctx, tag)
+	s.invalidateAll(ctx)
+	return removed, err
+}
+
+func (s *Storage) RemapGenre(ctx context.Context, spellings []string, to string) (int, error) {
+	remapped, err := s.Storage.RemapGenre(ctx, spellings, to)
+	s.invalidateAll(ctx)
+	return remapped, err
+}
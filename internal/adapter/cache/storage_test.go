@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+)
+
+// stubStorage - counts the reads, the books have the title of the stub at the time of the read. With loading set, Get
+// signals it once the title is read and waits for release before returning
+type stubStorage struct {
+	repository.Storage
+	title string
+	err   error
+	reads map[string]int
+
+	loading chan struct{}
+	release chan struct{}
+}
+
+func newStub() *stubStorage {
+	return &stubStorage{title: "Piranesi", reads: map[string]int{}}
+}
+
+func (s *stubStorage) Get(_ context.Context, id string) (*entity.Book, error) {
+	s.reads["Get"]++
+	if s.err != nil {
+		return nil, s.err
+	}
+	book := &entity.Book{ISBN: id, Title: s.title, Authors: []entity.Contributor{{Name: "Susanna Clarke"}}}
+	if s.loading != nil {
+		close(s.loading)
+		<-s.release
+	}
+	return book, nil
+}
+
+func (s *stubStorage) GetAll(context.Context) ([]entity.Book, error) {
+	s.reads["GetAll"]++
+	return []entity.Book{{ISBN: "isbn-1", Title: s.title}}, s.err
+}
+
+func (s *stubStorage) GenreBooks(_ context.Context, _ []string, page entity.Page) ([]entity.Book, int, error) {
+	s.reads["GenreBooks"]++
+	return []entity.Book{{ISBN: "isbn-1", Title: s.title}}, 12 + page.Offset, s.err
+}
+
+func (s *stubStorage) Upsert(context.Context, string, interface{}) error {
+	return nil
+}
+
+func (s *stubStorage) RenameTag(context.Context, string, string) (int, error) {
+	return 1, nil
+}
+
+func testConfig() config.Cache {
+	return config.Cache{Size: 10, BookTTL: time.Minute, ListTTL: time.Minute}
+}
+
+func TestStorage(t *testing.T) {
+	stub := newStub()
+	storage := NewStorage(stub, NewLRU(10), testConfig())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		book, err := storage.Get(ctx, "isbn-1")
+		if err != nil || book.Title != "Piranesi" || book.PrimaryAuthor() != "Susanna Clarke" {
+			t.Fatalf("TestStorage expected(Piranesi by Susanna Clarke) got (%+v, %v)", book, err)
+		}
+		if _, err = storage.GetAll(ctx); err != nil {
+			t.Fatalf("TestStorage expected(nil) got (%v)", err)
+		}
+	}
+	if stub.reads["Get"] != 1 || stub.reads["GetAll"] != 1 {
+		t.Errorf("TestStorage expected(1) read of each got (%v)", stub.reads)
+	}
+
+	// the write invalidates the book and the listings
+	stub.title = "Jonathan Strange & Mr Norrell"
+	if err := storage.Upsert(ctx, "isbn-1", entity.Book{}); err != nil {
+		t.Fatalf("TestStorage expected(nil) got (%v)", err)
+	}
+	book, _ := storage.Get(ctx, "isbn-1")
+	books, _ := storage.GetAll(ctx)
+	if book.Title != stub.title || books[0].Title != stub.title || stub.reads["Get"] != 2 || stub.reads["GetAll"] != 2 {
+		t.Errorf("TestStorage expected(%s) read again got (%s, %s) after (%v)", stub.title, book.Title, books[0].Title, stub.reads)
+	}
+
+	want := map[Count]uint64{{Kind: KindBook, Result: ResultHit}: 2, {Kind: KindBook, Result: ResultMiss}: 2,
+		{Kind: KindList, Result: ResultHit}: 2, {Kind: KindList, Result: ResultMiss}: 2}
+	for _, count := range storage.Counts() {
+		if count.Value != want[Count{Kind: count.Kind, Result: count.Result}] {
+			t.Errorf("TestStorage expected(%d) %s %s got (%d)", want[Count{Kind: count.Kind, Result: count.Result}], count.Kind, count.Result, count.Value)
+		}
+	}
+}
+
+func TestStorageReads(t *testing.T) {
+	tests := []struct {
+		testName string
+		cfg      config.Cache
+		err      error
+		bypass   bool
+		reads    int
+	}{
+		{"Read: should pass(cached)", testConfig(), nil, false, 1},
+		{"Read: should pass(bypassed)", testConfig(), nil, true, 3},
+		{"Read: should pass(list TTL of 0)", config.Cache{Size: 10, BookTTL: time.Minute}, nil, false, 3},
+		{"Read: should pass(size of 0)", config.Cache{BookTTL: time.Minute, ListTTL: time.Minute}, nil, false, 3},
+		{"Read: should fail(errors are not cached)", testConfig(), errors.New("forced query error"), false, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			stub := newStub()
+			stub.err = test.err
+			storage := NewStorage(stub, NewLRU(test.cfg.Size), test.cfg)
+			ctx := context.Background()
+			if test.bypass {
+				ctx = WithBypass(ctx)
+			}
+
+			for i := 0; i < 3; i++ {
+				books, total, err := storage.GenreBooks(ctx, []string{"fantasy"}, entity.Page{Offset: 2, Limit: 10})
+				if err != test.err {
+					t.Fatalf("%s expected(%v) got (%v)", test.testName, test.err, err)
+				}
+				if err == nil && (len(books) != 1 || total != 14) {
+					t.Fatalf("%s expected(1 book of 14) got (%d of %d)", test.testName, len(books), total)
+				}
+			}
+			if stub.reads["GenreBooks"] != test.reads {
+				t.Errorf("%s expected(%d) reads got (%d)", test.testName, test.reads, stub.reads["GenreBooks"])
+			}
+		})
+	}
+}
+
+func TestStorageInvalidateAll(t *testing.T) {
+	stub := newStub()
+	storage := NewStorage(stub, NewLRU(10), testConfig())
+	ctx := context.Background()
+
+	_, _ = storage.Get(ctx, "isbn-1")
+	_, _ = storage.Get(ctx, "isbn-2")
+	if _, err := storage.RenameTag(ctx, "scifi", "science-fiction"); err != nil {
+		t.Fatalf("TestStorageInvalidateAll expected(nil) got (%v)", err)
+	}
+	_, _ = storage.Get(ctx, "isbn-1")
+	_, _ = storage.Get(ctx, "isbn-2")
+	if stub.reads["Get"] != 4 {
+		t.Errorf("TestStorageInvalidateAll expected(4) reads got (%d)", stub.reads["Get"])
+	}
+}
+
+func TestStorageSlowLoad(t *testing.T) {
+	stub := newStub()
+	stub.loading, stub.release = make(chan struct{}), make(chan struct{})
+	storage := NewStorage(stub, NewLRU(10), testConfig())
+	ctx := context.Background()
+
+	// the load reads the book before the update and completes after the invalidation
+	loaded := make(chan *entity.Book)
+	go func() {
+		book, _ := storage.Get(ctx, "isbn-1")
+		loaded <- book
+	}()
+	<-stub.loading
+	stub.loading = nil
+	stub.title = "Jonathan Strange & Mr Norrell"
+	if err := storage.Upsert(ctx, "isbn-1", entity.Book{}); err != nil {
+		t.Fatalf("TestStorageSlowLoad expected(nil) got (%v)", err)
+	}
+	close(stub.release)
+	if book := <-loaded; book.Title != "Piranesi" {
+		t.Errorf("TestStorageSlowLoad expected(Piranesi) from the slow load got (%s)", book.Title)
+	}
+
+	book, _ := storage.Get(ctx, "isbn-1")
+	if book.Title != stub.title || stub.reads["Get"] != 2 {
+		t.Errorf("TestStorageSlowLoad expected(%s) read again got (%s) after (%d) reads", stub.title, book.Title, stub.reads["Get"])
+	}
+	_, _ = storage.Get(ctx, "isbn-1")
+	if stub.reads["Get"] != 2 {
+		t.Errorf("TestStorageSlowLoad expected the update cached got (%d) reads", stub.reads["Get"])
+	}
+}
+
+func TestLRU(t *testing.T) {
+	lru := NewLRU(2)
+	now := time.Now()
+	lru.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	lru.Set(ctx, "book:1", []byte("1"), time.Minute)
+	lru.Set(ctx, "book:2", []byte("2"), time.Minute)
+	_, _ = lru.Get(ctx, "book:1")
+	// book:2 is the least recently used
+	lru.Set(ctx, "list:all", []byte("all"), time.Second)
+	if _, ok := lru.Get(ctx, "book:2"); ok || lru.Len() != 2 {
+		t.Errorf("TestLRU expected book:2 evicted got (%v) with (%d) entries", ok, lru.Len())
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := lru.Get(ctx, "list:all"); ok {
+		t.Errorf("TestLRU expected list:all expired")
+	}
+
+	lru.Set(ctx, "list:all", []byte("all"), time.Minute)
+	lru.DeletePrefix(ctx, "list:")
+	if value, ok := lru.Get(ctx, "book:1"); !ok || string(value) != "1" || lru.Len() != 1 {
+		t.Errorf("TestLRU expected(book:1 only) got (%s, %v) with (%d) entries", value, ok, lru.Len())
+	}
+	lru.Delete(ctx, "book:1")
+	if lru.Len() != 0 {
+		t.Errorf("TestLRU expected(0) entries got (%d)", lru.Len())
+	}
+}
@@ -0,0 +1,286 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/grpcserver/booktrackerv1"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+// AddBook - checks the book and adds it to DB, the one with the same ISBN is replaced
+func (s *Server) AddBook(ctx context.Context, req *booktrackerv1.AddBookRequest) (*booktrackerv1.AddBookResponse, error) {
+	book := toBook(req.GetBook())
+	if msg := bookInvalid(book); msg != "" {
+		logging.FromContext(ctx).WithField(logging.FieldReason, msg).Error("AddBook invalid request")
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	if err := s.BookTracker.AddBook(ctx, book); err != nil {
+		logging.FromContext(ctx).WithError(err).WithField(logging.FieldPayload, book).Error("AddBook error")
+		return nil, statusError(err, "failed to save book.Refer to logs for more details")
+	}
+	return &booktrackerv1.AddBookResponse{}, nil
+}
+
+// UpdateBook - checks the book and updates it in DB. A bookmark on the last page only finishes the book with
+// auto_finish
+func (s *Server) UpdateBook(ctx context.Context, req *booktrackerv1.UpdateBookRequest) (*booktrackerv1.UpdateBookResponse, error) {
+	book := toBook(req.GetBook())
+	if !entity.StatusValid(book.Status) {
+		msg := fmt.Sprintf("Invalid status key. Expected one of %s", strings.Join(entity.Statuses, ", "))
+		logging.FromContext(ctx).WithField(logging.FieldReason, msg).Error("UpdateBook invalid request")
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	if msg := bookInvalid(book); msg != "" {
+		logging.FromContext(ctx).WithField(logging.FieldReason, msg).Error("UpdateBook invalid request")
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	lastPage := book.OnLastPage() && strings.ToUpper(book.Status) != entity.StatusFinished
+	if lastPage && req.GetAutoFinish() {
+		book.Status = entity.StatusFinished
+		lastPage = false
+	}
+
+	if err := s.BookTracker.UpdateBook(ctx, book); err != nil {
+		logging.FromContext(ctx).WithError(err).WithField(logging.FieldPayload, book).Error("UpdateBook error")
+		return nil, statusError(err, "operation failed.Refer to logs for more details")
+	}
+
+	msg := "book updated successfully"
+	if lastPage {
+		msg = fmt.Sprintf("%s. Bookmark is on the last page, set status to %s or retry with %s=true", msg, entity.StatusFinished, consts.AutoFinish)
+	}
+	return &booktrackerv1.UpdateBookResponse{Message: msg}, nil
+}
+
+// GetBook - gets the book with the ISBN from DB
+func (s *Server) GetBook(ctx context.Context, req *booktrackerv1.GetBookRequest) (*booktrackerv1.GetBookResponse, error) {
+	if req.GetIsbn() == "" {
+		return nil, status.Error(codes.InvalidArgument, "isbn is required")
+	}
+
+	book, err := s.BookTracker.GetBook(ctx, req.GetIsbn())
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField(logging.FieldISBN, req.GetIsbn()).Error("GetBook error")
+		return nil, statusError(err, "operation failed.Refer to logs for more details")
+	}
+	return &booktrackerv1.GetBookResponse{Book: fromBook(*book)}, nil
+}
+
+// ListBooks - the books matching the filter, in the sort order
+func (s *Server) ListBooks(ctx context.Context, req *booktrackerv1.ListBooksRequest) (*booktrackerv1.ListBooksResponse, error) {
+	books, err := s.listBooks(ctx, "ListBooks", req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &booktrackerv1.ListBooksResponse{Books: make([]*booktrackerv1.Book, 0, len(books))}
+	for _, book := range books {
+		resp.Books = append(resp.Books, fromBook(book))
+	}
+	return resp, nil
+}
+
+// StreamBooks - the books of ListBooks, one message per book so that the client can handle them as they arrive. The
+// list is loaded as a whole first, the service does not page it. The sends stop when the client goes away or the
+// deadline passes
+func (s *Server) StreamBooks(req *booktrackerv1.ListBooksRequest, stream booktrackerv1.BookTrackerService_StreamBooksServer) error {
+	books, err := s.listBooks(stream.Context(), "StreamBooks", req)
+	if err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		if err = stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err = stream.Send(fromBook(book)); err != nil {
+			logging.FromContext(stream.Context()).WithError(err).Error("StreamBooks send error")
+			return err
+		}
+	}
+	return nil
+}
+
+// listBooks - checks the sort and the filter and fetches the books from DB
+func (s *Server) listBooks(ctx context.Context, method string, req *booktrackerv1.ListBooksRequest) ([]entity.Book, error) {
+	if _, err := entity.ParseBookSort(req.GetSort()); err != nil {
+		logging.FromContext(ctx).WithError(err).Error(method + " invalid request")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !entity.FormatValid(req.GetFormat()) {
+		msg := fmt.Sprintf("Invalid format %s. Expected one of %s", req.GetFormat(), strings.Join(entity.Formats, ", "))
+		logging.FromContext(ctx).WithField(logging.FieldReason, msg).Error(method + " invalid request")
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	filter := entity.BookFilter{
+		Author:          req.GetAuthor(),
+		Series:          req.GetSeries(),
+		Publisher:       req.GetPublisher(),
+		Language:        req.GetLanguage(),
+		Format:          req.GetFormat(),
+		PublicationYear: int(req.GetPublicationYear()),
+		Tag:             req.GetTag(),
+	}
+	books, err := s.BookTracker.ListBooks(ctx, req.GetSort(), filter)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error(method + " error")
+		return nil, statusError(err, "failed to get books.Refer to logs for more details")
+	}
+	return books, nil
+}
+
+// GroupBooksByGenre - the genres and the books of each genre
+func (s *Server) GroupBooksByGenre(ctx context.Context, req *booktrackerv1.GroupBooksByGenreRequest) (*booktrackerv1.GroupBooksByGenreResponse, error) {
+	if req.GetSample() < 0 {
+		msg := fmt.Sprintf("Invalid sample %d. Expected a number that is not negative", req.GetSample())
+		logging.FromContext(ctx).WithField(logging.FieldReason, msg).Error("GroupBooksByGenre invalid request")
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	options := entity.GroupOptions{CountsOnly: req.GetCountsOnly(), Sample: int(req.GetSample())}
+	genres, err := s.BookTracker.GroupBooksByGenre(ctx, options)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("GroupBooksByGenre error")
+		return nil, statusError(err, "failed to get books.Refer to logs for more details")
+	}
+
+	resp := &booktrackerv1.GroupBooksByGenreResponse{Genres: make([]*booktrackerv1.GenreGroup, 0, len(genres))}
+	for _, genre := range genres {
+		group := &booktrackerv1.GenreGroup{Genre: genre.Genre, Parent: genre.Parent, Count: int32(genre.Count), Total: int32(genre.Total)}
+		for _, book := range genre.Books {
+			group.Books = append(group.Books, fromBook(book))
+		}
+		resp.Genres = append(resp.Genres, group)
+	}
+	return resp, nil
+}
+
+// bookInvalid - the fields the REST api requires(binding:"required"), then the checks of entity.Book.Invalid
+func bookInvalid(book entity.Book) string {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"isbn", book.ISBN},
+		{"title", book.Title},
+		{"author", book.Author},
+		{"genre", book.Genre},
+	} {
+		if field.value == "" {
+			return fmt.Sprintf("Invalid %s. Expected a value", field.name)
+		}
+	}
+	return book.Invalid()
+}
+
+// statusError - the status of the domain error, as the REST api maps it: NOT_FOUND, ALREADY_EXISTS, UNAVAILABLE(with
+// the retry delay when known), DEADLINE_EXCEEDED, CANCELLED and INTERNAL with the message otherwise
+func statusError(err error, message string) error {
+	var notFound entity.NotFoundError
+	var conflict entity.ConflictError
+	var unavailable entity.UnavailableError
+	var timeout entity.TimeoutError
+	var canceled entity.CanceledError
+	switch {
+	case errors.As(err, &notFound):
+		return status.Error(codes.NotFound, notFound.Error())
+	case errors.As(err, &conflict):
+		return status.Error(codes.AlreadyExists, conflict.Error())
+	case errors.As(err, &unavailable):
+		st := status.New(codes.Unavailable, "service temporarily unavailable.Retry later")
+		if unavailable.RetryAfter <= 0 {
+			return st.Err()
+		}
+		if detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(unavailable.RetryAfter)}); detailsErr == nil {
+			st = detailed
+		}
+		return st.Err()
+	case errors.As(err, &timeout):
+		return status.Error(codes.DeadlineExceeded, "request timed out.Refer to logs for more details")
+	case errors.As(err, &canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// toBook - the book of a request, with the author string and the contributors filled from each other
+func toBook(b *booktrackerv1.Book) entity.Book {
+	book := entity.Book{
+		ISBN:            b.GetIsbn(),
+		Title:           b.GetTitle(),
+		Author:          b.GetAuthor(),
+		Genre:           b.GetGenre(),
+		Status:          b.GetStatus(),
+		Bookmark:        int(b.GetBookmark()),
+		PageCount:       int(b.GetPageCount()),
+		DurationMinutes: int(b.GetDurationMinutes()),
+		Created:         b.GetCreated(),
+		Updated:         b.GetUpdated(),
+		CreatedBy:       b.GetCreatedBy(),
+		UpdatedBy:       b.GetUpdatedBy(),
+		Started:         b.GetStarted(),
+		Finished:        b.GetFinished(),
+		Publisher:       b.GetPublisher(),
+		PublicationYear: int(b.GetPublicationYear()),
+		Language:        b.GetLanguage(),
+		Format:          b.GetFormat(),
+		Description:     b.GetDescription(),
+		Tags:            b.GetTags(),
+	}
+	for _, contributor := range b.GetAuthors() {
+		book.Authors = append(book.Authors, entity.Contributor{Name: contributor.GetName(), Role: contributor.GetRole()})
+	}
+	if series := b.GetSeries(); series != nil {
+		book.Series = &entity.Series{Name: series.GetName(), Position: series.GetPosition()}
+	}
+	book.NormalizeAuthors()
+	return book
+}
+
+func fromBook(book entity.Book) *booktrackerv1.Book {
+	b := &booktrackerv1.Book{
+		Isbn:            book.ISBN,
+		Title:           book.Title,
+		Author:          book.Author,
+		Genre:           book.Genre,
+		Status:          book.Status,
+		Bookmark:        int32(book.Bookmark),
+		PageCount:       int32(book.PageCount),
+		DurationMinutes: int32(book.DurationMinutes),
+		PercentComplete: book.PercentComplete,
+		Created:         book.Created,
+		Updated:         book.Updated,
+		CreatedBy:       book.CreatedBy,
+		UpdatedBy:       book.UpdatedBy,
+		Started:         book.Started,
+		Finished:        book.Finished,
+		Publisher:       book.Publisher,
+		PublicationYear: int32(book.PublicationYear),
+		Language:        book.Language,
+		Format:          book.Format,
+		Description:     book.Description,
+		Tags:            book.Tags,
+		Rating:          book.Rating,
+	}
+	for _, contributor := range book.Authors {
+		b.Authors = append(b.Authors, &booktrackerv1.Contributor{Name: contributor.Name, Role: contributor.Role})
+	}
+	if book.Series != nil {
+		b.Series = &booktrackerv1.Series{Name: book.Series.Name, Position: book.Series.Position}
+	}
+	return b
+}
@@ -0,0 +1,334 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: booktracker/v1/book_tracker.proto
+
+package booktrackerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BookTrackerService_AddBook_FullMethodName           = "/booktracker.v1.BookTrackerService/AddBook"
+	BookTrackerService_UpdateBook_FullMethodName        = "/booktracker.v1.BookTrackerService/UpdateBook"
+	BookTrackerService_GetBook_FullMethodName           = "/booktracker.v1.BookTrackerService/GetBook"
+	BookTrackerService_ListBooks_FullMethodName         = "/booktracker.v1.BookTrackerService/ListBooks"
+	BookTrackerService_StreamBooks_FullMethodName       = "/booktracker.v1.BookTrackerService/StreamBooks"
+	BookTrackerService_GroupBooksByGenre_FullMethodName = "/booktracker.v1.BookTrackerService/GroupBooksByGenre"
+)
+
+// BookTrackerServiceClient is the client API for BookTrackerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookTrackerServiceClient interface {
+	// AddBook - tracks the book, the one with the same ISBN is replaced
+	AddBook(ctx context.Context, in *AddBookRequest, opts ...grpc.CallOption) (*AddBookResponse, error)
+	// UpdateBook - NOT_FOUND when the book is not tracked
+	UpdateBook(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*UpdateBookResponse, error)
+	// GetBook - NOT_FOUND when the book is not tracked
+	GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*GetBookResponse, error)
+	// ListBooks - the books matching the filter, in the sort order
+	ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (*ListBooksResponse, error)
+	// StreamBooks - the books of ListBooks, one message per book. The list is loaded as a whole before the first one
+	StreamBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (BookTrackerService_StreamBooksClient, error)
+	// GroupBooksByGenre - the genres with their books, rolled up to the parent genres
+	GroupBooksByGenre(ctx context.Context, in *GroupBooksByGenreRequest, opts ...grpc.CallOption) (*GroupBooksByGenreResponse, error)
+}
+
+type bookTrackerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookTrackerServiceClient(cc grpc.ClientConnInterface) BookTrackerServiceClient {
+	return &bookTrackerServiceClient{cc}
+}
+
+func (c *bookTrackerServiceClient) AddBook(ctx context.Context, in *AddBookRequest, opts ...grpc.CallOption) (*AddBookResponse, error) {
+	out := new(AddBookResponse)
+	err := c.cc.Invoke(ctx, BookTrackerService_AddBook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookTrackerServiceClient) UpdateBook(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*UpdateBookResponse, error) {
+	out := new(UpdateBookResponse)
+	err := c.cc.Invoke(ctx, BookTrackerService_UpdateBook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookTrackerServiceClient) GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*GetBookResponse, error) {
+	out := new(GetBookResponse)
+	err := c.cc.Invoke(ctx, BookTrackerService_GetBook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookTrackerServiceClient) ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (*ListBooksResponse, error) {
+	out := new(ListBooksResponse)
+	err := c.cc.Invoke(ctx, BookTrackerService_ListBooks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookTrackerServiceClient) StreamBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (BookTrackerService_StreamBooksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BookTrackerService_ServiceDesc.Streams[0], BookTrackerService_StreamBooks_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookTrackerServiceStreamBooksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BookTrackerService_StreamBooksClient interface {
+	Recv() (*Book, error)
+	grpc.ClientStream
+}
+
+type bookTrackerServiceStreamBooksClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookTrackerServiceStreamBooksClient) Recv() (*Book, error) {
+	m := new(Book)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bookTrackerServiceClient) GroupBooksByGenre(ctx context.Context, in *GroupBooksByGenreRequest, opts ...grpc.CallOption) (*GroupBooksByGenreResponse, error) {
+	out := new(GroupBooksByGenreResponse)
+	err := c.cc.Invoke(ctx, BookTrackerService_GroupBooksByGenre_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BookTrackerServiceServer is the server API for BookTrackerService service.
+// All implementations must embed UnimplementedBookTrackerServiceServer
+// for forward compatibility
+type BookTrackerServiceServer interface {
+	// AddBook - tracks the book, the one with the same ISBN is replaced
+	AddBook(context.Context, *AddBookRequest) (*AddBookResponse, error)
+	// UpdateBook - NOT_FOUND when the book is not tracked
+	UpdateBook(context.Context, *UpdateBookRequest) (*UpdateBookResponse, error)
+	// GetBook - NOT_FOUND when the book is not tracked
+	GetBook(context.Context, *GetBookRequest) (*GetBookResponse, error)
+	// ListBooks - the books matching the filter, in the sort order
+	ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error)
+	// StreamBooks - the books of ListBooks, one message per book. The list is loaded as a whole before the first one
+	StreamBooks(*ListBooksRequest, BookTrackerService_StreamBooksServer) error
+	// GroupBooksByGenre - the genres with their books, rolled up to the parent genres
+	GroupBooksByGenre(context.Context, *GroupBooksByGenreRequest) (*GroupBooksByGenreResponse, error)
+	mustEmbedUnimplementedBookTrackerServiceServer()
+}
+
+// UnimplementedBookTrackerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBookTrackerServiceServer struct {
+}
+
+func (UnimplementedBookTrackerServiceServer) AddBook(context.Context, *AddBookRequest) (*AddBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBook not implemented")
+}
+func (UnimplementedBookTrackerServiceServer) UpdateBook(context.Context, *UpdateBookRequest) (*UpdateBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateBook not implemented")
+}
+func (UnimplementedBookTrackerServiceServer) GetBook(context.Context, *GetBookRequest) (*GetBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
+}
+func (UnimplementedBookTrackerServiceServer) ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
+}
+func (UnimplementedBookTrackerServiceServer) StreamBooks(*ListBooksRequest, BookTrackerService_StreamBooksServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBooks not implemented")
+}
+func (UnimplementedBookTrackerServiceServer) GroupBooksByGenre(context.Context, *GroupBooksByGenreRequest) (*GroupBooksByGenreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GroupBooksByGenre not implemented")
+}
+func (UnimplementedBookTrackerServiceServer) mustEmbedUnimplementedBookTrackerServiceServer() {}
+
+// UnsafeBookTrackerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookTrackerServiceServer will
+// result in compilation errors.
+type UnsafeBookTrackerServiceServer interface {
+	mustEmbedUnimplementedBookTrackerServiceServer()
+}
+
+func RegisterBookTrackerServiceServer(s grpc.ServiceRegistrar, srv BookTrackerServiceServer) {
+	s.RegisterService(&BookTrackerService_ServiceDesc, srv)
+}
+
+func _BookTrackerService_AddBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookTrackerServiceServer).AddBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookTrackerService_AddBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookTrackerServiceServer).AddBook(ctx, req.(*AddBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookTrackerService_UpdateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookTrackerServiceServer).UpdateBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookTrackerService_UpdateBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookTrackerServiceServer).UpdateBook(ctx, req.(*UpdateBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookTrackerService_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookTrackerServiceServer).GetBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookTrackerService_GetBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookTrackerServiceServer).GetBook(ctx, req.(*GetBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookTrackerService_ListBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookTrackerServiceServer).ListBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookTrackerService_ListBooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookTrackerServiceServer).ListBooks(ctx, req.(*ListBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookTrackerService_StreamBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookTrackerServiceServer).StreamBooks(m, &bookTrackerServiceStreamBooksServer{stream})
+}
+
+type BookTrackerService_StreamBooksServer interface {
+	Send(*Book) error
+	grpc.ServerStream
+}
+
+type bookTrackerServiceStreamBooksServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookTrackerServiceStreamBooksServer) Send(m *Book) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BookTrackerService_GroupBooksByGenre_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupBooksByGenreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookTrackerServiceServer).GroupBooksByGenre(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookTrackerService_GroupBooksByGenre_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookTrackerServiceServer).GroupBooksByGenre(ctx, req.(*GroupBooksByGenreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BookTrackerService_ServiceDesc is the grpc.ServiceDesc for BookTrackerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BookTrackerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "booktracker.v1.BookTrackerService",
+	HandlerType: (*BookTrackerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddBook",
+			Handler:    _BookTrackerService_AddBook_Handler,
+		},
+		{
+			MethodName: "UpdateBook",
+			Handler:    _BookTrackerService_UpdateBook_Handler,
+		},
+		{
+			MethodName: "GetBook",
+			Handler:    _BookTrackerService_GetBook_Handler,
+		},
+		{
+			MethodName: "ListBooks",
+			Handler:    _BookTrackerService_ListBooks_Handler,
+		},
+		{
+			MethodName: "GroupBooksByGenre",
+			Handler:    _BookTrackerService_GroupBooksByGenre_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBooks",
+			Handler:       _BookTrackerService_StreamBooks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "booktracker/v1/book_tracker.proto",
+}
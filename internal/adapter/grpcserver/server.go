@@ -0,0 +1,224 @@
+// Package grpcserver serves the books of service.BookTracker over gRPC(booktracker.v1.BookTrackerService), next to the
+// REST api, with the health and reflection services. The messages are generated from api/proto(see make proto)
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/grpcserver/booktrackerv1"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
+)
+
+// the metadata keys of the HTTP headers, gRPC metadata keys are lower case
+var (
+	requestIDKey = strings.ToLower(logging.RequestIDHeader)
+	bypassKey    = strings.ToLower(cache.BypassHeader)
+)
+
+type Server struct {
+	booktrackerv1.UnimplementedBookTrackerServiceServer
+	Config      config.Config
+	BookTracker service.BookTracker
+
+	health *health.Server
+}
+
+// NewServer - the health service reports the BookTrackerService as serving until the shutdown
+func NewServer(cfg config.Config, books service.BookTracker) *Server {
+	s := &Server{Config: cfg, BookTracker: books, health: health.NewServer()}
+	s.health.SetServingStatus(booktrackerv1.BookTrackerService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return s
+}
+
+// Serve - serves on the gRPC port until the context is done. The health service reports NOT_SERVING first, after the
+// shutdown drain delay the server stops accepting calls and waits up to the shutdown timeout for the ones in flight
+func (s *Server) Serve(ctx context.Context) error {
+	l := logrus.StandardLogger()
+
+	srv, err := s.newGRPCServer()
+	if err != nil {
+		l.WithError(err).Error("gRPC TLS configuration error")
+		return err
+	}
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(s.Config.GRPC.Port))
+	if err != nil {
+		l.WithError(err).Error("gRPC Listen error")
+		return err
+	}
+
+	l.WithFields(logrus.Fields{"port": s.Config.GRPC.Port, "tls": s.Config.Server.TLS.CertFile != "",
+		"reflection": s.Config.GRPC.Reflection}).Info("starting gRPC server")
+	return s.serve(ctx, srv, ln)
+}
+
+func (s *Server) serve(ctx context.Context, srv *grpc.Server, ln net.Listener) error {
+	l := logrus.StandardLogger()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		l.WithError(err).Error("gRPC Serve error")
+		return err
+	case <-ctx.Done():
+	}
+
+	drainDelay := s.Config.Server.ShutdownDrainDelay
+	l.WithField("drain_delay", drainDelay.String()).Info("shutdown requested, gRPC health not serving before draining")
+	s.health.Shutdown()
+	time.Sleep(drainDelay)
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	timeout := s.Config.Server.ShutdownTimeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		l.WithField("shutdown_timeout", timeout.String()).Error("GracefulStop error, calls still in flight are cut")
+		srv.Stop()
+		<-stopped
+		return errors.New("gRPC shutdown timed out")
+	}
+	if err := <-errs; err != nil {
+		return err
+	}
+	l.Info("gRPC server drained and stopped")
+	return nil
+}
+
+// newGRPCServer - the BookTrackerService, the health service and, when enabled, the reflection service. It serves
+// TLS(and mTLS) with the settings of the HTTP server, and receives messages up to the request body limit
+func (s *Server) newGRPCServer() (*grpc.Server, error) {
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(s.Config.Server.MaxBodyBytes)),
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	}
+	if s.Config.Server.TLS.CertFile != "" {
+		tlsConfig, err := certs.ServerConfig(s.Config.Server.TLS)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	srv := grpc.NewServer(options...)
+	booktrackerv1.RegisterBookTrackerServiceServer(srv, s)
+	healthpb.RegisterHealthServer(srv, s.health)
+	if s.Config.GRPC.Reflection {
+		reflection.Register(srv)
+	}
+	return srv, nil
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	resp, err := handler(ctx, req)
+	accessLog(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, cancel := s.requestContext(ss.Context())
+	defer cancel()
+
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	accessLog(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// serverStream - the stream with the context of the call
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestContext - the context of a call, as the middlewares of the REST api build it: a logger with the request
+// id(x-request-id metadata, generated when missing and sent back in the header), the cache bypass(x-cache-bypass
+// metadata) and the request timeout. A shorter deadline of the client is kept
+func (s *Server) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := firstValue(md, requestIDKey)
+	if !logging.ValidRequestID(requestID) {
+		requestID = logging.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	ctx = logging.WithLogger(ctx, logrus.WithField(logging.FieldRequestID, requestID))
+
+	if bypass, _ := strconv.ParseBool(firstValue(md, bypassKey)); bypass {
+		ctx = cache.WithBypass(ctx)
+	}
+	if timeout := s.Config.Server.RequestTimeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// accessLog - one line per call, an error for the codes of the server failures and a warning for the other failures
+func accessLog(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := logrus.Fields{
+		logging.FieldRoute:   method,
+		logging.FieldStatus:  code.String(),
+		logging.FieldLatency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		fields[logging.FieldClientIP] = host
+	}
+
+	access := logging.FromContext(ctx).WithFields(fields)
+	switch code {
+	case codes.OK:
+		access.Info("request completed")
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented, codes.Unavailable, codes.DeadlineExceeded:
+		access.Error("request completed")
+	default:
+		access.Warn("request completed")
+	}
+}
@@ -0,0 +1,385 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/grpcserver/booktrackerv1"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
+)
+
+// stubBookTracker - keeps the books in a map, err fails every call
+type stubBookTracker struct {
+	service.BookTracker
+	books    map[string]entity.Book
+	err      error
+	bypassed bool
+}
+
+func (s *stubBookTracker) AddBook(_ context.Context, book entity.Book) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.books[book.ISBN] = book
+	return nil
+}
+
+func (s *stubBookTracker) UpdateBook(_ context.Context, book entity.Book) error {
+	if s.err != nil {
+		return s.err
+	}
+	if _, ok := s.books[book.ISBN]; !ok {
+		return entity.NotFoundError{Message: "book not found"}
+	}
+	s.books[book.ISBN] = book
+	return nil
+}
+
+func (s *stubBookTracker) GetBook(ctx context.Context, isbn string) (*entity.Book, error) {
+	s.bypassed = cache.Bypassed(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+	book, ok := s.books[isbn]
+	if !ok {
+		return nil, entity.NotFoundError{Message: "book not found"}
+	}
+	return &book, nil
+}
+
+func (s *stubBookTracker) ListBooks(context.Context, string, entity.BookFilter) ([]entity.Book, error) {
+	var books []entity.Book
+	for _, isbn := range []string{"isbn-1", "isbn-2", "isbn-3"} {
+		if book, ok := s.books[isbn]; ok {
+			books = append(books, book)
+		}
+	}
+	return books, s.err
+}
+
+func (s *stubBookTracker) GroupBooksByGenre(_ context.Context, options entity.GroupOptions) ([]entity.BooksByGenre, error) {
+	group := entity.BooksByGenre{Genre: "fantasy", Count: len(s.books), Total: len(s.books)}
+	if !options.CountsOnly {
+		for _, book := range s.books {
+			group.Books = append(group.Books, book)
+		}
+	}
+	return []entity.BooksByGenre{group}, s.err
+}
+
+// startServer - the server on an in-memory listener, stopped with the test
+func startServer(t *testing.T, stub *stubBookTracker) (*grpc.ClientConn, *Server) {
+	cfg := config.Default()
+	cfg.Server.ShutdownDrainDelay = 0
+	s := NewServer(cfg, stub)
+	srv, err := s.newGRPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.serve(ctx, srv, ln)
+	}()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve expected(nil) got (%v)", err)
+		}
+	})
+	return conn, s
+}
+
+func newStub() *stubBookTracker {
+	return &stubBookTracker{books: map[string]entity.Book{
+		"isbn-1": {ISBN: "isbn-1", Title: "Piranesi", Author: "Susanna Clarke", Genre: "fantasy", PageCount: 272},
+		"isbn-2": {ISBN: "isbn-2", Title: "Circe", Author: "Madeline Miller", Genre: "fantasy"},
+	}}
+}
+
+func TestBooks(t *testing.T) {
+	stub := newStub()
+	conn, _ := startServer(t, stub)
+	client := booktrackerv1.NewBookTrackerServiceClient(conn)
+	ctx := context.Background()
+
+	book := &booktrackerv1.Book{Isbn: "isbn-3", Title: "The Hobbit", Genre: "fantasy", PageCount: 310, Rating: 7.3,
+		Authors: []*booktrackerv1.Contributor{{Name: "J.R.R. Tolkien"}}, Series: &booktrackerv1.Series{Name: "Middle-earth", Position: 1}}
+	if _, err := client.AddBook(ctx, &booktrackerv1.AddBookRequest{Book: book}); err != nil {
+		t.Fatalf("TestBooks expected(nil) got (%v)", err)
+	}
+	added := stub.books["isbn-3"]
+	if added.Author != "J.R.R. Tolkien" || added.Authors[0].Role != entity.RoleAuthor || added.Series.Name != "Middle-earth" {
+		t.Errorf("TestBooks expected the author string and role filled got (%+v)", added)
+	}
+	if added.Rating != 0 {
+		t.Errorf("TestBooks expected the rating ignored got (%v)", added.Rating)
+	}
+
+	resp, err := client.GetBook(ctx, &booktrackerv1.GetBookRequest{Isbn: "isbn-3"})
+	if err != nil || resp.GetBook().GetTitle() != "The Hobbit" || resp.GetBook().GetSeries().GetPosition() != 1 {
+		t.Errorf("TestBooks expected(The Hobbit) got (%v, %v)", resp, err)
+	}
+
+	update, err := client.UpdateBook(ctx, &booktrackerv1.UpdateBookRequest{Book: &booktrackerv1.Book{Isbn: "isbn-1",
+		Title: "Piranesi", Author: "Susanna Clarke", Genre: "fantasy", PageCount: 272, Bookmark: 272, Status: entity.StatusInProgress}})
+	if err != nil || update.GetMessage() != "book updated successfully. Bookmark is on the last page, set status to FINISHED or retry with auto_finish=true" {
+		t.Errorf("TestBooks expected the last page hint got (%v, %v)", update, err)
+	}
+	_, err = client.UpdateBook(ctx, &booktrackerv1.UpdateBookRequest{AutoFinish: true, Book: &booktrackerv1.Book{Isbn: "isbn-1",
+		Title: "Piranesi", Author: "Susanna Clarke", Genre: "fantasy", PageCount: 272, Bookmark: 272, Status: entity.StatusInProgress}})
+	if err != nil || stub.books["isbn-1"].Status != entity.StatusFinished {
+		t.Errorf("TestBooks expected(%s) got (%s, %v)", entity.StatusFinished, stub.books["isbn-1"].Status, err)
+	}
+
+	list, err := client.ListBooks(ctx, &booktrackerv1.ListBooksRequest{Sort: "title"})
+	if err != nil || len(list.GetBooks()) != 3 {
+		t.Errorf("TestBooks expected(3) books got (%v, %v)", list, err)
+	}
+
+	groups, err := client.GroupBooksByGenre(ctx, &booktrackerv1.GroupBooksByGenreRequest{CountsOnly: true})
+	if err != nil || len(groups.GetGenres()) != 1 || groups.GetGenres()[0].GetCount() != 3 || len(groups.GetGenres()[0].GetBooks()) != 0 {
+		t.Errorf("TestBooks expected(fantasy, 3 books, counts only) got (%v, %v)", groups, err)
+	}
+}
+
+func TestStreamBooks(t *testing.T) {
+	conn, _ := startServer(t, newStub())
+	client := booktrackerv1.NewBookTrackerServiceClient(conn)
+
+	stream, err := client.StreamBooks(context.Background(), &booktrackerv1.ListBooksRequest{})
+	if err != nil {
+		t.Fatalf("TestStreamBooks expected(nil) got (%v)", err)
+	}
+	var titles []string
+	for {
+		book, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("TestStreamBooks expected(nil) got (%v)", err)
+		}
+		titles = append(titles, book.GetTitle())
+	}
+	if len(titles) != 2 || titles[0] != "Piranesi" || titles[1] != "Circe" {
+		t.Errorf("TestStreamBooks expected([Piranesi Circe]) got (%v)", titles)
+	}
+
+	stream, err = client.StreamBooks(context.Background(), &booktrackerv1.ListBooksRequest{Format: "scroll"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("TestStreamBooks expected(%s) got (%v)", codes.InvalidArgument, err)
+	}
+}
+
+// canceledStream - a StreamBooks stream whose client goes away after the first book
+type canceledStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	sent   int
+}
+
+func (c *canceledStream) Context() context.Context {
+	return c.ctx
+}
+
+func (c *canceledStream) Send(*booktrackerv1.Book) error {
+	c.sent++
+	c.cancel()
+	return nil
+}
+
+func TestStreamBooksCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &canceledStream{ctx: ctx, cancel: cancel}
+
+	err := NewServer(config.Default(), newStub()).StreamBooks(&booktrackerv1.ListBooksRequest{}, stream)
+	if status.Code(err) != codes.Canceled || stream.sent != 1 {
+		t.Errorf("TestStreamBooksCanceled expected(%s after 1 book) got (%v after %d)", codes.Canceled, err, stream.sent)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	valid := &booktrackerv1.Book{Isbn: "isbn-9", Title: "Dune", Author: "Frank Herbert", Genre: "science fiction"}
+	tests := []struct {
+		testName string
+		err      error
+		call     func(booktrackerv1.BookTrackerServiceClient) error
+		code     codes.Code
+	}{
+		{"AddBook: should fail(missing title)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.AddBook(context.Background(), &booktrackerv1.AddBookRequest{Book: &booktrackerv1.Book{Isbn: "isbn-9", Author: "Frank Herbert", Genre: "science fiction"}})
+			return err
+		}, codes.InvalidArgument},
+		{"AddBook: should fail(invalid format)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			book := &booktrackerv1.Book{Isbn: "isbn-9", Title: "Dune", Author: "Frank Herbert", Genre: "science fiction", Format: "scroll"}
+			_, err := c.AddBook(context.Background(), &booktrackerv1.AddBookRequest{Book: book})
+			return err
+		}, codes.InvalidArgument},
+		{"AddBook: should fail(conflict)", entity.ConflictError{Message: "book changed"}, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.AddBook(context.Background(), &booktrackerv1.AddBookRequest{Book: valid})
+			return err
+		}, codes.AlreadyExists},
+		{"UpdateBook: should fail(invalid status)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			book := &booktrackerv1.Book{Isbn: "isbn-1", Title: "Piranesi", Author: "Susanna Clarke", Genre: "fantasy", Status: "ABANDONED"}
+			_, err := c.UpdateBook(context.Background(), &booktrackerv1.UpdateBookRequest{Book: book})
+			return err
+		}, codes.InvalidArgument},
+		{"UpdateBook: should fail(not found)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.UpdateBook(context.Background(), &booktrackerv1.UpdateBookRequest{Book: valid})
+			return err
+		}, codes.NotFound},
+		{"GetBook: should fail(not found)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.GetBook(context.Background(), &booktrackerv1.GetBookRequest{Isbn: "isbn-9"})
+			return err
+		}, codes.NotFound},
+		{"GetBook: should fail(missing isbn)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.GetBook(context.Background(), &booktrackerv1.GetBookRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"GetBook: should fail(timeout)", entity.TimeoutError{Message: "get timed out"}, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.GetBook(context.Background(), &booktrackerv1.GetBookRequest{Isbn: "isbn-1"})
+			return err
+		}, codes.DeadlineExceeded},
+		{"GetBook: should fail(canceled)", entity.CanceledError{Message: "get canceled"}, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.GetBook(context.Background(), &booktrackerv1.GetBookRequest{Isbn: "isbn-1"})
+			return err
+		}, codes.Canceled},
+		{"ListBooks: should fail(invalid sort)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.ListBooks(context.Background(), &booktrackerv1.ListBooksRequest{Sort: "colour"})
+			return err
+		}, codes.InvalidArgument},
+		{"ListBooks: should fail(query error)", entity.NotFoundError{Message: "bucket not found"}, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.ListBooks(context.Background(), &booktrackerv1.ListBooksRequest{})
+			return err
+		}, codes.NotFound},
+		{"GroupBooksByGenre: should fail(negative sample)", nil, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.GroupBooksByGenre(context.Background(), &booktrackerv1.GroupBooksByGenreRequest{Sample: -1})
+			return err
+		}, codes.InvalidArgument},
+		{"GroupBooksByGenre: should fail(query error)", io.ErrUnexpectedEOF, func(c booktrackerv1.BookTrackerServiceClient) error {
+			_, err := c.GroupBooksByGenre(context.Background(), &booktrackerv1.GroupBooksByGenreRequest{})
+			return err
+		}, codes.Internal},
+	}
+
+	stub := newStub()
+	conn, _ := startServer(t, stub)
+	client := booktrackerv1.NewBookTrackerServiceClient(conn)
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			stub.err = test.err
+			if code := status.Code(test.call(client)); code != test.code {
+				t.Errorf("%s expected(%s) got (%s)", test.testName, test.code, code)
+			}
+		})
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	stub := newStub()
+	stub.err = entity.UnavailableError{Message: "circuit open", RetryAfter: 1500 * time.Millisecond}
+	conn, _ := startServer(t, stub)
+	client := booktrackerv1.NewBookTrackerServiceClient(conn)
+
+	_, err := client.GetBook(context.Background(), &booktrackerv1.GetBookRequest{Isbn: "isbn-1"})
+	st := status.Convert(err)
+	if st.Code() != codes.Unavailable || len(st.Details()) != 1 {
+		t.Fatalf("TestUnavailable expected(%s with the retry info) got (%v)", codes.Unavailable, err)
+	}
+	if info, ok := st.Details()[0].(*errdetails.RetryInfo); !ok || info.GetRetryDelay().AsDuration() != 1500*time.Millisecond {
+		t.Errorf("TestUnavailable expected(1.5s) got (%v)", st.Details()[0])
+	}
+}
+
+func TestRequestMetadata(t *testing.T) {
+	stub := newStub()
+	conn, _ := startServer(t, stub)
+	client := booktrackerv1.NewBookTrackerServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "request-1", bypassKey, "true")
+	var header metadata.MD
+	if _, err := client.GetBook(ctx, &booktrackerv1.GetBookRequest{Isbn: "isbn-1"}, grpc.Header(&header)); err != nil {
+		t.Fatalf("TestRequestMetadata expected(nil) got (%v)", err)
+	}
+	if ids := header.Get(requestIDKey); len(ids) != 1 || ids[0] != "request-1" || !stub.bypassed {
+		t.Errorf("TestRequestMetadata expected(request-1, bypassed) got (%v, %v)", ids, stub.bypassed)
+	}
+
+	header = nil
+	if _, err := client.GetBook(context.Background(), &booktrackerv1.GetBookRequest{Isbn: "isbn-1"}, grpc.Header(&header)); err != nil {
+		t.Fatalf("TestRequestMetadata expected(nil) got (%v)", err)
+	}
+	if ids := header.Get(requestIDKey); len(ids) != 1 || len(ids[0]) != 32 || stub.bypassed {
+		t.Errorf("TestRequestMetadata expected(a generated id, not bypassed) got (%v, %v)", ids, stub.bypassed)
+	}
+}
+
+func TestHealthAndReflection(t *testing.T) {
+	conn, s := startServer(t, newStub())
+	ctx := context.Background()
+
+	health := healthpb.NewHealthClient(conn)
+	for _, name := range []string{"", booktrackerv1.BookTrackerService_ServiceDesc.ServiceName} {
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: name})
+		if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("TestHealthAndReflection expected(SERVING) %q got (%v, %v)", name, resp, err)
+		}
+	}
+
+	stream, err := grpc_reflection_v1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("TestHealthAndReflection expected(nil) got (%v)", err)
+	}
+	found := false
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		found = found || service.GetName() == booktrackerv1.BookTrackerService_ServiceDesc.ServiceName
+	}
+	if !found {
+		t.Errorf("TestHealthAndReflection expected(%s) listed got (%v)", booktrackerv1.BookTrackerService_ServiceDesc.ServiceName, resp)
+	}
+	_ = stream.CloseSend()
+
+	s.health.Shutdown()
+	resp2, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp2.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("TestHealthAndReflection expected(NOT_SERVING) after the shutdown got (%v, %v)", resp2, err)
+	}
+}
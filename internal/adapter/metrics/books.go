@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
+)
+
+var booksByStatus = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "books"),
+	"Books tracked, by status.", []string{"status"}, nil)
+
+// collectTimeout - the time the books per status count gets, below the default scrape timeout of Prometheus(10s)
+const collectTimeout = 5 * time.Second
+
+// booksCollector - counts the books per status when scraped(a N1QL aggregate on couchbase), so that the gauge never
+// drifts from the repository
+type booksCollector struct {
+	books service.BookTracker
+}
+
+// RegisterBooks - adds the books per status gauge to the Registry
+func RegisterBooks(books service.BookTracker) error {
+	return Registry.Register(&booksCollector{books: books})
+}
+
+func (b *booksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- booksByStatus
+}
+
+func (b *booksCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	groups, err := b.books.GroupBooks(ctx, entity.GroupByStatus, entity.GroupOptions{CountsOnly: true})
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(booksByStatus, err)
+		return
+	}
+	for _, group := range groups {
+		ch <- prometheus.MustNewConstMetric(booksByStatus, prometheus.GaugeValue, float64(group.Count), group.Group)
+	}
+}
+
+// reviewTracker - counts the book exports
+type reviewTracker struct {
+	service.ReviewTracker
+}
+
+// NewReviewTracker - decorates the review tracker with the book export counters
+func NewReviewTracker(tracker service.ReviewTracker) service.ReviewTracker {
+	return &reviewTracker{ReviewTracker: tracker}
+}
+
+func (r *reviewTracker) ExportBooks(ctx context.Context) ([]entity.BookExport, error) {
+	books, err := r.ReviewTracker.ExportBooks(ctx)
+	if err == nil {
+		exports.WithLabelValues("books").Inc()
+		exportedItems.WithLabelValues("books").Add(float64(len(books)))
+	}
+	return books, err
+}
+
+// highlightTracker - counts the clippings imports and the highlight exports
+type highlightTracker struct {
+	service.HighlightTracker
+}
+
+// NewHighlightTracker - decorates the highlight tracker with the import and export counters
+func NewHighlightTracker(tracker service.HighlightTracker) service.HighlightTracker {
+	return &highlightTracker{HighlightTracker: tracker}
+}
+
+func (h *highlightTracker) ImportClippings(ctx context.Context, r io.Reader) (*entity.ImportResult, error) {
+	result, err := h.HighlightTracker.ImportClippings(ctx, r)
+	if err == nil && result != nil {
+		importedHighlights.WithLabelValues("imported").Add(float64(result.Imported))
+		importedHighlights.WithLabelValues("duplicate").Add(float64(result.Duplicates))
+		importedHighlights.WithLabelValues("skipped").Add(float64(result.Skipped))
+		for _, book := range result.Unmatched {
+			importedHighlights.WithLabelValues("unmatched").Add(float64(book.Count))
+		}
+	}
+	return result, err
+}
+
+func (h *highlightTracker) ExportMarkdown(ctx context.Context) ([]byte, error) {
+	markdown, err := h.HighlightTracker.ExportMarkdown(ctx)
+	if err == nil {
+		exports.WithLabelValues("highlights").Inc()
+	}
+	return markdown, err
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+)
+
+var cacheRequests = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "cache_requests_total"),
+	"Repository cache lookups, by kind(book, list) and result(hit, miss, bypass).", []string{"kind", "result"}, nil)
+
+// cacheCollector - reads the lookup counts of the cache decorator when scraped
+type cacheCollector struct {
+	storage *cache.Storage
+}
+
+// RegisterCache - adds the hits, misses and bypasses of the cache decorator to the Registry
+func RegisterCache(storage *cache.Storage) error {
+	return Registry.Register(&cacheCollector{storage: storage})
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheRequests
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, count := range c.storage.Counts() {
+		ch <- prometheus.MustNewConstMetric(cacheRequests, prometheus.CounterValue, float64(count.Value), count.Kind, count.Result)
+	}
+}
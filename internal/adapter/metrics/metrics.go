@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace      = "book_tracker"
+	unmatchedRoute = "unmatched"
+)
+
+// Registry - the collectors exposed on /metrics, kept apart from the global registry so that tests can build it once
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Repository operation latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	repositoryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_errors_total",
+		Help:      "Failed repository operations by method.",
+	}, []string{"method"})
+
+	exports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exports_total",
+		Help:      "Successful exports by kind(books, highlights).",
+	}, []string{"kind"})
+
+	exportedItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exported_items_total",
+		Help:      "Books or highlights exported, by kind.",
+	}, []string{"kind"})
+
+	importedHighlights = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "imported_highlights_total",
+		Help:      "Highlights read from clippings files, by outcome(imported, duplicate, skipped, unmatched).",
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, repositoryDuration, repositoryErrors, exports, exportedItems, importedHighlights,
+	)
+}
+
+// Handler - serves the collectors of the Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware - counts and times the requests. The route is the registered path(e.g. /api/v1/book/:id), not the
+// requested one, so that ids do not end up in the labels
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// observe - records the latency of the repository operation, and the error if any
+func observe(method string, start time.Time, err error) {
+	repositoryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		repositoryErrors.WithLabelValues(method).Inc()
+	}
+}
@@ -0,0 +1,126 @@
+//go:build fake
+// +build fake
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/resilience"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/database"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/v1/book/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	for _, url := range []string{"/api/v1/book/isbn-1", "/api/v1/book/isbn-2", "/api/v1/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/api/v1/book/:id", "404")); got != 2 {
+		t.Errorf("http_requests_total for the book route got (%v) wanted (2)", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")); got != 1 {
+		t.Errorf("http_requests_total for unmatched routes got (%v) wanted (1)", got)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	tests := []struct {
+		testName string
+		force    string
+		method   string
+		errors   float64
+	}{
+		{"Get: should pass", "", "Get", 0},
+		{"Get: should fail(collection error)", "true", "Get", 1},
+		{"GetAll: should fail(query error)", "query-error", "GetAll", 1},
+		{"Upsert: should fail(update error)", "update-error", "Upsert", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			cbStorage, _ := database.NewFakeCouchbaseStorage(test.force)
+			storage := NewStorage(cbStorage)
+			before := testutil.ToFloat64(repositoryErrors.WithLabelValues(test.method))
+
+			switch test.method {
+			case "Get":
+				_, _ = storage.Get(context.Background(), "isbn-1")
+			case "GetAll":
+				_, _ = storage.GetAll(context.Background())
+			case "Upsert":
+				_ = storage.Upsert(context.Background(), "isbn-1", entity.Book{})
+			}
+
+			if got := testutil.ToFloat64(repositoryErrors.WithLabelValues(test.method)) - before; got != test.errors {
+				t.Errorf("%s repository_operation_errors_total got (%v) wanted (%v)", test.testName, got, test.errors)
+			}
+			if testutil.CollectAndCount(repositoryDuration, namespace+"_repository_operation_duration_seconds") == 0 {
+				t.Errorf("%s repository_operation_duration_seconds got no samples", test.testName)
+			}
+		})
+	}
+}
+
+// statusGroups - fixed status groups, the books of the fake storage have no status. The scrape must be bounded
+type statusGroups struct {
+	service.BookTracker
+}
+
+func (s statusGroups) GroupBooks(ctx context.Context, _ string, _ entity.GroupOptions) ([]entity.BookGroup, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("no deadline")
+	}
+	return []entity.BookGroup{{Group: "FINISHED", Count: 3, Total: 3}, {Group: "UNREAD", Count: 1, Total: 1}}, nil
+}
+
+func TestHandler(t *testing.T) {
+	cbStorage, _ := database.NewFakeCouchbaseStorage("")
+	books := service.NewBookTracker(NewStorage(cbStorage))
+	if err := RegisterBooks(statusGroups{BookTracker: books}); err != nil {
+		t.Fatalf("RegisterBooks error %s", err.Error())
+	}
+	reviews := NewReviewTracker(service.NewReviewTracker(cbStorage, books))
+	if _, err := reviews.ExportBooks(context.Background()); err != nil {
+		t.Fatalf("ExportBooks error %s", err.Error())
+	}
+	// the first read fails and opens the breaker, its retry is rejected
+	unavailable, _ := database.NewFakeCouchbaseStorage("unavailable")
+	resilient := resilience.NewStorage(unavailable, config.Resilience{Retries: 2, BreakerFailures: 1, BreakerOpenTimeout: time.Minute})
+	if err := RegisterResilience(resilient); err != nil {
+		t.Fatalf("RegisterResilience error %s", err.Error())
+	}
+	_, _ = resilient.Get(context.Background(), "isbn-1")
+	cached := cache.NewStorage(cbStorage, cache.NewLRU(10), config.Cache{BookTTL: time.Minute})
+	if err := RegisterCache(cached); err != nil {
+		t.Fatalf("RegisterCache error %s", err.Error())
+	}
+	_, _ = cached.Get(context.Background(), "isbn-1")
+	_, _ = cached.Get(context.Background(), "isbn-1")
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{`book_tracker_books{status="FINISHED"} 3`, `book_tracker_exports_total{kind="books"} 1`, "go_goroutines",
+		"book_tracker_circuit_breaker_state 2", "book_tracker_repository_retries_total 1", "book_tracker_circuit_breaker_rejections_total 1",
+		`book_tracker_cache_requests_total{kind="book",result="hit"} 1`, `book_tracker_cache_requests_total{kind="book",result="miss"} 1`} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("/metrics got no %s", want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/resilience"
+)
+
+// RegisterResilience - adds the circuit breaker state, the retries and the rejections of the resilience decorator to
+// the Registry. They are read from the decorator when scraped
+func RegisterResilience(storage *resilience.Storage) error {
+	for _, collector := range []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_state",
+			Help:      "State of the repository circuit breaker(0 closed, 1 half-open, 2 open).",
+		}, func() float64 { return float64(storage.State()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_retries_total",
+			Help:      "Repository reads retried after a transient failure.",
+		}, func() float64 { return float64(storage.Retries()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_rejections_total",
+			Help:      "Repository operations rejected by the open circuit breaker.",
+		}, func() float64 { return float64(storage.Rejections()) }),
+	} {
+		if err := Registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
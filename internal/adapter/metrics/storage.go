@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+// storage - times the book operations of the repository. Every other method is passed through as is
+type storage struct {
+	repository.Storage
+}
+
+// NewStorage - decorates the repository with the operation latency and error metrics
+func NewStorage(s repository.Storage) repository.Storage {
+	return &storage{Storage: s}
+}
+
+func (s *storage) Get(ctx context.Context, id string) (*entity.Book, error) {
+	start := time.Now()
+	book, err := s.Storage.Get(ctx, id)
+	observe("Get", start, err)
+	return book, err
+}
+
+func (s *storage) GetAll(ctx context.Context) ([]entity.Book, error) {
+	start := time.Now()
+	books, err := s.Storage.GetAll(ctx)
+	observe("GetAll", start, err)
+	return books, err
+}
+
+func (s *storage) GetAllSorted(ctx context.Context, keys entity.BookSort) ([]entity.Book, error) {
+	start := time.Now()
+	books, err := s.Storage.GetAllSorted(ctx, keys)
+	observe("GetAllSorted", start, err)
+	return books, err
+}
+
+func (s *storage) Upsert(ctx context.Context, id string, doc interface{}) error {
+	start := time.Now()
+	err := s.Storage.Upsert(ctx, id, doc)
+	observe("Upsert", start, err)
+	return err
+}
+
+func (s *storage) Stats(ctx context.Context, filter entity.StatsFilter) (*entity.ReadingStats, error) {
+	start := time.Now()
+	stats, err := s.Storage.Stats(ctx, filter)
+	observe("Stats", start, err)
+	return stats, err
+}
+
+func (s *storage) GenreCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	start := time.Now()
+	counts, err := s.Storage.GenreCounts(ctx)
+	observe("GenreCounts", start, err)
+	return counts, err
+}
+
+func (s *storage) StatusCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	start := time.Now()
+	counts, err := s.Storage.StatusCounts(ctx)
+	observe("StatusCounts", start, err)
+	return counts, err
+}
+
+func (s *storage) GenreBooks(ctx context.Context, spellings []string, page entity.Page) ([]entity.Book, int, error) {
+	start := time.Now()
+	books, total, err := s.Storage.GenreBooks(ctx, spellings, page)
+	observe("GenreBooks", start, err)
+	return books, total, err
+}
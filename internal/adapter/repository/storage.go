@@ -1,9 +1,50 @@
 package repository
 
-import "github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
 
 type Storage interface {
-	Upsert(string, interface{}) error
-	GetAll() ([]entity.Book, error)
-	Get(string) (*entity.Book, error)
+	Upsert(context.Context, string, interface{}) error
+	GetAll(context.Context) ([]entity.Book, error)
+	GetAllSorted(context.Context, entity.BookSort) ([]entity.Book, error)
+	Stats(context.Context, entity.StatsFilter) (*entity.ReadingStats, error)
+	GenreCounts(context.Context) ([]entity.NamedCount, error)
+	StatusCounts(context.Context) ([]entity.NamedCount, error)
+	GenreBooks(context.Context, []string, entity.Page) ([]entity.Book, int, error)
+	Get(context.Context, string) (*entity.Book, error)
+	UpsertGoal(context.Context, string, interface{}) error
+	GetGoal(context.Context, string) (*entity.Goal, error)
+	GetAllGoals(context.Context) ([]entity.Goal, error)
+	UpsertShelf(context.Context, string, interface{}) error
+	GetShelf(context.Context, string) (*entity.Shelf, error)
+	GetAllShelves(context.Context) ([]entity.Shelf, error)
+	RemoveShelf(context.Context, string) error
+	RenameTag(context.Context, string, string) (int, error)
+	RemoveTag(context.Context, string) (int, error)
+	UpsertReview(context.Context, string, interface{}) error
+	GetReview(context.Context, string) (*entity.Review, error)
+	GetAllReviews(context.Context) ([]entity.Review, error)
+	RemoveReview(context.Context, string) error
+	UpsertNote(context.Context, string, interface{}) error
+	GetNote(context.Context, string) (*entity.Note, error)
+	GetNotes(context.Context, string) ([]entity.Note, error)
+	GetAllNotes(context.Context) ([]entity.Note, error)
+	RemoveNote(context.Context, string) error
+	UpsertHighlight(context.Context, string, interface{}) error
+	GetHighlight(context.Context, string) (*entity.Highlight, error)
+	GetHighlights(context.Context, string) ([]entity.Highlight, error)
+	GetAllHighlights(context.Context) ([]entity.Highlight, error)
+	RemoveHighlight(context.Context, string) error
+	UpsertGenre(context.Context, string, interface{}) error
+	GetGenre(context.Context, string) (*entity.Genre, error)
+	GetAllGenres(context.Context) ([]entity.Genre, error)
+	RemoveGenre(context.Context, string) error
+	RemapGenre(context.Context, []string, string) (int, error)
+	PingCluster(time.Duration) error
+	PingBucket(time.Duration) error
+	Close() error
 }
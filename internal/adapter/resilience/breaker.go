@@ -0,0 +1,146 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+// State - of the circuit breaker, the value of the circuit_breaker_state gauge
+type State int
+
+const (
+	Closed State = iota
+	HalfOpen
+	Open
+)
+
+// halfOpenRetryAfter - the Retry-After of the operations rejected while the trial runs
+const halfOpenRetryAfter = time.Second
+
+// ErrCircuitOpen - the operation was rejected by the circuit breaker without reaching the repository
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker - opens after consecutive failures(the repository is unavailable or timed out) and rejects the operations
+// until the open timeout elapsed. The next operation is then a trial: its success closes the breaker, its failure opens
+// it again. Canceled operations say nothing about the repository and are not counted. A threshold of 0 disables it
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newBreaker(threshold int, openTimeout time.Duration) *breaker {
+	return &breaker{threshold: threshold, openTimeout: openTimeout, now: time.Now}
+}
+
+// allow - nil when the operation may run, an entity.UnavailableError wrapping ErrCircuitOpen otherwise
+func (b *breaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if elapsed := b.now().Sub(b.openedAt); elapsed < b.openTimeout {
+			return unavailable(b.openTimeout - elapsed)
+		}
+		b.transition(HalfOpen)
+		b.trial = true
+	case HalfOpen:
+		if b.trial {
+			return unavailable(halfOpenRetryAfter)
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record - the outcome of an operation that was allowed
+func (b *breaker) record(ctx context.Context, err error) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case failure(err):
+		b.failures++
+		if b.state == HalfOpen || b.failures >= b.threshold {
+			b.openedAt = b.now()
+			b.transition(Open)
+		}
+	case canceled(ctx, err):
+		// the trial did not tell anything, the next operation tries again
+	default:
+		b.failures = 0
+		if b.state != Closed {
+			b.transition(Closed)
+		}
+	}
+	b.trial = false
+}
+
+// current - the state, half-open once the open timeout elapsed even if no operation came since
+func (b *breaker) current() (State, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if elapsed := b.now().Sub(b.openedAt); elapsed < b.openTimeout {
+			return Open, b.openTimeout - elapsed
+		}
+		return HalfOpen, 0
+	}
+	return b.state, 0
+}
+
+func (b *breaker) transition(state State) {
+	logrus.WithFields(logrus.Fields{"from": b.state.String(), "to": state.String(), "failures": b.failures}).
+		Warn("circuit breaker state changed")
+	b.state = state
+}
+
+func unavailable(retryAfter time.Duration) error {
+	return entity.UnavailableError{Message: fmt.Sprintf("%s, retry in %s", ErrCircuitOpen.Error(), retryAfter.Round(time.Millisecond)),
+		RetryAfter: retryAfter, Err: ErrCircuitOpen}
+}
+
+// failure - the repository is unavailable or too slow
+func failure(err error) bool {
+	var unavailable entity.UnavailableError
+	var timeout entity.TimeoutError
+	return errors.As(err, &unavailable) || errors.As(err, &timeout)
+}
+
+// canceled - the caller gave up, whatever the repository did
+func canceled(ctx context.Context, err error) bool {
+	var canceled entity.CanceledError
+	return errors.As(err, &canceled) || errors.Is(ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,385 @@
+// Package resilience keeps the service responsive while the repository struggles: the reads are retried on transient
+// failures, and a circuit breaker fails every operation fast while the repository keeps failing
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+)
+
+// Storage - every operation goes through the circuit breaker. The reads are idempotent and retried after a jittered
+// exponential backoff when the repository is unavailable or timed out, the writes are not since they may have been
+// applied. Ping and Close are passed through as is
+type Storage struct {
+	repository.Storage
+	breaker   *breaker
+	retries   int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	retried  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// NewStorage - decorates the repository with the retries and the circuit breaker of the configuration
+func NewStorage(s repository.Storage, cfg config.Resilience) *Storage {
+	return &Storage{Storage: s, breaker: newBreaker(cfg.BreakerFailures, cfg.BreakerOpenTimeout),
+		retries: cfg.Retries, baseDelay: cfg.RetryBaseDelay, maxDelay: cfg.RetryMaxDelay}
+}
+
+// State - of the circuit breaker
+func (s *Storage) State() State {
+	state, _ := s.breaker.current()
+	return state
+}
+
+// Retries - the reads retried so far
+func (s *Storage) Retries() uint64 {
+	return s.retried.Load()
+}
+
+// Rejections - the operations rejected by the open circuit breaker so far
+func (s *Storage) Rejections() uint64 {
+	return s.rejected.Load()
+}
+
+// PingCircuit - the readiness check of the circuit breaker, fails while it is open
+func (s *Storage) PingCircuit(time.Duration) error {
+	if state, retryAfter := s.breaker.current(); state == Open {
+		return unavailable(retryAfter)
+	}
+	return nil
+}
+
+// call - runs the operation through the circuit breaker
+func (s *Storage) call(ctx context.Context, operation func() error) error {
+	if err := s.breaker.allow(); err != nil {
+		s.rejected.Add(1)
+		return err
+	}
+	err := operation()
+	s.breaker.record(ctx, err)
+	return err
+}
+
+// retry - runs the read through the circuit breaker, again after a backoff while it fails transiently and the retries
+// are not exhausted. The last error is returned when the context is done during the backoff
+func (s *Storage) retry(ctx context.Context, method string, read func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := s.call(ctx, read)
+		if err == nil || attempt >= s.retries || !retriable(ctx, err) {
+			return err
+		}
+		s.retried.Add(1)
+		delay := s.backoff(attempt)
+		logrus.WithError(err).WithFields(logrus.Fields{"method": method, "attempt": attempt + 1, "delay": delay.String()}).
+			Debug("retrying the repository read")
+		if !sleep(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// backoff - full jitter: a random delay up to the base delay doubled for every attempt, capped by the max delay
+func (s *Storage) backoff(attempt int) time.Duration {
+	ceiling := s.maxDelay
+	if attempt < 32 && s.baseDelay<<attempt < s.maxDelay {
+		ceiling = s.baseDelay << attempt
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retriable - the repository may answer a moment later, and the caller still waits. The rejections of the circuit
+// breaker are not retried, they last until the open timeout
+func retriable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && failure(err) && !errors.Is(err, ErrCircuitOpen)
+}
+
+// sleep - false when the context is done first
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Storage) Get(ctx context.Context, id string) (*entity.Book, error) {
+	var book *entity.Book
+	err := s.retry(ctx, "Get", func() (err error) {
+		book, err = s.Storage.Get(ctx, id)
+		return err
+	})
+	return book, err
+}
+
+func (s *Storage) GetAll(ctx context.Context) ([]entity.Book, error) {
+	var books []entity.Book
+	err := s.retry(ctx, "GetAll", func() (err error) {
+		books, err = s.Storage.GetAll(ctx)
+		return err
+	})
+	return books, err
+}
+
+func (s *Storage) GetAllSorted(ctx context.Context, keys entity.BookSort) ([]entity.Book, error) {
+	var books []entity.Book
+	err := s.retry(ctx, "GetAllSorted", func() (err error) {
+		books, err = s.Storage.GetAllSorted(ctx, keys)
+		return err
+	})
+	return books, err
+}
+
+func (s *Storage) Upsert(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.Upsert(ctx, id, doc) })
+}
+
+func (s *Storage) Stats(ctx context.Context, filter entity.StatsFilter) (*entity.ReadingStats, error) {
+	var stats *entity.ReadingStats
+	err := s.retry(ctx, "Stats", func() (err error) {
+		stats, err = s.Storage.Stats(ctx, filter)
+		return err
+	})
+	return stats, err
+}
+
+func (s *Storage) GenreCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	var counts []entity.NamedCount
+	err := s.retry(ctx, "GenreCounts", func() (err error) {
+		counts, err = s.Storage.GenreCounts(ctx)
+		return err
+	})
+	return counts, err
+}
+
+func (s *Storage) StatusCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	var counts []entity.NamedCount
+	err := s.retry(ctx, "StatusCounts", func() (err error) {
+		counts, err = s.Storage.StatusCounts(ctx)
+		return err
+	})
+	return counts, err
+}
+
+func (s *Storage) GenreBooks(ctx context.Context, spellings []string, page entity.Page) ([]entity.Book, int, error) {
+	var books []entity.Book
+	var total int
+	err := s.retry(ctx, "GenreBooks", func() (err error) {
+		books, total, err = s.Storage.GenreBooks(ctx, spellings, page)
+		return err
+	})
+	return books, total, err
+}
+
+func (s *Storage) UpsertGoal(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.UpsertGoal(ctx, id, doc) })
+}
+
+func (s *Storage) GetGoal(ctx context.Context, id string) (*entity.Goal, error) {
+	var goal *entity.Goal
+	err := s.retry(ctx, "GetGoal", func() (err error) {
+		goal, err = s.Storage.GetGoal(ctx, id)
+		return err
+	})
+	return goal, err
+}
+
+func (s *Storage) GetAllGoals(ctx context.Context) ([]entity.Goal, error) {
+	var goals []entity.Goal
+	err := s.retry(ctx, "GetAllGoals", func() (err error) {
+		goals, err = s.Storage.GetAllGoals(ctx)
+		return err
+	})
+	return goals, err
+}
+
+func (s *Storage) UpsertShelf(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.UpsertShelf(ctx, id, doc) })
+}
+
+func (s *Storage) GetShelf(ctx context.Context, id string) (*entity.Shelf, error) {
+	var shelf *entity.Shelf
+	err := s.retry(ctx, "GetShelf", func() (err error) {
+		shelf, err = s.Storage.GetShelf(ctx, id)
+		return err
+	})
+	return shelf, err
+}
+
+func (s *Storage) GetAllShelves(ctx context.Context) ([]entity.Shelf, error) {
+	var shelves []entity.Shelf
+	err := s.retry(ctx, "GetAllShelves", func() (err error) {
+		shelves, err = s.Storage.GetAllShelves(ctx)
+		return err
+	})
+	return shelves, err
+}
+
+func (s *Storage) RemoveShelf(ctx context.Context, id string) error {
+	return s.call(ctx, func() error { return s.Storage.RemoveShelf(ctx, id) })
+}
+
+func (s *Storage) RenameTag(ctx context.Context, from string, to string) (int, error) {
+	var renamed int
+	err := s.call(ctx, func() (err error) {
+		renamed, err = s.Storage.RenameTag(ctx, from, to)
+		return err
+	})
+	return renamed, err
+}
+
+func (s *Storage) RemoveTag(ctx context.Context, tag string) (int, error) {
+	var removed int
+	err := s.call(ctx, func() (err error) {
+		removed, err = s.Storage.RemoveTag(ctx, tag)
+		return err
+	})
+	return removed, err
+}
+
+func (s *Storage) UpsertReview(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.UpsertReview(ctx, id, doc) })
+}
+
+func (s *Storage) GetReview(ctx context.Context, id string) (*entity.Review, error) {
+	var review *entity.Review
+	err := s.retry(ctx, "GetReview", func() (err error) {
+		review, err = s.Storage.GetReview(ctx, id)
+		return err
+	})
+	return review, err
+}
+
+func (s *Storage) GetAllReviews(ctx context.Context) ([]entity.Review, error) {
+	var reviews []entity.Review
+	err := s.retry(ctx, "GetAllReviews", func() (err error) {
+		reviews, err = s.Storage.GetAllReviews(ctx)
+		return err
+	})
+	return reviews, err
+}
+
+func (s *Storage) RemoveReview(ctx context.Context, id string) error {
+	return s.call(ctx, func() error { return s.Storage.RemoveReview(ctx, id) })
+}
+
+func (s *Storage) UpsertNote(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.UpsertNote(ctx, id, doc) })
+}
+
+func (s *Storage) GetNote(ctx context.Context, id string) (*entity.Note, error) {
+	var note *entity.Note
+	err := s.retry(ctx, "GetNote", func() (err error) {
+		note, err = s.Storage.GetNote(ctx, id)
+		return err
+	})
+	return note, err
+}
+
+func (s *Storage) GetNotes(ctx context.Context, bookID string) ([]entity.Note, error) {
+	var notes []entity.Note
+	err := s.retry(ctx, "GetNotes", func() (err error) {
+		notes, err = s.Storage.GetNotes(ctx, bookID)
+		return err
+	})
+	return notes, err
+}
+
+func (s *Storage) GetAllNotes(ctx context.Context) ([]entity.Note, error) {
+	var notes []entity.Note
+	err := s.retry(ctx, "GetAllNotes", func() (err error) {
+		notes, err = s.Storage.GetAllNotes(ctx)
+		return err
+	})
+	return notes, err
+}
+
+func (s *Storage) RemoveNote(ctx context.Context, id string) error {
+	return s.call(ctx, func() error { return s.Storage.RemoveNote(ctx, id) })
+}
+
+func (s *Storage) UpsertHighlight(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.UpsertHighlight(ctx, id, doc) })
+}
+
+func (s *Storage) GetHighlight(ctx context.Context, id string) (*entity.Highlight, error) {
+	var highlight *entity.Highlight
+	err := s.retry(ctx, "GetHighlight", func() (err error) {
+		highlight, err = s.Storage.GetHighlight(ctx, id)
+		return err
+	})
+	return highlight, err
+}
+
+func (s *Storage) GetHighlights(ctx context.Context, bookID string) ([]entity.Highlight, error) {
+	var highlights []entity.Highlight
+	err := s.retry(ctx, "GetHighlights", func() (err error) {
+		highlights, err = s.Storage.GetHighlights(ctx, bookID)
+		return err
+	})
+	return highlights, err
+}
+
+func (s *Storage) GetAllHighlights(ctx context.Context) ([]entity.Highlight, error) {
+	var highlights []entity.Highlight
+	err := s.retry(ctx, "GetAllHighlights", func() (err error) {
+		highlights, err = s.Storage.GetAllHighlights(ctx)
+		return err
+	})
+	return highlights, err
+}
+
+func (s *Storage) RemoveHighlight(ctx context.Context, id string) error {
+	return s.call(ctx, func() error { return s.Storage.RemoveHighlight(ctx, id) })
+}
+
+func (s *Storage) UpsertGenre(ctx context.Context, id string, doc interface{}) error {
+	return s.call(ctx, func() error { return s.Storage.UpsertGenre(ctx, id, doc) })
+}
+
+func (s *Storage) GetGenre(ctx context.Context, id string) (*entity.Genre, error) {
+	var genre *entity.Genre
+	err := s.retry(ctx, "GetGenre", func() (err error) {
+		genre, err = s.Storage.GetGenre(ctx, id)
+		return err
+	})
+	return genre, err
+}
+
+func (s *Storage) GetAllGenres(ctx context.Context) ([]entity.Genre, error) {
+	var genres []entity.Genre
+	err := s.retry(ctx, "GetAllGenres", func() (err error) {
+		genres, err = s.Storage.GetAllGenres(ctx)
+		return err
+	})
+	return genres, err
+}
+
+func (s *Storage) RemoveGenre(ctx context.Context, id string) error {
+	return s.call(ctx, func() error { return s.Storage.RemoveGenre(ctx, id) })
+}
+
+func (s *Storage) RemapGenre(ctx context.Context, spellings []string, to string) (int, error) {
+	var remapped int
+	err := s.call(ctx, func() (err error) {
+		remapped, err = s.Storage.RemapGenre(ctx, spellings, to)
+		return err
+	})
+	return remapped, err
+}
@@ -0,0 +1,196 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+)
+
+var (
+	errUnavailable = entity.UnavailableError{Message: "get book error:cluster temporarily unavailable"}
+	errTimeout     = entity.TimeoutError{Message: "get book error:timed out"}
+)
+
+// stubStorage - returns the errors in order, one per call, then succeeds
+type stubStorage struct {
+	repository.Storage
+	errs  []error
+	calls int
+}
+
+func (s *stubStorage) next() error {
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func (s *stubStorage) Get(context.Context, string) (*entity.Book, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+	return &entity.Book{ISBN: "isbn-1"}, nil
+}
+
+func (s *stubStorage) Upsert(context.Context, string, interface{}) error {
+	return s.next()
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		testName string
+		method   string
+		errs     []error
+		calls    int
+		err      error
+	}{
+		{"Get: should pass(after an unavailable cluster)", "Get", []error{errUnavailable}, 2, nil},
+		{"Get: should pass(after two timeouts)", "Get", []error{errTimeout, errTimeout}, 3, nil},
+		{"Get: should fail(retries exhausted)", "Get", []error{errUnavailable, errUnavailable, errUnavailable}, 3, errUnavailable},
+		{"Get: should fail(not found is not retried)", "Get", []error{entity.NotFoundError{Message: "not found"}}, 1, entity.NotFoundError{Message: "not found"}},
+		{"Upsert: should fail(writes are not retried)", "Upsert", []error{errUnavailable}, 1, errUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			stub := &stubStorage{errs: test.errs}
+			storage := NewStorage(stub, config.Resilience{Retries: 2, RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Millisecond})
+
+			var err error
+			switch test.method {
+			case "Get":
+				_, err = storage.Get(context.Background(), "isbn-1")
+			case "Upsert":
+				err = storage.Upsert(context.Background(), "isbn-1", entity.Book{})
+			}
+
+			if err != test.err {
+				t.Errorf("%s expected(%v) got (%v)", test.testName, test.err, err)
+			}
+			if stub.calls != test.calls {
+				t.Errorf("%s expected(%d) calls got (%d)", test.testName, test.calls, stub.calls)
+			}
+			if storage.Retries() != uint64(test.calls-1) {
+				t.Errorf("%s expected(%d) retries got (%d)", test.testName, test.calls-1, storage.Retries())
+			}
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	stub := &stubStorage{errs: []error{errUnavailable, errUnavailable}}
+	storage := NewStorage(stub, config.Resilience{Retries: 2, RetryBaseDelay: time.Minute, RetryMaxDelay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// the backoff outlives the deadline, the error of the first attempt is returned
+	if _, err := storage.Get(ctx, "isbn-1"); err != errUnavailable {
+		t.Errorf("TestRetryCanceled expected(%v) got (%v)", errUnavailable, err)
+	}
+	if stub.calls != 1 {
+		t.Errorf("TestRetryCanceled expected(1) calls got (%d)", stub.calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	storage := NewStorage(&stubStorage{}, config.Resilience{RetryBaseDelay: 50 * time.Millisecond, RetryMaxDelay: time.Second})
+	for attempt, ceiling := range []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond,
+		400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second, time.Second} {
+		for i := 0; i < 100; i++ {
+			if delay := storage.backoff(attempt); delay < 0 || delay > ceiling {
+				t.Fatalf("TestBackoff attempt %d expected a delay up to (%s) got (%s)", attempt, ceiling, delay)
+			}
+		}
+	}
+	if delay := storage.backoff(100); delay > time.Second {
+		t.Errorf("TestBackoff expected a delay up to (1s) got (%s)", delay)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	stub := &stubStorage{errs: []error{errUnavailable, errTimeout, errUnavailable}}
+	storage := NewStorage(stub, config.Resilience{BreakerFailures: 3, BreakerOpenTimeout: 30 * time.Second})
+	now := time.Now()
+	storage.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_ = storage.Upsert(ctx, "isbn-1", entity.Book{})
+	}
+	if storage.State() != Open {
+		t.Fatalf("TestBreaker expected(open) after 3 failures got (%s)", storage.State())
+	}
+
+	// fails fast until the open timeout elapsed
+	now = now.Add(10 * time.Second)
+	_, err := storage.Get(ctx, "isbn-1")
+	var unavailable entity.UnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, ErrCircuitOpen) || unavailable.RetryAfter != 20*time.Second {
+		t.Errorf("TestBreaker expected(circuit breaker open, retry in 20s) got (%v)", err)
+	}
+	if stub.calls != 3 || storage.Rejections() != 1 {
+		t.Errorf("TestBreaker expected(3) calls and (1) rejection got (%d) and (%d)", stub.calls, storage.Rejections())
+	}
+	if err = storage.PingCircuit(time.Second); err == nil {
+		t.Errorf("TestBreaker expected the readiness check to fail while open")
+	}
+
+	// a failed trial opens it again
+	now = now.Add(20 * time.Second)
+	if storage.State() != HalfOpen || storage.PingCircuit(time.Second) != nil {
+		t.Errorf("TestBreaker expected(half-open) once the open timeout elapsed got (%s)", storage.State())
+	}
+	stub.errs = []error{errTimeout}
+	if _, err = storage.Get(ctx, "isbn-1"); err != errTimeout || storage.State() != Open {
+		t.Errorf("TestBreaker expected(open) after a failed trial got (%s, %v)", storage.State(), err)
+	}
+
+	// a successful trial closes it
+	now = now.Add(30 * time.Second)
+	if _, err = storage.Get(ctx, "isbn-1"); err != nil || storage.State() != Closed {
+		t.Errorf("TestBreaker expected(closed) after a successful trial got (%s, %v)", storage.State(), err)
+	}
+}
+
+func TestBreakerIgnores(t *testing.T) {
+	tests := []struct {
+		testName string
+		err      error
+	}{
+		{"Breaker: should stay closed(not found)", entity.NotFoundError{Message: "not found"}},
+		{"Breaker: should stay closed(conflict)", entity.ConflictError{Message: "conflict"}},
+		{"Breaker: should stay closed(canceled)", entity.CanceledError{Message: "canceled"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			stub := &stubStorage{errs: []error{test.err, test.err, test.err}}
+			storage := NewStorage(stub, config.Resilience{BreakerFailures: 2, BreakerOpenTimeout: time.Minute})
+			for i := 0; i < 3; i++ {
+				_ = storage.Upsert(context.Background(), "isbn-1", entity.Book{})
+			}
+			if storage.State() != Closed {
+				t.Errorf("%s expected(closed) got (%s)", test.testName, storage.State())
+			}
+		})
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	stub := &stubStorage{errs: []error{errUnavailable, errUnavailable, errUnavailable}}
+	storage := NewStorage(stub, config.Resilience{})
+	for i := 0; i < 3; i++ {
+		_ = storage.Upsert(context.Background(), "isbn-1", entity.Book{})
+	}
+	if storage.State() != Closed || stub.calls != 3 || storage.Rejections() != 0 {
+		t.Errorf("TestBreakerDisabled expected(closed) got (%s) after (%d) calls", storage.State(), stub.calls)
+	}
+}
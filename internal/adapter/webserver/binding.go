@@ -0,0 +1,152 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// unknownFieldError - a field of the request body that the payload does not have, e.g. a typo like "bookmrk"
+type unknownFieldError struct {
+	Field    string
+	Expected []string
+}
+
+func (e unknownFieldError) Error() string {
+	return fmt.Sprintf("Invalid request. Unknown field %q, expected one of %s", e.Field, strings.Join(e.Expected, ", "))
+}
+
+// bindJSON - decodes and validates the request body into obj, like ShouldBindJSON. In strict mode the fields unknown
+// to obj are rejected. They are looked up on the raw body since custom unmarshalers(e.g. entity.Book) do not carry
+// the DisallowUnknownFields setting of the decoder
+func (s *Server) bindJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	if s.Config.Server.StrictJSON {
+		if err := unknownField(body, reflect.TypeOf(obj), ""); err != nil {
+			return *err
+		}
+	}
+	return binding.JSON.BindBody(body, obj)
+}
+
+// handleBindError - 413 when the body is over the limit, 400 with the unknown field in strict mode and 400 with the
+// message otherwise
+func handleBindError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	var unknown unknownFieldError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, entity.NewGenericResponse(http.StatusRequestEntityTooLarge, bodyTooLargeMessage(tooLarge.Limit)))
+	case errors.As(err, &unknown):
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, unknown.Error()))
+	default:
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, message))
+	}
+}
+
+func bodyTooLargeMessage(maxBytes int64) string {
+	return fmt.Sprintf("request body too large. Expected at most %d bytes", maxBytes)
+}
+
+// unknownField - the first field of the JSON document that t does not have, with its path(e.g. authors[0].rol).
+// Documents that do not match the shape of t are left to the decoder
+func unknownField(data []byte, t reflect.Type, path string) *unknownFieldError {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return nil
+		}
+		fields, names := jsonFields(t)
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// encoding/json matches the keys case-insensitively
+			field, ok := fields[strings.ToLower(key)]
+			if !ok {
+				return &unknownFieldError{Field: fieldPath(path, key), Expected: names}
+			}
+			if err := unknownField(object[key], field.Type, fieldPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := unknownField(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if json.Unmarshal(data, &values) != nil {
+			return nil
+		}
+		for key, value := range values {
+			if err := unknownField(value, t.Elem(), fieldPath(path, key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFields - the fields of the struct by lower case JSON name, and the JSON names in declaration order. The fields
+// of embedded structs are promoted
+func jsonFields(t reflect.Type) (map[string]reflect.StructField, []string) {
+	fields := map[string]reflect.StructField{}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			embedded, embeddedNames := jsonFields(field.Type)
+			for name, f := range embedded {
+				fields[name] = f
+			}
+			names = append(names, embeddedNames...)
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		fields[strings.ToLower(tag)] = field
+		names = append(names, tag)
+	}
+	return fields, names
+}
+
+func fieldPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,102 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateGenre - checks incoming request and adds the genre to the taxonomy
+func (s *Server) CreateGenre(c *gin.Context) {
+	var genre entity.Genre
+
+	if err := s.bindJSON(c, &genre); err != nil || strings.TrimSpace(genre.Name) == "" {
+		msg := "Invalid genre. Expected a name"
+		logger(c).WithError(err).Error("CreateGenre invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	err := s.Services.GenreTracker.CreateGenre(c.Request.Context(), genre)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldPayload, genre).Error("CreateGenre error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "genre creation successful"))
+}
+
+// ListGenres - lists the genre taxonomy
+func (s *Server) ListGenres(c *gin.Context) {
+	genres, err := s.Services.GenreTracker.ListGenres(c.Request.Context())
+	if err != nil {
+		logger(c).WithError(err).Error("ListGenres error")
+		handleError(c, err, "failed to get genres.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenreResponse(http.StatusOK, "genres retrieval successful", genres))
+}
+
+// UpdateGenre - replaces the aliases and parent of the genre, or renames it
+func (s *Server) UpdateGenre(c *gin.Context) {
+	var genre entity.Genre
+	name, _ := c.Params.Get("name")
+
+	if err := s.bindJSON(c, &genre); err != nil || strings.TrimSpace(genre.Name) == "" {
+		msg := "Invalid genre. Expected a name"
+		logger(c).WithError(err).Error("UpdateGenre invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	err := s.Services.GenreTracker.UpdateGenre(c.Request.Context(), name, genre)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldGenre: name, logging.FieldPayload: genre}).Error("UpdateGenre error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "genre updated successfully"))
+}
+
+// DeleteGenre - deletes a genre without sub-genres from the taxonomy
+func (s *Server) DeleteGenre(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	err := s.Services.GenreTracker.DeleteGenre(c.Request.Context(), name)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldGenre, name).Error("DeleteGenre error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "genre deleted successfully"))
+}
+
+// RemapGenre - admin operation moving the books of a genre to another genre
+func (s *Server) RemapGenre(c *gin.Context) {
+	var remap entity.GenreRemap
+
+	if err := s.bindJSON(c, &remap); err != nil || strings.TrimSpace(remap.From) == "" || strings.TrimSpace(remap.To) == "" {
+		msg := "Invalid remap. Expected the from and to genres"
+		logger(c).WithError(err).Error("RemapGenre invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	count, err := s.Services.GenreTracker.RemapGenre(c.Request.Context(), remap)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldPayload, remap).Error("RemapGenre error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, fmt.Sprintf("genre remapped on %d books", count)))
+}
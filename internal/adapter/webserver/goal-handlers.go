@@ -0,0 +1,79 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetGoal - checks incoming request and creates or replaces the reading goal of a year
+func (s *Server) SetGoal(c *gin.Context) {
+	var goal entity.Goal
+
+	if err := s.bindJSON(c, &goal); err != nil {
+		logger(c).WithError(err).Error("SetGoal invalid request")
+		handleBindError(c, err, err.Error())
+		return
+	}
+
+	if !goal.YearValid() {
+		msg := fmt.Sprintf("Invalid year %d. Expected a value between %d and %d", goal.Year, entity.MinGoalYear, entity.MaxGoalYear())
+		logger(c).WithField(logging.FieldReason, msg).Error("SetGoal invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
+		return
+	}
+
+	if goal.Books < 0 || goal.Pages < 0 || (goal.Books == 0 && goal.Pages == 0) {
+		msg := "Invalid goal. Expected a positive books or pages target"
+		logger(c).WithField(logging.FieldReason, msg).Error("SetGoal invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
+		return
+	}
+
+	err := s.Services.GoalTracker.SetGoal(c.Request.Context(), goal)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldPayload, goal).Error("SetGoal error")
+		handleError(c, err, "failed to save goal.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "goal saved successfully"))
+}
+
+// ListGoals - lists the reading goals of all years
+func (s *Server) ListGoals(c *gin.Context) {
+	goals, err := s.Services.GoalTracker.ListGoals(c.Request.Context())
+	if err != nil {
+		logger(c).WithError(err).Error("ListGoals error")
+		handleError(c, err, "failed to get goals.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGoalResponse(http.StatusOK, "goals retrieval successful", goals, nil))
+}
+
+// GoalProgress - gets the progress and projection of the reading goal of a year
+func (s *Server) GoalProgress(c *gin.Context) {
+	yearParam, _ := c.Params.Get("year")
+	year, err := strconv.Atoi(yearParam)
+	if err != nil || year <= 0 {
+		msg := fmt.Sprintf("Invalid year %s", yearParam)
+		logger(c).WithField(logging.FieldReason, msg).Error("GoalProgress invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
+		return
+	}
+
+	progress, err := s.Services.GoalTracker.GoalProgress(c.Request.Context(), year)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldYear, year).Error("GoalProgress error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGoalResponse(http.StatusOK, "goal progress retrieval successful", nil, progress))
+}
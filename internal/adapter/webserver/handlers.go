@@ -1,43 +1,60 @@
 package webserver
 
 import (
+	"errors"
 	"fmt"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
 
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
 )
 
 const (
-	unreadStatus     = "UNREAD"
-	inProgressStatus = "IN PROGRESS"
-	finishedStatus   = "FINISHED"
-	fileLocation     = "/tmp/test.yaml"
+	fileLocation    = "/tmp/test.yaml"
+	dateLayout      = "2006-01-02"
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// statusClientClosedRequest - the client closed the connection before the response(not a standard status code)
+	statusClientClosedRequest = 499
 )
 
-var l = logrus.StandardLogger()
+var (
+	tracer = otel.Tracer("github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/webserver")
+)
 
 // AddBook - checks incoming request and add the book to DB
 func (s *Server) AddBook(c *gin.Context) {
 	var book entity.Book
 
-	if err := c.ShouldBindJSON(&book); err != nil {
-		l.Errorf("CreateBook invalid request. Error: %s", err.Error())
-		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+	if err := s.bindJSON(c, &book); err != nil {
+		logger(c).WithError(err).Error("AddBook invalid request")
+		handleBindError(c, err, err.Error())
+		return
+	}
+
+	if msg := book.Invalid(); msg != "" {
+		logger(c).WithField(logging.FieldReason, msg).Error("AddBook invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
 		return
 	}
 
-	err := s.Services.BookTracker.AddBook(book)
+	err := s.Services.BookTracker.AddBook(c.Request.Context(), book)
 	if err != nil {
-		l.Errorf("AddBook error %s. Request payload %+v", err.Error(), book)
-		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "failed to save book.Refer to logs for more details"))
+		logger(c).WithError(err).WithField(logging.FieldPayload, book).Error("AddBook error")
+		handleError(c, err, "failed to save book.Refer to logs for more details")
 		return
 	}
 
@@ -48,29 +65,37 @@ func (s *Server) AddBook(c *gin.Context) {
 func (s *Server) ListBooks(c *gin.Context) {
 	sortKey := c.Query(consts.SortKey)
 
-	if !sortKeyValid(sortKey) {
-		msg := fmt.Sprintf("Invalid sort key. Expected: %s or %s", consts.Status, consts.Title)
-		l.Errorf("GetBooks error: %s", msg)
-		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
+	if _, err := entity.ParseBookSort(sortKey); err != nil {
+		logger(c).WithError(err).Error("ListBooks invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	filter, err := bookFilter(c)
+	if err != nil {
+		logger(c).WithError(err).Error("ListBooks invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
 		return
 	}
 
-	books, err := s.Services.BookTracker.ListBooks(sortKey)
+	books, err := s.Services.BookTracker.ListBooks(c.Request.Context(), sortKey, filter)
 	if err != nil {
-		l.Errorf("GetBooks error %s", err.Error())
-		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "failed to get books.Refer to logs for more details"))
+		logger(c).WithError(err).Error("ListBooks error")
+		handleError(c, err, "failed to get books.Refer to logs for more details")
 		return
 	}
 
+	_, span := tracer.Start(c.Request.Context(), "encode", trace.WithAttributes(attribute.Int("books.count", len(books))))
 	c.JSON(http.StatusOK, entity.NewBookResponse(http.StatusOK, "books retrieval successful", nil, books))
+	span.End()
 }
 
 // GetBook - gets the book with id from the DB(ISBN)
 func (s *Server) GetBook(c *gin.Context) {
 	bookId, _ := c.Params.Get("id")
-	book, err := s.Services.BookTracker.GetBook(bookId)
+	book, err := s.Services.BookTracker.GetBook(c.Request.Context(), bookId)
 	if err != nil {
-		l.Errorf("GetBook error %s. Request ISBN %s", err.Error(), bookId)
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("GetBook error")
 		handleErrorTypes(c, err)
 		return
 	}
@@ -82,47 +107,129 @@ func (s *Server) GetBook(c *gin.Context) {
 func (s *Server) UpdateBook(c *gin.Context) {
 	var book entity.Book
 
-	if err := c.ShouldBindJSON(&book); err != nil {
-		l.Errorf("UpdateBook invalid request. Error: %s", err.Error())
-		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+	if err := s.bindJSON(c, &book); err != nil {
+		logger(c).WithError(err).Error("UpdateBook invalid request")
+		handleBindError(c, err, err.Error())
+		return
+	}
+
+	if !entity.StatusValid(book.Status) {
+		msg := fmt.Sprintf("Invalid status key. Expected one of %s", strings.Join(entity.Statuses, ", "))
+		logger(c).WithField(logging.FieldReason, msg).Error("UpdateBook invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
 		return
 	}
 
-	if !statusValid(book.Status) {
-		msg := fmt.Sprintf("Invalid status key. Expected one of %s, %s, %s", unreadStatus, inProgressStatus, finishedStatus)
-		l.Errorf("UpdateBook error: %s", msg)
+	if msg := book.Invalid(); msg != "" {
+		logger(c).WithField(logging.FieldReason, msg).Error("UpdateBook invalid request")
 		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
 		return
 	}
 
-	err := s.Services.BookTracker.UpdateBook(book)
+	// a bookmark on the last page only finishes the book when the client opts in
+	lastPage := book.OnLastPage() && strings.ToUpper(book.Status) != entity.StatusFinished
+	if lastPage && c.Query(consts.AutoFinish) == "true" {
+		book.Status = entity.StatusFinished
+		lastPage = false
+	}
+
+	err := s.Services.BookTracker.UpdateBook(c.Request.Context(), book)
 	if err != nil {
-		l.Errorf("UpdateBook error %s. Request payload %+v", err.Error(), book)
+		logger(c).WithError(err).WithField(logging.FieldPayload, book).Error("UpdateBook error")
 		handleErrorTypes(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "book updated successfully"))
+	msg := "book updated successfully"
+	if lastPage {
+		msg = fmt.Sprintf("%s. Bookmark is on the last page, set status to %s or retry with %s=true", msg, entity.StatusFinished, consts.AutoFinish)
+	}
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, msg))
 }
 
 // GroupBooksByGenre - lists the genres and books associated with each genre
 func (s *Server) GroupBooksByGenre(c *gin.Context) {
-	genres, err := s.Services.BookTracker.GroupBooksByGenre()
+	options, err := groupOptions(c)
+	if err != nil {
+		logger(c).WithError(err).Error("GroupBooksByGenre invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	genres, err := s.Services.BookTracker.GroupBooksByGenre(c.Request.Context(), options)
 	if err != nil {
-		l.Errorf("GetBooks error %s", err.Error())
-		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "failed to get books.Refer to logs for more details"))
+		logger(c).WithError(err).Error("GroupBooksByGenre error")
+		handleError(c, err, "failed to get books.Refer to logs for more details")
 		return
 	}
 
 	c.JSON(http.StatusOK, entity.NewGroupByGenreResponse(http.StatusOK, "books retrieval successful", genres))
 }
 
-// ExportBooks - exports the books as yaml file. Using the sync package here to guard the critical section of writing to file
+// GenreBooks - lists a page of the books of the genre ordered by title
+func (s *Server) GenreBooks(c *gin.Context) {
+	genre, _ := c.Params.Get("genre")
+	page, err := bookPage(c)
+	if err != nil {
+		logger(c).WithError(err).Error("GenreBooks invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	books, total, err := s.Services.BookTracker.GenreBooks(c.Request.Context(), genre, page)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldGenre, genre).Error("GenreBooks error")
+		handleError(c, err, "failed to get books.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewBookPageResponse(http.StatusOK, "books retrieval successful", page, total, books))
+}
+
+// GroupBooksByTag - lists the tags and books associated with each tag
+func (s *Server) GroupBooksByTag(c *gin.Context) {
+	tags, err := s.Services.BookTracker.GroupBooksByTag(c.Request.Context())
+	if err != nil {
+		logger(c).WithError(err).Error("GroupBooksByTag error")
+		handleError(c, err, "failed to get books.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGroupByTagResponse(http.StatusOK, "books retrieval successful", tags))
+}
+
+// GroupBooks - lists the groups of books by ?by=genre|author|status|year_finished|language|tag
+func (s *Server) GroupBooks(c *gin.Context) {
+	by := strings.ToLower(c.Query(consts.By))
+	if !groupingValid(by) {
+		msg := fmt.Sprintf("Invalid by %s. Expected one of %s", by, strings.Join(entity.Groupings, ", "))
+		logger(c).WithField(logging.FieldReason, msg).Error("GroupBooks invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, msg))
+		return
+	}
+	options, err := groupOptions(c)
+	if err != nil {
+		logger(c).WithError(err).Error("GroupBooks invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	groups, err := s.Services.BookTracker.GroupBooks(c.Request.Context(), by, options)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldGroupBy, by).Error("GroupBooks error")
+		handleError(c, err, "failed to get books.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewBookGroupResponse(http.StatusOK, "books retrieval successful", by, groups))
+}
+
+// ExportBooks - exports the books with their reviews and notes as yaml file. Using the sync package here to guard the critical section of writing to file
 func (s *Server) ExportBooks(c *gin.Context) {
-	books, err := s.Services.BookTracker.ListBooks("")
+	books, err := s.Services.ReviewTracker.ExportBooks(c.Request.Context())
 	if err != nil {
-		l.Errorf("ExportBooks error %s", err.Error())
-		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "failed to export books.Refer to logs for more details"))
+		logger(c).WithError(err).Error("ExportBooks error")
+		handleError(c, err, "failed to export books.Refer to logs for more details")
 		return
 	}
 	yamlData, _ := yaml.Marshal(books)
@@ -132,7 +239,7 @@ func (s *Server) ExportBooks(c *gin.Context) {
 
 	err = os.WriteFile(fileLocation, yamlData, 0644)
 	if err != nil {
-		l.Errorf("ExportBooks error creating yaml file %s", err.Error())
+		logger(c).WithError(err).Error("ExportBooks error creating yaml file")
 		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "failed to write books to file.Refer to logs for more details"))
 		return
 	}
@@ -142,22 +249,160 @@ func (s *Server) ExportBooks(c *gin.Context) {
 	mu.Unlock()
 
 }
-func sortKeyValid(sortKey string) bool {
-	return sortKey == "" || strings.ToLower(sortKey) == consts.Title || strings.ToLower(sortKey) == consts.Status
+
+// ReadingStats - computes reading statistics for books finished within the optional from/to date range
+func (s *Server) ReadingStats(c *gin.Context) {
+	filter, err := statsFilter(c.Query(consts.From), c.Query(consts.To))
+	if err != nil {
+		logger(c).WithError(err).Error("ReadingStats invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	stats, err := s.Services.BookTracker.ReadingStats(c.Request.Context(), filter)
+	if err != nil {
+		logger(c).WithError(err).Error("ReadingStats error")
+		handleError(c, err, "failed to get reading stats.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewStatsResponse(http.StatusOK, "reading stats retrieval successful", stats))
+}
+
+// groupOptions - ?counts_only=true leaves the books out, ?sample=n keeps at most n books per group
+func groupOptions(c *gin.Context) (entity.GroupOptions, error) {
+	var options entity.GroupOptions
+	if countsOnly := c.Query(consts.CountsOnly); countsOnly != "" {
+		value, err := strconv.ParseBool(countsOnly)
+		if err != nil {
+			return options, fmt.Errorf("Invalid counts_only %s. Expected true or false", countsOnly)
+		}
+		options.CountsOnly = value
+	}
+	if sample := c.Query(consts.Sample); sample != "" {
+		value, err := strconv.Atoi(sample)
+		if err != nil || value < 0 {
+			return options, fmt.Errorf("Invalid sample %s. Expected a number that is not negative", sample)
+		}
+		options.Sample = value
+	}
+	return options, nil
 }
 
-func statusValid(status string) bool {
-	return status == "" ||
-		strings.ToUpper(status) == unreadStatus ||
-		strings.ToUpper(status) == inProgressStatus ||
-		strings.ToUpper(status) == finishedStatus
+func groupingValid(by string) bool {
+	for _, grouping := range entity.Groupings {
+		if by == grouping {
+			return true
+		}
+	}
+	return false
+}
+
+// bookPage - ?offset and ?limit of a listing, 20 books by default
+func bookPage(c *gin.Context) (entity.Page, error) {
+	page := entity.Page{Limit: defaultPageSize}
+	if offset := c.Query(consts.Offset); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return page, fmt.Errorf("Invalid offset %s. Expected a number that is not negative", offset)
+		}
+		page.Offset = value
+	}
+	if limit := c.Query(consts.Limit); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxPageSize {
+			return page, fmt.Errorf("Invalid limit %s. Expected a number between 1 and %d", limit, maxPageSize)
+		}
+		page.Limit = value
+	}
+	return page, nil
+}
+
+// bookFilter - builds the ListBooks filter from the query parameters
+func bookFilter(c *gin.Context) (entity.BookFilter, error) {
+	filter := entity.BookFilter{
+		Author:    c.Query(consts.Author),
+		Series:    c.Query(consts.Series),
+		Publisher: c.Query(consts.Publisher),
+		Language:  c.Query(consts.Language),
+		Format:    c.Query(consts.Format),
+		Tag:       c.Query(consts.Tag),
+	}
+	if filter.Format != "" && !entity.FormatValid(filter.Format) {
+		return filter, fmt.Errorf("Invalid format %s. Expected one of %s", filter.Format, strings.Join(entity.Formats, ", "))
+	}
+	if year := c.Query(consts.Year); year != "" {
+		value, err := strconv.Atoi(year)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid year %s", year)
+		}
+		filter.PublicationYear = value
+	}
+	return filter, nil
+}
+
+// statsFilter - parses the from/to dates(YYYY-MM-DD, UTC). The to date is inclusive
+func statsFilter(from, to string) (entity.StatsFilter, error) {
+	var filter entity.StatsFilter
+	if from != "" {
+		date, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid from date %s. Expected format YYYY-MM-DD", from)
+		}
+		filter.From = date.Unix()
+	}
+	if to != "" {
+		date, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid to date %s. Expected format YYYY-MM-DD", to)
+		}
+		filter.To = date.AddDate(0, 0, 1).Unix() - 1
+	}
+	if filter.From > 0 && filter.To > 0 && filter.From > filter.To {
+		return filter, fmt.Errorf("Invalid date range. from %s is after to %s", from, to)
+	}
+	return filter, nil
 }
 
 func handleErrorTypes(c *gin.Context, err error) {
+	if handleTransientErrors(c, err) {
+		return
+	}
 	switch err.(type) {
 	case entity.NotFoundError:
 		c.JSON(http.StatusNotFound, entity.NewGenericResponse(http.StatusNotFound, err.Error()))
+	case entity.ConflictError:
+		c.JSON(http.StatusConflict, entity.NewGenericResponse(http.StatusConflict, err.Error()))
 	default:
 		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "operation failed.Refer to logs for more details"))
 	}
 }
+
+// handleError - the timeouts and cancellations of the request(see handleTransientErrors), a 500 with the message otherwise
+func handleError(c *gin.Context, err error, message string) {
+	if !handleTransientErrors(c, err) {
+		c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, message))
+	}
+}
+
+// handleTransientErrors - 504 when the request ran out of time, 499 when it was canceled(the client went away), 503
+// with Retry-After when the repository is unavailable(e.g. the circuit breaker is open)
+func handleTransientErrors(c *gin.Context, err error) bool {
+	var timeout entity.TimeoutError
+	var canceled entity.CanceledError
+	var unavailable entity.UnavailableError
+	switch {
+	case errors.As(err, &unavailable):
+		if unavailable.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(unavailable.RetryAfter.Seconds()))))
+		}
+		c.JSON(http.StatusServiceUnavailable, entity.NewGenericResponse(http.StatusServiceUnavailable, "service temporarily unavailable.Retry later"))
+	case errors.As(err, &timeout):
+		c.JSON(http.StatusGatewayTimeout, entity.NewGenericResponse(http.StatusGatewayTimeout, "request timed out.Refer to logs for more details"))
+	case errors.As(err, &canceled):
+		c.JSON(statusClientClosedRequest, entity.NewGenericResponse(statusClientClosedRequest, "request canceled"))
+	default:
+		return false
+	}
+	return true
+}
@@ -5,17 +5,28 @@ package webserver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/database"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 const (
@@ -25,16 +36,82 @@ const (
 	getBookHandler            = "GetBook"
 	updateBookHandler         = "UpdateBook"
 	groupBooksByGenreHandler  = "GroupBooksByGenre"
+	genreBooksHandler         = "GenreBooks"
+	groupBooksHandler         = "GroupBooks"
 	exportBooksHandler        = "ExportBooks"
+	readingStatsHandler       = "ReadingStats"
+	setGoalHandler            = "SetGoal"
+	listGoalsHandler          = "ListGoals"
+	goalProgressHandler       = "GoalProgress"
+	goalJsonFile              = "goal.json"
+	goalMissingTargetJsonFile = "goal-missing-target.json"
+	goalInvalidYearJsonFile   = "goal-invalid-year.json"
+	groupBooksByTagHandler    = "GroupBooksByTag"
+	createShelfHandler        = "CreateShelf"
+	listShelvesHandler        = "ListShelves"
+	getShelfHandler           = "GetShelf"
+	updateShelfHandler        = "UpdateShelf"
+	deleteShelfHandler        = "DeleteShelf"
+	addBookToShelfHandler     = "AddBookToShelf"
+	removeBookFromShelf       = "RemoveBookFromShelf"
+	shelfJsonFile             = "shelf.json"
+	shelfMissingNameJsonFile  = "shelf-missing-name.json"
+	shelfRenamedJsonFile      = "shelf-renamed.json"
+	setReviewHandler          = "SetReview"
+	getReviewHandler          = "GetReview"
+	deleteReviewHandler       = "DeleteReview"
+	addNoteHandler            = "AddNote"
+	listNotesHandler          = "ListNotes"
+	updateNoteHandler         = "UpdateNote"
+	deleteNoteHandler         = "DeleteNote"
+	reviewJsonFile            = "review.json"
+	reviewInvalidRatingFile   = "review-invalid-rating.json"
+	noteJsonFile              = "note.json"
+	noteMissingTextJsonFile   = "note-missing-text.json"
+	addHighlightHandler       = "AddHighlight"
+	listHighlightsHandler     = "ListHighlights"
+	updateHighlightHandler    = "UpdateHighlight"
+	deleteHighlightHandler    = "DeleteHighlight"
+	importClippingsHandler    = "ImportClippings"
+	exportHighlightsHandler   = "ExportHighlights"
+	highlightJsonFile         = "highlight.json"
+	highlightInvalidColorFile = "highlight-invalid-color.json"
+	clippingsFile             = "my-clippings.txt"
+	recommendHandler          = "Recommend"
+	createGenreHandler        = "CreateGenre"
+	listGenresHandler         = "ListGenres"
+	updateGenreHandler        = "UpdateGenre"
+	deleteGenreHandler        = "DeleteGenre"
+	remapGenreHandler         = "RemapGenre"
+	genreJsonFile             = "genre.json"
+	genreNewJsonFile          = "genre-new.json"
+	genreMissingNameJsonFile  = "genre-missing-name.json"
+	genreRemapJsonFile        = "genre-remap.json"
+	genreRemapMissingJsonFile = "genre-remap-missing-to.json"
 	bookJsonFile              = "book.json"
 	bookInvalidStatusJsonFile = "book-invalid-status.json"
 	bookMissingFieldJsonFile  = "book-missing-mandatory-field.json"
+	bookInvalidBookmarkFile   = "book-invalid-bookmark.json"
+	bookLastPageJsonFile      = "book-last-page.json"
+	bookRatingJsonFile        = "book-rating.json"
+	bookAuthorsJsonFile       = "book-authors.json"
+	bookInvalidFormatJsonFile = "book-invalid-format.json"
+	bookUnknownFieldJsonFile  = "book-unknown-field.json"
+	bookUnknownNestedJsonFile = "book-unknown-nested-field.json"
 )
 
 var (
 	bookURL       = "/api/v1/book"
 	bookExportURL = "/api/v1/book/export"
+	bookGroupsURL = "/api/v1/book/groups"
 	genreURL      = "/api/v1/genre"
+	statsURL      = "/api/v1/stats"
+	goalsURL      = "/api/v1/goals"
+	tagURL        = "/api/v1/tag"
+	shelvesURL    = "/api/v1/shelves"
+	highlightsURL = "/api/v1/highlights"
+	recommendURL  = "/api/v1/recommendations"
+	genresURL     = "/api/v1/genres"
 )
 
 func TestHandlers(t *testing.T) {
@@ -58,6 +135,26 @@ func TestHandlers(t *testing.T) {
 			createBookHandler,
 			bookURL,
 		},
+		{
+			"AddBook Book: unknown field",
+			http.MethodPost,
+			"",
+			`Invalid request. Unknown field "bookmrk", expected one of isbn, title, author, genre, status, bookmark, page_count, duration_minutes, percent_complete, created, updated, created_by, updated_by, started, finished, active, authors, series, publisher, publication_year, language, format, description, tags, rating, schema_version`,
+			http.StatusBadRequest,
+			bookUnknownFieldJsonFile,
+			createBookHandler,
+			bookURL,
+		},
+		{
+			"AddBook Book: unknown nested field",
+			http.MethodPost,
+			"",
+			`Invalid request. Unknown field "authors[0].rol", expected one of name, role`,
+			http.StatusBadRequest,
+			bookUnknownNestedJsonFile,
+			createBookHandler,
+			bookURL,
+		},
 		{
 			"AddBook Book: force DB error",
 			http.MethodPost,
@@ -78,6 +175,46 @@ func TestHandlers(t *testing.T) {
 			createBookHandler,
 			bookURL,
 		},
+		{
+			"AddBook Book: bookmark beyond page count",
+			http.MethodPost,
+			"",
+			"Invalid bookmark 301. Expected a value between 0 and 300",
+			http.StatusBadRequest,
+			bookInvalidBookmarkFile,
+			createBookHandler,
+			bookURL,
+		},
+		{
+			"UpdateBook Book: bookmark beyond page count",
+			http.MethodPut,
+			"",
+			"Invalid bookmark 301. Expected a value between 0 and 300",
+			http.StatusBadRequest,
+			bookInvalidBookmarkFile,
+			updateBookHandler,
+			bookURL,
+		},
+		{
+			"AddBook Book: should pass(author list only)",
+			http.MethodPost,
+			"",
+			"",
+			http.StatusOK,
+			bookAuthorsJsonFile,
+			createBookHandler,
+			bookURL,
+		},
+		{
+			"AddBook Book: invalid format",
+			http.MethodPost,
+			"",
+			"Invalid format scroll. Expected one of HARDCOVER, PAPERBACK, EBOOK, AUDIOBOOK",
+			http.StatusBadRequest,
+			bookInvalidFormatJsonFile,
+			createBookHandler,
+			bookURL,
+		},
 		{
 			"UpdateBook Book: missing mandatory fields",
 			http.MethodPut,
@@ -148,6 +285,26 @@ func TestHandlers(t *testing.T) {
 			getBookHandler,
 			bookURL,
 		},
+		{
+			"Get Book: force DB timeout",
+			http.MethodGet,
+			"timeout",
+			"request timed out.Refer to logs for more details",
+			http.StatusGatewayTimeout,
+			"",
+			getBookHandler,
+			bookURL,
+		},
+		{
+			"Get Book: force DB unavailable",
+			http.MethodGet,
+			"unavailable",
+			"service temporarily unavailable.Retry later",
+			http.StatusServiceUnavailable,
+			"",
+			getBookHandler,
+			bookURL,
+		},
 		{
 			"Get Book: should pass",
 			http.MethodGet,
@@ -162,12 +319,62 @@ func TestHandlers(t *testing.T) {
 			"Get Books: force fail(invalid sort key)",
 			http.MethodGet,
 			"",
-			"Invalid sort key. Expected: status or title",
+			"Invalid sort key bla. Expected a comma separated list of title, author, status, genre, created, updated, started, finished, rating, prefixed with - for descending order",
 			http.StatusBadRequest,
 			"",
 			getBooksHandler,
 			bookURL + "?sort=bla",
 		},
+		{
+			"Get Books: force fail(invalid second sort key)",
+			http.MethodGet,
+			"",
+			"Invalid sort key isbn. Expected a comma separated list of title, author, status, genre, created, updated, started, finished, rating, prefixed with - for descending order",
+			http.StatusBadRequest,
+			"",
+			getBooksHandler,
+			bookURL + "?sort=-finished,isbn",
+		},
+		{
+			"Get Books: should pass(multi-key sort)",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			getBooksHandler,
+			bookURL + "?sort=-finished,title",
+		},
+		{
+			"Get Books: force fail(invalid format filter)",
+			http.MethodGet,
+			"",
+			"Invalid format scroll. Expected one of HARDCOVER, PAPERBACK, EBOOK, AUDIOBOOK",
+			http.StatusBadRequest,
+			"",
+			getBooksHandler,
+			bookURL + "?format=scroll",
+		},
+		{
+			"Get Books: force fail(invalid year filter)",
+			http.MethodGet,
+			"",
+			"Invalid year bla",
+			http.StatusBadRequest,
+			"",
+			getBooksHandler,
+			bookURL + "?year=bla",
+		},
+		{
+			"Get Books: should pass(filtered)",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			getBooksHandler,
+			bookURL + "?author=Test%20Author&series=Test%20Series&language=en&format=ebook&year=2019",
+		},
 		{
 			"Get Books: force DB error",
 			http.MethodGet,
@@ -208,6 +415,66 @@ func TestHandlers(t *testing.T) {
 			groupBooksByGenreHandler,
 			genreURL,
 		},
+		{
+			"GroupBooksByGenre: should fail(invalid sample)",
+			http.MethodGet,
+			"",
+			"Invalid sample -1. Expected a number that is not negative",
+			http.StatusBadRequest,
+			"",
+			groupBooksByGenreHandler,
+			genreURL + "?sample=-1",
+		},
+		{
+			"GroupBooksByGenre: should pass(counts only)",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			groupBooksByGenreHandler,
+			genreURL + "?counts_only=true",
+		},
+		{
+			"GroupBooksByGenre: should pass(sample)",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			groupBooksByGenreHandler,
+			genreURL + "?sample=1",
+		},
+		{
+			"GenreBooks: should fail(invalid limit)",
+			http.MethodGet,
+			"",
+			"Invalid limit 500. Expected a number between 1 and 100",
+			http.StatusBadRequest,
+			"",
+			genreBooksHandler,
+			genreURL + "/horror?limit=500",
+		},
+		{
+			"GenreBooks: should fail(force DB error)",
+			http.MethodGet,
+			"query-error",
+			"failed to get books.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			genreBooksHandler,
+			genreURL + "/horror",
+		},
+		{
+			"GenreBooks: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			genreBooksHandler,
+			genreURL + "/horror?offset=10&limit=5",
+		},
 		{
 			"GroupBooksByGenre: should pass",
 			http.MethodGet,
@@ -218,6 +485,56 @@ func TestHandlers(t *testing.T) {
 			groupBooksByGenreHandler,
 			genreURL,
 		},
+		{
+			"GroupBooks: should fail(missing by)",
+			http.MethodGet,
+			"",
+			"Invalid by . Expected one of genre, author, status, year_finished, language, tag",
+			http.StatusBadRequest,
+			"",
+			groupBooksHandler,
+			bookGroupsURL,
+		},
+		{
+			"GroupBooks: should fail(invalid by)",
+			http.MethodGet,
+			"",
+			"Invalid by publisher. Expected one of genre, author, status, year_finished, language, tag",
+			http.StatusBadRequest,
+			"",
+			groupBooksHandler,
+			bookGroupsURL + "?by=publisher",
+		},
+		{
+			"GroupBooks: should fail(invalid sample)",
+			http.MethodGet,
+			"",
+			"Invalid sample -1. Expected a number that is not negative",
+			http.StatusBadRequest,
+			"",
+			groupBooksHandler,
+			bookGroupsURL + "?by=author&sample=-1",
+		},
+		{
+			"GroupBooks: should fail(force DB error)",
+			http.MethodGet,
+			"query-error",
+			"failed to get books.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			groupBooksHandler,
+			bookGroupsURL + "?by=status",
+		},
+		{
+			"GroupBooks: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			groupBooksHandler,
+			bookGroupsURL + "?by=year_finished&counts_only=true",
+		},
 		{
 			"ExportBooks: should fail(force DB error)",
 			http.MethodGet,
@@ -238,55 +555,1015 @@ func TestHandlers(t *testing.T) {
 			exportBooksHandler,
 			bookExportURL,
 		},
-	}
-
-	for _, test := range crulTests {
-		t.Run(test.testName, func(t *testing.T) {
-			//setup
-			file, _ := os.ReadFile(testFolderPath + test.requestPayload)
-
-			rr := httptest.NewRecorder()
-			req, _ := http.NewRequest(test.httpMethod, test.url, bytes.NewBuffer(file))
-			c, _ := gin.CreateTestContext(rr)
-			c.Request = req
-
-			cbStorage, _ := database.NewFakeCouchbaseStorage(test.errorFlag)
-			bookSvc := service.NewBookTracker(cbStorage)
-			server := NewServer(Services{BookTracker: bookSvc})
-
-			// actual tests
-			switch test.handler {
-			case createBookHandler:
-				server.AddBook(c)
-			case updateBookHandler:
-				server.UpdateBook(c)
-			case getBookHandler:
-				server.GetBook(c)
-			case getBooksHandler:
-				server.ListBooks(c)
-			case groupBooksByGenreHandler:
-				server.GroupBooksByGenre(c)
-			case exportBooksHandler:
-				server.ExportBooks(c)
-			}
-
-			//assertions
-			if rr.Code != test.statusCodeExpected {
-				t.Errorf("Handler %s returned with incorrect status code - got (%d) wanted (%d)", test.handler, rr.Code, test.statusCodeExpected)
-			}
-
-			//check error messages for failures
-			if rr.Code != http.StatusOK && test.errorExpected != "" {
-				var resp entity.BookResponse
-				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
-					t.Fatalf("Should not fail: found error %v ", err)
-				}
-
-				if resp.Message != test.errorExpected {
-					t.Errorf("Handler %s returned with incorrect error - got (%s) wanted (%s)", test.handler, resp.Message, test.errorExpected)
-				}
-			}
-		})
-	}
-
+		{
+			"ReadingStats: should fail(invalid from date)",
+			http.MethodGet,
+			"",
+			"Invalid from date 01-01-2023. Expected format YYYY-MM-DD",
+			http.StatusBadRequest,
+			"",
+			readingStatsHandler,
+			statsURL + "?from=01-01-2023",
+		},
+		{
+			"ReadingStats: should fail(from after to)",
+			http.MethodGet,
+			"",
+			"Invalid date range. from 2023-12-31 is after to 2023-01-01",
+			http.StatusBadRequest,
+			"",
+			readingStatsHandler,
+			statsURL + "?from=2023-12-31&to=2023-01-01",
+		},
+		{
+			"ReadingStats: should fail(force DB error)",
+			http.MethodGet,
+			"query-error",
+			"failed to get reading stats.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			readingStatsHandler,
+			statsURL,
+		},
+		{
+			"ReadingStats: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			readingStatsHandler,
+			statsURL + "?from=2023-01-01&to=2023-12-31",
+		},
+		{
+			"SetGoal: should fail(missing target)",
+			http.MethodPost,
+			"",
+			"Invalid goal. Expected a positive books or pages target",
+			http.StatusBadRequest,
+			goalMissingTargetJsonFile,
+			setGoalHandler,
+			goalsURL,
+		},
+		{
+			"SetGoal: should fail(invalid year)",
+			http.MethodPost,
+			"",
+			fmt.Sprintf("Invalid year 20233. Expected a value between 1900 and %d", time.Now().Year()+1),
+			http.StatusBadRequest,
+			goalInvalidYearJsonFile,
+			setGoalHandler,
+			goalsURL,
+		},
+		{
+			"SetGoal: should fail(force DB error)",
+			http.MethodPost,
+			"update-error",
+			"failed to save goal.Refer to logs for more details",
+			http.StatusInternalServerError,
+			goalJsonFile,
+			setGoalHandler,
+			goalsURL,
+		},
+		{
+			"SetGoal: should pass",
+			http.MethodPost,
+			"",
+			"",
+			http.StatusOK,
+			goalJsonFile,
+			setGoalHandler,
+			goalsURL,
+		},
+		{
+			"ListGoals: should fail(force DB error)",
+			http.MethodGet,
+			"query-error",
+			"failed to get goals.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			listGoalsHandler,
+			goalsURL,
+		},
+		{
+			"ListGoals: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			listGoalsHandler,
+			goalsURL,
+		},
+		{
+			"GoalProgress: should fail(invalid year)",
+			http.MethodGet,
+			"",
+			"Invalid year bla",
+			http.StatusBadRequest,
+			"",
+			goalProgressHandler,
+			goalsURL + "/bla",
+		},
+		{
+			"GoalProgress: document not found error",
+			http.MethodGet,
+			"not-found-error",
+			"goal for year 2023 not found",
+			http.StatusNotFound,
+			"",
+			goalProgressHandler,
+			goalsURL + "/2023",
+		},
+		{
+			"GoalProgress: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			goalProgressHandler,
+			goalsURL + "/2023",
+		},
+		{
+			"GroupBooksByTag: should fail(force DB error)",
+			http.MethodGet,
+			"query-error",
+			"failed to get books.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			groupBooksByTagHandler,
+			tagURL,
+		},
+		{
+			"GroupBooksByTag: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			groupBooksByTagHandler,
+			tagURL,
+		},
+		{
+			"Get Books: should pass(filtered by tag)",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			getBooksHandler,
+			bookURL + "?tag=owned",
+		},
+		{
+			"CreateShelf: should fail(missing name)",
+			http.MethodPost,
+			"",
+			"Invalid shelf. Expected a name",
+			http.StatusBadRequest,
+			shelfMissingNameJsonFile,
+			createShelfHandler,
+			shelvesURL,
+		},
+		{
+			"CreateShelf: should fail(already exists)",
+			http.MethodPost,
+			"",
+			"shelf Book Club already exists",
+			http.StatusConflict,
+			shelfJsonFile,
+			createShelfHandler,
+			shelvesURL,
+		},
+		{
+			"CreateShelf: should pass",
+			http.MethodPost,
+			"not-found-error",
+			"",
+			http.StatusOK,
+			shelfJsonFile,
+			createShelfHandler,
+			shelvesURL,
+		},
+		{
+			"ListShelves: should fail(force DB error)",
+			http.MethodGet,
+			"query-error",
+			"failed to get shelves.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			listShelvesHandler,
+			shelvesURL,
+		},
+		{
+			"ListShelves: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			listShelvesHandler,
+			shelvesURL,
+		},
+		{
+			"GetShelf: document not found error",
+			http.MethodGet,
+			"not-found-error",
+			"shelf owned not found",
+			http.StatusNotFound,
+			"",
+			getShelfHandler,
+			shelvesURL + "/owned",
+		},
+		{
+			"GetShelf: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			getShelfHandler,
+			shelvesURL + "/book club",
+		},
+		{
+			"UpdateShelf: should fail(rename to an existing shelf)",
+			http.MethodPut,
+			"",
+			"shelf Lent Out already exists",
+			http.StatusConflict,
+			shelfRenamedJsonFile,
+			updateShelfHandler,
+			shelvesURL + "/book club",
+		},
+		{
+			"UpdateShelf: should pass",
+			http.MethodPut,
+			"",
+			"",
+			http.StatusOK,
+			shelfJsonFile,
+			updateShelfHandler,
+			shelvesURL + "/book club",
+		},
+		{
+			"DeleteShelf: should fail(force DB error)",
+			http.MethodDelete,
+			"remove-error",
+			"operation failed.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			deleteShelfHandler,
+			shelvesURL + "/book club",
+		},
+		{
+			"DeleteShelf: should pass",
+			http.MethodDelete,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			deleteShelfHandler,
+			shelvesURL + "/book club",
+		},
+		{
+			"AddBookToShelf: document not found error",
+			http.MethodPut,
+			"not-found-error",
+			"shelf book club not found",
+			http.StatusNotFound,
+			"",
+			addBookToShelfHandler,
+			shelvesURL + "/book club/books/TEST-ISBN-1",
+		},
+		{
+			"AddBookToShelf: should pass",
+			http.MethodPut,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			addBookToShelfHandler,
+			shelvesURL + "/book club/books/TEST-ISBN-1",
+		},
+		{
+			"RemoveBookFromShelf: should pass",
+			http.MethodDelete,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			removeBookFromShelf,
+			shelvesURL + "/book club/books/TEST-ISBN-1",
+		},
+		{
+			"SetReview: should fail(rating not in half stars)",
+			http.MethodPut,
+			"",
+			"Invalid review. Expected a rating between 0.5 and 5 in half stars",
+			http.StatusBadRequest,
+			reviewInvalidRatingFile,
+			setReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"SetReview: document not found error",
+			http.MethodPut,
+			"not-found-error",
+			"book with id TEST-ISBN-1 not found",
+			http.StatusNotFound,
+			reviewJsonFile,
+			setReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"SetReview: should pass",
+			http.MethodPut,
+			"",
+			"",
+			http.StatusOK,
+			reviewJsonFile,
+			setReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"GetReview: document not found error",
+			http.MethodGet,
+			"not-found-error",
+			"review of book TEST-ISBN-1 not found",
+			http.StatusNotFound,
+			"",
+			getReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"GetReview: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			getReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"DeleteReview: remove error",
+			http.MethodDelete,
+			"remove-error",
+			"operation failed.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			deleteReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"DeleteReview: should pass",
+			http.MethodDelete,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			deleteReviewHandler,
+			bookURL + "/TEST-ISBN-1/review",
+		},
+		{
+			"AddNote: should fail(missing text)",
+			http.MethodPost,
+			"",
+			"Invalid note. Expected a text and a page that is not negative",
+			http.StatusBadRequest,
+			noteMissingTextJsonFile,
+			addNoteHandler,
+			bookURL + "/TEST-ISBN-1/notes",
+		},
+		{
+			"AddNote: should pass",
+			http.MethodPost,
+			"",
+			"",
+			http.StatusOK,
+			noteJsonFile,
+			addNoteHandler,
+			bookURL + "/TEST-ISBN-1/notes",
+		},
+		{
+			"ListNotes: query error",
+			http.MethodGet,
+			"query-error",
+			"operation failed.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			listNotesHandler,
+			bookURL + "/TEST-ISBN-1/notes",
+		},
+		{
+			"ListNotes: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			listNotesHandler,
+			bookURL + "/TEST-ISBN-1/notes",
+		},
+		{
+			"UpdateNote: document not found error",
+			http.MethodPut,
+			"not-found-error",
+			"note note-1 of book TEST-ISBN-1 not found",
+			http.StatusNotFound,
+			noteJsonFile,
+			updateNoteHandler,
+			bookURL + "/TEST-ISBN-1/notes/note-1",
+		},
+		{
+			"UpdateNote: should pass",
+			http.MethodPut,
+			"",
+			"",
+			http.StatusOK,
+			noteJsonFile,
+			updateNoteHandler,
+			bookURL + "/TEST-ISBN-1/notes/note-1",
+		},
+		{
+			"DeleteNote: should pass",
+			http.MethodDelete,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			deleteNoteHandler,
+			bookURL + "/TEST-ISBN-1/notes/note-1",
+		},
+		{
+			"AddHighlight: should fail(invalid color)",
+			http.MethodPost,
+			"",
+			"Invalid highlight. Expected a text, a page that is not negative and a color among YELLOW, BLUE, PINK, ORANGE",
+			http.StatusBadRequest,
+			highlightInvalidColorFile,
+			addHighlightHandler,
+			bookURL + "/TEST-ISBN-1/highlights",
+		},
+		{
+			"AddHighlight: document not found error",
+			http.MethodPost,
+			"not-found-error",
+			"book with id TEST-ISBN-1 not found",
+			http.StatusNotFound,
+			highlightJsonFile,
+			addHighlightHandler,
+			bookURL + "/TEST-ISBN-1/highlights",
+		},
+		{
+			"AddHighlight: should pass",
+			http.MethodPost,
+			"",
+			"",
+			http.StatusOK,
+			highlightJsonFile,
+			addHighlightHandler,
+			bookURL + "/TEST-ISBN-1/highlights",
+		},
+		{
+			"ListHighlights: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			listHighlightsHandler,
+			bookURL + "/TEST-ISBN-1/highlights",
+		},
+		{
+			"UpdateHighlight: document not found error",
+			http.MethodPut,
+			"not-found-error",
+			"highlight highlight-1 of book TEST-ISBN-1 not found",
+			http.StatusNotFound,
+			highlightJsonFile,
+			updateHighlightHandler,
+			bookURL + "/TEST-ISBN-1/highlights/highlight-1",
+		},
+		{
+			"UpdateHighlight: should pass",
+			http.MethodPut,
+			"",
+			"",
+			http.StatusOK,
+			highlightJsonFile,
+			updateHighlightHandler,
+			bookURL + "/TEST-ISBN-1/highlights/highlight-1",
+		},
+		{
+			"DeleteHighlight: remove error",
+			http.MethodDelete,
+			"remove-error",
+			"operation failed.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			deleteHighlightHandler,
+			bookURL + "/TEST-ISBN-1/highlights/highlight-1",
+		},
+		{
+			"ImportClippings: query error",
+			http.MethodPost,
+			"query-error",
+			"failed to import clippings.Refer to logs for more details",
+			http.StatusInternalServerError,
+			clippingsFile,
+			importClippingsHandler,
+			highlightsURL + "/import",
+		},
+		{
+			"ImportClippings: should pass",
+			http.MethodPost,
+			"not-found-error",
+			"",
+			http.StatusOK,
+			clippingsFile,
+			importClippingsHandler,
+			highlightsURL + "/import",
+		},
+		{
+			"ExportHighlights: query error",
+			http.MethodGet,
+			"query-error",
+			"failed to export highlights.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			exportHighlightsHandler,
+			highlightsURL + "/export",
+		},
+		{
+			"ExportHighlights: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			exportHighlightsHandler,
+			highlightsURL + "/export",
+		},
+		{
+			"Recommend: should fail(invalid length)",
+			http.MethodGet,
+			"",
+			"Invalid length epic. Expected one of short, medium, long",
+			http.StatusBadRequest,
+			"",
+			recommendHandler,
+			recommendURL + "?length=epic",
+		},
+		{
+			"Recommend: should fail(invalid limit)",
+			http.MethodGet,
+			"",
+			"Invalid limit 0. Expected a number between 1 and 50",
+			http.StatusBadRequest,
+			"",
+			recommendHandler,
+			recommendURL + "?limit=0",
+		},
+		{
+			"Recommend: query error",
+			http.MethodGet,
+			"query-error",
+			"failed to get recommendations.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			recommendHandler,
+			recommendURL,
+		},
+		{
+			"Recommend: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			recommendHandler,
+			recommendURL + "?length=short&mood=dark&limit=5",
+		},
+		{
+			"CreateGenre: should fail(missing name)",
+			http.MethodPost,
+			"",
+			"Invalid genre. Expected a name",
+			http.StatusBadRequest,
+			genreMissingNameJsonFile,
+			createGenreHandler,
+			genresURL,
+		},
+		{
+			"CreateGenre: should fail(unknown parent)",
+			http.MethodPost,
+			"",
+			"parent genre Fiction not found",
+			http.StatusNotFound,
+			genreJsonFile,
+			createGenreHandler,
+			genresURL,
+		},
+		{
+			"CreateGenre: should pass",
+			http.MethodPost,
+			"",
+			"",
+			http.StatusOK,
+			genreNewJsonFile,
+			createGenreHandler,
+			genresURL,
+		},
+		{
+			"ListGenres: query error",
+			http.MethodGet,
+			"query-error",
+			"failed to get genres.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			listGenresHandler,
+			genresURL,
+		},
+		{
+			"ListGenres: should pass",
+			http.MethodGet,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			listGenresHandler,
+			genresURL,
+		},
+		{
+			"UpdateGenre: document not found error",
+			http.MethodPut,
+			"not-found-error",
+			"genre fantasy not found",
+			http.StatusNotFound,
+			genreNewJsonFile,
+			updateGenreHandler,
+			genresURL + "/fantasy",
+		},
+		{
+			"UpdateGenre: should pass",
+			http.MethodPut,
+			"",
+			"",
+			http.StatusOK,
+			genreNewJsonFile,
+			updateGenreHandler,
+			genresURL + "/science fiction",
+		},
+		{
+			"DeleteGenre: remove error",
+			http.MethodDelete,
+			"remove-error",
+			"operation failed.Refer to logs for more details",
+			http.StatusInternalServerError,
+			"",
+			deleteGenreHandler,
+			genresURL + "/science fiction",
+		},
+		{
+			"DeleteGenre: should pass",
+			http.MethodDelete,
+			"",
+			"",
+			http.StatusOK,
+			"",
+			deleteGenreHandler,
+			genresURL + "/science fiction",
+		},
+		{
+			"RemapGenre: should fail(missing genres)",
+			http.MethodPost,
+			"",
+			"Invalid remap. Expected the from and to genres",
+			http.StatusBadRequest,
+			genreRemapMissingJsonFile,
+			remapGenreHandler,
+			"/api/v1/admin/genres/remap",
+		},
+		{
+			"RemapGenre: should pass",
+			http.MethodPost,
+			"",
+			"",
+			http.StatusOK,
+			genreRemapJsonFile,
+			remapGenreHandler,
+			"/api/v1/admin/genres/remap",
+		},
+	}
+
+	for _, test := range crulTests {
+		t.Run(test.testName, func(t *testing.T) {
+			//setup
+			file, _ := os.ReadFile(testFolderPath + test.requestPayload)
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.httpMethod, strings.ReplaceAll(test.url, " ", "%20"), bytes.NewBuffer(file))
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = req
+
+			cbStorage, _ := database.NewFakeCouchbaseStorage(test.errorFlag)
+			bookSvc := service.NewBookTracker(cbStorage)
+			goalSvc := service.NewGoalTracker(cbStorage, bookSvc)
+			shelfSvc := service.NewShelfTracker(cbStorage, bookSvc)
+			reviewSvc := service.NewReviewTracker(cbStorage, bookSvc)
+			highlightSvc := service.NewHighlightTracker(cbStorage, bookSvc)
+			server := NewServer(config.Default(), Services{BookTracker: bookSvc, GoalTracker: goalSvc, ShelfTracker: shelfSvc,
+				ReviewTracker: reviewSvc, HighlightTracker: highlightSvc, Recommender: service.NewRecommender(bookSvc),
+				GenreTracker: service.NewGenreTracker(cbStorage)})
+
+			// actual tests
+			switch test.handler {
+			case createBookHandler:
+				server.AddBook(c)
+			case updateBookHandler:
+				server.UpdateBook(c)
+			case getBookHandler:
+				server.GetBook(c)
+			case getBooksHandler:
+				server.ListBooks(c)
+			case groupBooksByGenreHandler:
+				server.GroupBooksByGenre(c)
+			case genreBooksHandler:
+				c.Params = gin.Params{{Key: "genre", Value: path.Base(req.URL.Path)}}
+				server.GenreBooks(c)
+			case groupBooksHandler:
+				server.GroupBooks(c)
+			case exportBooksHandler:
+				server.ExportBooks(c)
+			case readingStatsHandler:
+				server.ReadingStats(c)
+			case setGoalHandler:
+				server.SetGoal(c)
+			case listGoalsHandler:
+				server.ListGoals(c)
+			case goalProgressHandler:
+				c.Params = gin.Params{{Key: "year", Value: path.Base(test.url)}}
+				server.GoalProgress(c)
+			case groupBooksByTagHandler:
+				server.GroupBooksByTag(c)
+			case createShelfHandler:
+				server.CreateShelf(c)
+			case listShelvesHandler:
+				server.ListShelves(c)
+			case getShelfHandler:
+				c.Params = shelfParams(test.url)
+				server.GetShelf(c)
+			case updateShelfHandler:
+				c.Params = shelfParams(test.url)
+				server.UpdateShelf(c)
+			case deleteShelfHandler:
+				c.Params = shelfParams(test.url)
+				server.DeleteShelf(c)
+			case addBookToShelfHandler:
+				c.Params = shelfParams(test.url)
+				server.AddBookToShelf(c)
+			case removeBookFromShelf:
+				c.Params = shelfParams(test.url)
+				server.RemoveBookFromShelf(c)
+			case setReviewHandler:
+				c.Params = bookParams(test.url)
+				server.SetReview(c)
+			case getReviewHandler:
+				c.Params = bookParams(test.url)
+				server.GetReview(c)
+			case deleteReviewHandler:
+				c.Params = bookParams(test.url)
+				server.DeleteReview(c)
+			case addNoteHandler:
+				c.Params = bookParams(test.url)
+				server.AddNote(c)
+			case listNotesHandler:
+				c.Params = bookParams(test.url)
+				server.ListNotes(c)
+			case updateNoteHandler:
+				c.Params = bookParams(test.url)
+				server.UpdateNote(c)
+			case deleteNoteHandler:
+				c.Params = bookParams(test.url)
+				server.DeleteNote(c)
+			case addHighlightHandler:
+				c.Params = bookParams(test.url)
+				server.AddHighlight(c)
+			case listHighlightsHandler:
+				c.Params = bookParams(test.url)
+				server.ListHighlights(c)
+			case updateHighlightHandler:
+				c.Params = bookParams(test.url)
+				server.UpdateHighlight(c)
+			case deleteHighlightHandler:
+				c.Params = bookParams(test.url)
+				server.DeleteHighlight(c)
+			case importClippingsHandler:
+				server.ImportClippings(c)
+			case exportHighlightsHandler:
+				server.ExportHighlights(c)
+			case recommendHandler:
+				server.Recommend(c)
+			case createGenreHandler:
+				server.CreateGenre(c)
+			case listGenresHandler:
+				server.ListGenres(c)
+			case updateGenreHandler:
+				c.Params = gin.Params{{Key: "name", Value: path.Base(test.url)}}
+				server.UpdateGenre(c)
+			case deleteGenreHandler:
+				c.Params = gin.Params{{Key: "name", Value: path.Base(test.url)}}
+				server.DeleteGenre(c)
+			case remapGenreHandler:
+				server.RemapGenre(c)
+			}
+
+			//assertions
+			if rr.Code != test.statusCodeExpected {
+				t.Errorf("Handler %s returned with incorrect status code - got (%d) wanted (%d)", test.handler, rr.Code, test.statusCodeExpected)
+			}
+
+			//check error messages for failures
+			if rr.Code != http.StatusOK && test.errorExpected != "" {
+				var resp entity.BookResponse
+				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+					t.Fatalf("Should not fail: found error %v ", err)
+				}
+
+				if resp.Message != test.errorExpected {
+					t.Errorf("Handler %s returned with incorrect error - got (%s) wanted (%s)", test.handler, resp.Message, test.errorExpected)
+				}
+			}
+		})
+	}
+
+}
+
+// updatedBooks - records the books passed to UpdateBook
+type updatedBooks struct {
+	service.BookTracker
+	books *[]entity.Book
+}
+
+func (u updatedBooks) UpdateBook(_ context.Context, book entity.Book) error {
+	*u.books = append(*u.books, book)
+	return nil
+}
+
+func TestAutoFinish(t *testing.T) {
+	tests := []struct {
+		testName        string
+		url             string
+		statusExpected  string
+		messageExpected string
+	}{
+		{"UpdateBook Book: should pass(auto finish)", bookURL + "?auto_finish=true", entity.StatusFinished,
+			"book updated successfully"},
+		{"UpdateBook Book: should pass(bookmark on last page)", bookURL, "IN PROGRESS",
+			"book updated successfully. Bookmark is on the last page, set status to FINISHED or retry with auto_finish=true"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			file, _ := os.ReadFile(testFolderPath + bookLastPageJsonFile)
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request, _ = http.NewRequest(http.MethodPut, test.url, bytes.NewBuffer(file))
+
+			var books []entity.Book
+			server := NewServer(config.Default(), Services{BookTracker: updatedBooks{books: &books}})
+			server.UpdateBook(c)
+
+			var resp entity.BookResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("Should not fail: found error %v ", err)
+			}
+			if rr.Code != http.StatusOK || resp.Message != test.messageExpected {
+				t.Errorf("%s got (%d %s) wanted (%d %s)", test.testName, rr.Code, resp.Message, http.StatusOK, test.messageExpected)
+			}
+			if len(books) != 1 || books[0].Status != test.statusExpected {
+				t.Errorf("%s updated (%+v) wanted status (%s)", test.testName, books, test.statusExpected)
+			}
+		})
+	}
+}
+
+// storedBooks - a book repository keeping the books in a map
+type storedBooks map[string]entity.Book
+
+func (s storedBooks) Upsert(_ context.Context, id string, doc interface{}) error {
+	s[id] = doc.(entity.Book)
+	return nil
+}
+
+func (s storedBooks) GetAll(context.Context) ([]entity.Book, error) {
+	var books []entity.Book
+	for _, book := range s {
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+func (s storedBooks) Get(_ context.Context, id string) (*entity.Book, error) {
+	book, ok := s[id]
+	if !ok {
+		return nil, errors.New("document not found")
+	}
+	return &book, nil
+}
+
+func TestAddBookRating(t *testing.T) {
+	tests := []struct {
+		testName       string
+		existing       *entity.Book
+		ratingExpected float64
+	}{
+		{"AddBook Book: should pass(rating of a new book ignored)", nil, 0},
+		{"AddBook Book: should pass(rating of the review kept)", &entity.Book{ISBN: "TEST-ISBN-1", Rating: 4.5}, 4.5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			file, _ := os.ReadFile(testFolderPath + bookRatingJsonFile)
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request, _ = http.NewRequest(http.MethodPost, bookURL, bytes.NewBuffer(file))
+
+			books := storedBooks{}
+			if test.existing != nil {
+				books[test.existing.ISBN] = *test.existing
+			}
+			server := NewServer(config.Default(), Services{BookTracker: service.NewBookTracker(books)})
+			server.AddBook(c)
+
+			if rr.Code != http.StatusOK || books["TEST-ISBN-1"].Rating != test.ratingExpected {
+				t.Errorf("%s got (%d %v) wanted (%d %v)", test.testName, rr.Code, books["TEST-ISBN-1"].Rating, http.StatusOK, test.ratingExpected)
+			}
+		})
+	}
+}
+
+// shelfParams - the handlers are called directly, so the route params are taken from /shelves/:name/books/:id
+func shelfParams(url string) gin.Params {
+	parts := strings.Split(strings.TrimPrefix(url, shelvesURL+"/"), "/")
+	params := gin.Params{{Key: "name", Value: parts[0]}}
+	if len(parts) == 3 {
+		params = append(params, gin.Param{Key: "id", Value: parts[2]})
+	}
+	return params
+}
+
+// bookParams - the route params of the book sub-resources /book/:id/notes/:noteId and /book/:id/highlights/:highlightId
+func bookParams(url string) gin.Params {
+	parts := strings.Split(strings.TrimPrefix(url, bookURL+"/"), "/")
+	params := gin.Params{{Key: "id", Value: parts[0]}}
+	if len(parts) == 3 {
+		key := map[string]string{"notes": "noteId", "highlights": "highlightId"}[parts[1]]
+		params = append(params, gin.Param{Key: key, Value: parts[2]})
+	}
+	return params
+}
+
+func TestTracing(t *testing.T) {
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(provider)
+	defer otel.SetTextMapPropagator(propagator)
+
+	cbStorage, _ := database.NewFakeCouchbaseStorage("")
+	server := NewServer(config.Default(), Services{BookTracker: service.NewBookTracker(cbStorage)})
+	req := httptest.NewRequest(http.MethodGet, bookURL+"?sortKey=title", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	rr := httptest.NewRecorder()
+	server.Routes().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("TestTracing expected(%v) got (%v)", http.StatusOK, rr.Code)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() != traceID {
+			t.Errorf("TestTracing span %s expected trace(%s) got (%s)", span.Name(), traceID, span.SpanContext().TraceID())
+		}
+		spans[span.Name()] = span
+	}
+	for name, parent := range map[string]string{"/api/v1/book": "", "BookTracker.ListBooks": "/api/v1/book", "encode": "/api/v1/book"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("TestTracing expected span(%s) got none", name)
+			continue
+		}
+		want := parentID
+		if parent != "" && spans[parent] != nil {
+			want = spans[parent].SpanContext().SpanID().String()
+		}
+		if got := span.Parent().SpanID().String(); got != want {
+			t.Errorf("TestTracing span %s expected parent(%s) got (%s)", name, want, got)
+		}
+	}
 }
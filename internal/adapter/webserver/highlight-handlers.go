@@ -0,0 +1,160 @@
+package webserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	clippingsFormField    = "file"
+	highlightsExportFile  = "highlights.md"
+	markdownContentType   = "text/markdown; charset=utf-8"
+	multipartContentType  = "multipart/form-data"
+	invalidHighlightError = "Invalid highlight. Expected a text, a page that is not negative and a color among %s"
+)
+
+var highlightColors = []string{entity.ColorYellow, entity.ColorBlue, entity.ColorPink, entity.ColorOrange}
+
+// AddHighlight - checks incoming request and adds a highlight to the book(ISBN)
+func (s *Server) AddHighlight(c *gin.Context) {
+	var highlight entity.Highlight
+	bookId, _ := c.Params.Get("id")
+
+	if err := s.bindJSON(c, &highlight); err != nil || !highlightValid(highlight) {
+		msg := fmt.Sprintf(invalidHighlightError, strings.Join(highlightColors, ", "))
+		logger(c).WithError(err).Error("AddHighlight invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	added, err := s.Services.HighlightTracker.AddHighlight(c.Request.Context(), bookId, highlight)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldISBN: bookId, logging.FieldPayload: highlight}).Error("AddHighlight error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewHighlightResponse(http.StatusOK, "highlight added successfully", added, nil))
+}
+
+// ListHighlights - lists the highlights of the book(ISBN) ordered by page and location
+func (s *Server) ListHighlights(c *gin.Context) {
+	bookId, _ := c.Params.Get("id")
+	highlights, err := s.Services.HighlightTracker.ListHighlights(c.Request.Context(), bookId)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("ListHighlights error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewHighlightResponse(http.StatusOK, "highlights retrieval successful", nil, highlights))
+}
+
+// UpdateHighlight - checks incoming request and replaces the highlight
+func (s *Server) UpdateHighlight(c *gin.Context) {
+	var highlight entity.Highlight
+	bookId, _ := c.Params.Get("id")
+	highlightId, _ := c.Params.Get("highlightId")
+
+	if err := s.bindJSON(c, &highlight); err != nil || !highlightValid(highlight) {
+		msg := fmt.Sprintf(invalidHighlightError, strings.Join(highlightColors, ", "))
+		logger(c).WithError(err).Error("UpdateHighlight invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	updated, err := s.Services.HighlightTracker.UpdateHighlight(c.Request.Context(), bookId, highlightId, highlight)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldISBN: bookId, logging.FieldHighlightID: highlightId, logging.FieldPayload: highlight}).Error("UpdateHighlight error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewHighlightResponse(http.StatusOK, "highlight updated successfully", updated, nil))
+}
+
+// DeleteHighlight - deletes the highlight of the book(ISBN)
+func (s *Server) DeleteHighlight(c *gin.Context) {
+	bookId, _ := c.Params.Get("id")
+	highlightId, _ := c.Params.Get("highlightId")
+	err := s.Services.HighlightTracker.DeleteHighlight(c.Request.Context(), bookId, highlightId)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldISBN: bookId, logging.FieldHighlightID: highlightId}).Error("DeleteHighlight error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "highlight deleted successfully"))
+}
+
+// ImportClippings - imports a Kindle "My Clippings.txt" file, uploaded as the "file" form field or sent as the request body
+func (s *Server) ImportClippings(c *gin.Context) {
+	var clippings io.Reader = c.Request.Body
+	if c.ContentType() == multipartContentType {
+		header, err := c.FormFile(clippingsFormField)
+		if err != nil {
+			logger(c).WithError(err).Error("ImportClippings invalid request")
+			handleBindError(c, err, "Invalid request. Expected the clippings file in the file field")
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			logger(c).WithError(err).Error("ImportClippings error opening upload")
+			c.JSON(http.StatusInternalServerError, entity.NewGenericResponse(http.StatusInternalServerError, "failed to read clippings.Refer to logs for more details"))
+			return
+		}
+		defer file.Close()
+		clippings = file
+	}
+
+	result, err := s.Services.HighlightTracker.ImportClippings(c.Request.Context(), clippings)
+	if err != nil {
+		logger(c).WithError(err).Error("ImportClippings error")
+		// a body streamed past MAX_UPLOAD_BYTES fails while it is parsed
+		if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+			handleBindError(c, err, "")
+			return
+		}
+		handleError(c, err, "failed to import clippings.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewImportResponse(http.StatusOK, "clippings import successful", result))
+}
+
+// ExportHighlights - exports all highlights as a Markdown file attached to the response
+func (s *Server) ExportHighlights(c *gin.Context) {
+	md, err := s.Services.HighlightTracker.ExportMarkdown(c.Request.Context())
+	if err != nil {
+		logger(c).WithError(err).Error("ExportHighlights error")
+		handleError(c, err, "failed to export highlights.Refer to logs for more details")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", highlightsExportFile))
+	c.Data(http.StatusOK, markdownContentType, md)
+}
+
+func highlightValid(highlight entity.Highlight) bool {
+	if strings.TrimSpace(highlight.Text) == "" || highlight.Page < 0 {
+		return false
+	}
+	if highlight.Color == "" {
+		return true
+	}
+	for _, valid := range highlightColors {
+		if strings.ToUpper(highlight.Color) == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// requestLogger - carries a logger with the request id in the request context, echoes the id in the response and
+// writes one access log line per request
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(logging.RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		c.Header(logging.RequestIDHeader, requestID)
+		entry := logrus.WithField(logging.FieldRequestID, requestID)
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), entry))
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		access := logging.FromContext(c.Request.Context()).WithFields(logrus.Fields{
+			logging.FieldMethod:   c.Request.Method,
+			logging.FieldRoute:    route,
+			logging.FieldPath:     c.Request.URL.Path,
+			logging.FieldStatus:   c.Writer.Status(),
+			logging.FieldLatency:  float64(time.Since(start).Microseconds()) / 1000,
+			logging.FieldClientIP: c.ClientIP(),
+		})
+		switch status := c.Writer.Status(); {
+		case status >= http.StatusInternalServerError:
+			access.Error("request completed")
+		case status >= http.StatusBadRequest:
+			access.Warn("request completed")
+		default:
+			access.Info("request completed")
+		}
+	}
+}
+
+// cacheBypass - the reads of the requests with the X-Cache-Bypass: true header skip the cache, e.g. to tell whether
+// a stale answer comes from it
+func cacheBypass() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bypass, _ := strconv.ParseBool(c.GetHeader(cache.BypassHeader)); bypass {
+			c.Request = c.Request.WithContext(cache.WithBypass(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// requestTimeout - the deadline of the request context, the repository operations still running when it passes are
+// canceled and the request fails with 504. 0 disables it
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// bodyLimit - request bodies are read up to maxBytes, larger ones fail with 413. The declared content length is
+// checked upfront, chunked bodies fail when the handler reads past the limit(see handleBindError)
+func bodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			logger(c).WithField("content_length", c.Request.ContentLength).Error("request body too large")
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, entity.NewGenericResponse(http.StatusRequestEntityTooLarge, bodyTooLargeMessage(maxBytes)))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// logger - the logger of the request
+func logger(c *gin.Context) *logrus.Entry {
+	return logging.FromContext(c.Request.Context())
+}
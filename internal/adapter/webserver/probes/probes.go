@@ -1,14 +1,13 @@
 package probes
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"os"
 )
 
-// Liveness probe
-func Liveness(c *gin.Context) {
-	c.Writer.WriteHeader(http.StatusOK)
-	fmt.Fprintf(c.Writer, "{ \"name\": \""+os.Getenv("NAME")+"\" }")
+// Liveness probe of the service name
+func Liveness(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"name": name})
+	}
 }
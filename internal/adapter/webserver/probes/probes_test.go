@@ -1,10 +1,14 @@
 package probes
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestProbes(t *testing.T) {
@@ -14,11 +18,86 @@ func TestProbes(t *testing.T) {
 		rr := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(rr)
 		c.Request = req
-		Liveness(c)
+		Liveness(`book-tracker "100%"`)(c)
 
 		if rr.Code != http.StatusOK {
 			t.Errorf("Handler %s returned with error - got (%v) wanted (%v)", "liveness", rr.Code, http.StatusOK)
 		}
+		var resp map[string]string
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp["name"] != `book-tracker "100%"` {
+			t.Errorf("Handler %s returned with incorrect name - got (%v, %v) wanted (%s)", "liveness", resp, err, `book-tracker "100%"`)
+		}
+	})
+
+	t.Run("Test readiness : should fail once draining", func(t *testing.T) {
+		readiness := &Readiness{}
+		for _, want := range []int{http.StatusOK, http.StatusServiceUnavailable} {
+			req, _ := http.NewRequest(http.MethodGet, "", nil)
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request = req
+			readiness.Probe(c)
+
+			if rr.Code != want {
+				t.Errorf("Handler %s returned with error - got (%v) wanted (%v)", "readiness", rr.Code, want)
+			}
+			readiness.Drain()
+		}
 	})
 
+	t.Run("Test readiness and startup : dependency status", func(t *testing.T) {
+		tests := []struct {
+			testName string
+			ping     error
+			expected int
+			status   string
+		}{
+			{"dependency up", nil, http.StatusOK, statusUp},
+			{"dependency down", errors.New("bucket ping error:timeout"), http.StatusServiceUnavailable, statusDown},
+		}
+
+		for _, test := range tests {
+			readiness := NewReadiness("book-tracker-service", time.Second, time.Minute, Check{Name: "couchbase-bucket", Ping: func(time.Duration) error {
+				return test.ping
+			}})
+			for name, probe := range map[string]gin.HandlerFunc{"readiness": readiness.Probe, "startup": readiness.Startup} {
+				rr := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(rr)
+				c.Request, _ = http.NewRequest(http.MethodGet, "", nil)
+				probe(c)
+
+				var response probeResponse
+				_ = json.Unmarshal(rr.Body.Bytes(), &response)
+				if rr.Code != test.expected || len(response.Dependencies) != 1 || response.Dependencies[0].Status != test.status {
+					t.Errorf("%s %s got (%v %s) wanted (%v) with the dependency %s", name, test.testName, rr.Code, rr.Body.String(), test.expected, test.status)
+				}
+			}
+		}
+	})
+
+	t.Run("Test readiness : pings are cached", func(t *testing.T) {
+		var mu sync.Mutex
+		pings := 0
+		readiness := NewReadiness("book-tracker-service", time.Second, time.Minute, Check{Name: "couchbase-cluster", Ping: func(time.Duration) error {
+			mu.Lock()
+			defer mu.Unlock()
+			pings++
+			return nil
+		}})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+				c.Request, _ = http.NewRequest(http.MethodGet, "", nil)
+				readiness.Probe(c)
+			}()
+		}
+		wg.Wait()
+		if pings != 1 {
+			t.Errorf("readiness pinged (%d) times wanted (1)", pings)
+		}
+	})
 }
@@ -0,0 +1,136 @@
+package probes
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	statusReady    = "ready"
+	statusNotReady = "not ready"
+	statusDraining = "draining"
+	statusUp       = "up"
+	statusDown     = "down"
+
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// Check - a dependency pinged by the readiness and startup probes. Ping fails when it does not answer within the timeout
+type Check struct {
+	Name string
+	Ping func(time.Duration) error
+}
+
+// DependencyStatus - the outcome of the last ping of a dependency
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type probeResponse struct {
+	Name         string             `json:"name"`
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// Readiness probe. The dependencies are pinged at most once per cache TTL whatever the number of probe requests, the
+// requests arriving during a ping wait for its outcome. It fails once the service starts draining, so that the load
+// balancer stops sending requests before the server stops accepting connections
+type Readiness struct {
+	name     string
+	checks   []Check
+	timeout  time.Duration
+	ttl      time.Duration
+	draining atomic.Bool
+
+	mu       sync.Mutex
+	checked  time.Time
+	statuses []DependencyStatus
+}
+
+// NewReadiness - name is the service name in the responses, timeout bounds every ping, ttl is how long the outcome of
+// the pings is reused
+func NewReadiness(name string, timeout time.Duration, ttl time.Duration, checks ...Check) *Readiness {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &Readiness{name: name, checks: checks, timeout: timeout, ttl: ttl}
+}
+
+// Drain - fails the readiness probe from now on
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Probe - the readiness probe, 503 while draining or when a dependency is down
+func (r *Readiness) Probe(c *gin.Context) {
+	if r.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, probeResponse{Name: r.name, Status: statusDraining})
+		return
+	}
+	r.respond(c)
+}
+
+// Startup - the startup probe, 503 until every dependency answers. Draining does not matter here, the probe is not
+// called anymore once the service started
+func (r *Readiness) Startup(c *gin.Context) {
+	r.respond(c)
+}
+
+func (r *Readiness) respond(c *gin.Context) {
+	statuses, up := r.status()
+	if !up {
+		c.JSON(http.StatusServiceUnavailable, probeResponse{Name: r.name, Status: statusNotReady, Dependencies: statuses})
+		return
+	}
+	c.JSON(http.StatusOK, probeResponse{Name: r.name, Status: statusReady, Dependencies: statuses})
+}
+
+// status - the cached statuses of the dependencies, pinged again once the cache expired
+func (r *Readiness) status() ([]DependencyStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.statuses == nil || time.Since(r.checked) >= r.ttl {
+		r.statuses = r.ping()
+		r.checked = time.Now()
+	}
+	for _, status := range r.statuses {
+		if status.Status != statusUp {
+			return r.statuses, false
+		}
+	}
+	return r.statuses, true
+}
+
+// ping - pings the dependencies concurrently, so that the probe takes at most one timeout
+func (r *Readiness) ping() []DependencyStatus {
+	statuses := make([]DependencyStatus, len(r.checks))
+	var wg sync.WaitGroup
+	for i, check := range r.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Ping(r.timeout)
+			statuses[i] = DependencyStatus{Name: check.Name, Status: statusUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				statuses[i].Status = statusDown
+				statuses[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	return statuses
+}
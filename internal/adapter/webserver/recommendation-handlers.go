@@ -0,0 +1,57 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRecommendations = 50
+
+var bookLengths = []string{entity.LengthShort, entity.LengthMedium, entity.LengthLong}
+
+// Recommend - ranks the UNREAD books, optionally filtered by length and mood, with the reasons for each pick
+func (s *Server) Recommend(c *gin.Context) {
+	filter, err := recommendationFilter(c)
+	if err != nil {
+		logger(c).WithError(err).Error("Recommend invalid request")
+		c.JSON(http.StatusBadRequest, entity.NewGenericResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	picks, err := s.Services.Recommender.Recommend(c.Request.Context(), filter)
+	if err != nil {
+		logger(c).WithError(err).Error("Recommend error")
+		handleError(c, err, "failed to get recommendations.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewRecommendationResponse(http.StatusOK, "recommendations retrieval successful", picks))
+}
+
+func recommendationFilter(c *gin.Context) (entity.RecommendationFilter, error) {
+	filter := entity.RecommendationFilter{Length: strings.ToLower(c.Query(consts.Length)), Mood: c.Query(consts.Mood)}
+	if filter.Length != "" {
+		valid := false
+		for _, length := range bookLengths {
+			valid = valid || filter.Length == length
+		}
+		if !valid {
+			return filter, fmt.Errorf("Invalid length %s. Expected one of %s", filter.Length, strings.Join(bookLengths, ", "))
+		}
+	}
+	if limit := c.Query(consts.Limit); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxRecommendations {
+			return filter, fmt.Errorf("Invalid limit %s. Expected a number between 1 and %d", limit, maxRecommendations)
+		}
+		filter.Limit = value
+	}
+	return filter, nil
+}
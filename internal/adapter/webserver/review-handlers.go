@@ -0,0 +1,147 @@
+package webserver
+
+import (
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const maxRating = 5
+
+// SetReview - checks incoming request and creates or replaces the review of the book(ISBN)
+func (s *Server) SetReview(c *gin.Context) {
+	var review entity.Review
+	bookId, _ := c.Params.Get("id")
+
+	if err := s.bindJSON(c, &review); err != nil || !ratingValid(review.Rating) {
+		msg := "Invalid review. Expected a rating between 0.5 and 5 in half stars"
+		logger(c).WithError(err).Error("SetReview invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	saved, err := s.Services.ReviewTracker.SetReview(c.Request.Context(), bookId, review)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("SetReview error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewReviewResponse(http.StatusOK, "review saved successfully", saved))
+}
+
+// GetReview - gets the review of the book(ISBN)
+func (s *Server) GetReview(c *gin.Context) {
+	bookId, _ := c.Params.Get("id")
+	review, err := s.Services.ReviewTracker.GetReview(c.Request.Context(), bookId)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("GetReview error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewReviewResponse(http.StatusOK, "review retrieval successful", review))
+}
+
+// DeleteReview - deletes the review and clears the rating of the book(ISBN)
+func (s *Server) DeleteReview(c *gin.Context) {
+	bookId, _ := c.Params.Get("id")
+	err := s.Services.ReviewTracker.DeleteReview(c.Request.Context(), bookId)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("DeleteReview error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "review deleted successfully"))
+}
+
+// AddNote - checks incoming request and adds a note to the book(ISBN)
+func (s *Server) AddNote(c *gin.Context) {
+	var note entity.Note
+	bookId, _ := c.Params.Get("id")
+
+	if err := s.bindJSON(c, &note); err != nil || !noteValid(note) {
+		msg := "Invalid note. Expected a text and a page that is not negative"
+		logger(c).WithError(err).Error("AddNote invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	added, err := s.Services.ReviewTracker.AddNote(c.Request.Context(), bookId, note)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("AddNote error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewNoteResponse(http.StatusOK, "note added successfully", added, nil))
+}
+
+// ListNotes - lists the notes of the book(ISBN) ordered by page
+func (s *Server) ListNotes(c *gin.Context) {
+	bookId, _ := c.Params.Get("id")
+	notes, err := s.Services.ReviewTracker.ListNotes(c.Request.Context(), bookId)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldISBN, bookId).Error("ListNotes error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewNoteResponse(http.StatusOK, "notes retrieval successful", nil, notes))
+}
+
+// UpdateNote - checks incoming request and replaces the text and page of the note
+func (s *Server) UpdateNote(c *gin.Context) {
+	var note entity.Note
+	bookId, _ := c.Params.Get("id")
+	noteId, _ := c.Params.Get("noteId")
+
+	if err := s.bindJSON(c, &note); err != nil || !noteValid(note) {
+		msg := "Invalid note. Expected a text and a page that is not negative"
+		logger(c).WithError(err).Error("UpdateNote invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	updated, err := s.Services.ReviewTracker.UpdateNote(c.Request.Context(), bookId, noteId, note)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldISBN: bookId, logging.FieldNoteID: noteId}).Error("UpdateNote error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewNoteResponse(http.StatusOK, "note updated successfully", updated, nil))
+}
+
+// DeleteNote - deletes the note of the book(ISBN)
+func (s *Server) DeleteNote(c *gin.Context) {
+	bookId, _ := c.Params.Get("id")
+	noteId, _ := c.Params.Get("noteId")
+	err := s.Services.ReviewTracker.DeleteNote(c.Request.Context(), bookId, noteId)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldISBN: bookId, logging.FieldNoteID: noteId}).Error("DeleteNote error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "note deleted successfully"))
+}
+
+// ratingValid - 0 means not rated, otherwise half stars between 0.5 and 5
+func ratingValid(rating float64) bool {
+	if rating == 0 {
+		return true
+	}
+	return rating >= 0.5 && rating <= maxRating && math.Mod(rating*2, 1) == 0
+}
+
+func noteValid(note entity.Note) bool {
+	return strings.TrimSpace(note.Text) != "" && note.Page >= 0
+}
@@ -3,32 +3,81 @@ package webserver
 import (
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/webserver/swagger"
 	"net/http"
+	"strings"
 
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/metrics"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/webserver/probes"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func (s *Server) Routes() error {
-	r := gin.Default()
+// Routes - the handler serving the api, the probes and the openapi documentation
+func (s *Server) Routes() http.Handler {
+	// the spans of the api are children of the incoming W3C traceparent, if any. The request logger runs inside the
+	// span so that the access log carries the trace id
+	r := gin.New()
+	r.Use(gin.Recovery(), otelgin.Middleware(s.Config.Name, otelgin.WithFilter(traced)), requestLogger(), metrics.Middleware())
 
-	r.Group("/api/v1").
-		Use(gin.Logger()).
+	api := r.Group("/api/v1", requestTimeout(s.Config.Server.RequestTimeout), cacheBypass())
+	// the clippings upload has its own, larger, body limit
+	api.POST("/highlights/import", bodyLimit(s.Config.Server.MaxUploadBytes), s.ImportClippings)
+	api.Group("", bodyLimit(s.Config.Server.MaxBodyBytes)).
 		POST("/book", s.AddBook).
 		GET("/book/:id", s.GetBook).
 		GET("/book", s.ListBooks).
 		PUT("/book", s.UpdateBook).
 		GET("/genre", s.GroupBooksByGenre).
-		GET("/book/export", s.ExportBooks)
+		GET("/genre/:genre", s.GenreBooks).
+		GET("/book/export", s.ExportBooks).
+		GET("/book/groups", s.GroupBooks).
+		GET("/stats", s.ReadingStats).
+		POST("/goals", s.SetGoal).
+		GET("/goals", s.ListGoals).
+		GET("/goals/:year", s.GoalProgress).
+		GET("/tag", s.GroupBooksByTag).
+		POST("/shelves", s.CreateShelf).
+		GET("/shelves", s.ListShelves).
+		GET("/shelves/:name", s.GetShelf).
+		PUT("/shelves/:name", s.UpdateShelf).
+		DELETE("/shelves/:name", s.DeleteShelf).
+		PUT("/shelves/:name/books/:id", s.AddBookToShelf).
+		DELETE("/shelves/:name/books/:id", s.RemoveBookFromShelf).
+		PUT("/book/:id/review", s.SetReview).
+		GET("/book/:id/review", s.GetReview).
+		DELETE("/book/:id/review", s.DeleteReview).
+		POST("/book/:id/notes", s.AddNote).
+		GET("/book/:id/notes", s.ListNotes).
+		PUT("/book/:id/notes/:noteId", s.UpdateNote).
+		DELETE("/book/:id/notes/:noteId", s.DeleteNote).
+		POST("/book/:id/highlights", s.AddHighlight).
+		GET("/book/:id/highlights", s.ListHighlights).
+		PUT("/book/:id/highlights/:highlightId", s.UpdateHighlight).
+		DELETE("/book/:id/highlights/:highlightId", s.DeleteHighlight).
+		GET("/highlights/export", s.ExportHighlights).
+		GET("/recommendations", s.Recommend).
+		POST("/genres", s.CreateGenre).
+		GET("/genres", s.ListGenres).
+		PUT("/genres/:name", s.UpdateGenre).
+		DELETE("/genres/:name", s.DeleteGenre).
+		POST("/admin/genres/remap", s.RemapGenre)
 
 	r.Group("/api/v1/probes").
-		GET("/liveness", probes.Liveness)
+		GET("/liveness", probes.Liveness(s.Config.Name)).
+		GET("/readiness", s.Readiness.Probe).
+		GET("/startup", s.Readiness.Startup)
 
 	r.Group("/api/v1/openapi").
 		GET("/", swagger.Build).
 		GET("/:resource", swagger.Build)
 
-	http.Handle("/", r)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	return nil
+	return r
+}
+
+// traced - the api requests, the probes and the documentation are not traced
+func traced(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/v1/") && !strings.HasPrefix(r.URL.Path, "/api/v1/probes") &&
+		!strings.HasPrefix(r.URL.Path, "/api/v1/openapi")
 }
@@ -1,45 +1,123 @@
 package webserver
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/webserver/probes"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/service"
 )
 
 type Server struct {
-	Services Services
+	Config    config.Config
+	Services  Services
+	Readiness *probes.Readiness
 }
 
 type Services struct {
-	BookTracker service.BookTracker
+	BookTracker      service.BookTracker
+	GoalTracker      service.GoalTracker
+	ShelfTracker     service.ShelfTracker
+	ReviewTracker    service.ReviewTracker
+	HighlightTracker service.HighlightTracker
+	Recommender      service.Recommender
+	GenreTracker     service.GenreTracker
 }
 
-func NewServer(services Services) *Server {
+// NewServer - the checks are the dependencies pinged by the readiness and startup probes, every probes cache TTL at
+// most and within the probes timeout
+func NewServer(cfg config.Config, services Services, checks ...probes.Check) *Server {
 	return &Server{
-		Services: services,
+		Config:    cfg,
+		Services:  services,
+		Readiness: probes.NewReadiness(cfg.Name, cfg.Probes.Timeout, cfg.Probes.CacheTTL, checks...),
 	}
 }
 
-// Run -initializes the routes and starts the server
+// Run - serves until SIGINT or SIGTERM is received, then drains the server
 func (s *Server) Run() error {
-	l := logrus.StandardLogger()
-	var err error
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Serve(ctx)
+}
 
-	srv := &http.Server{Addr: ":" + os.Getenv("SERVER_PORT")}
+// Serve - initializes the routes and serves until the context is done. The readiness probe fails first, after the
+// shutdown drain delay the server stops accepting connections and waits up to the shutdown timeout for the requests
+// in flight
+func (s *Server) Serve(ctx context.Context) error {
+	l := logrus.StandardLogger()
 
-	err = s.Routes()
+	srv, err := newHTTPServer(s.Config.Server, s.Routes())
 	if err != nil {
+		l.WithError(err).Error("TLS configuration error")
+		return err
+	}
+
+	l.WithFields(logrus.Fields{"name": s.Config.Name, "port": s.Config.Server.Port, "tls": srv.TLSConfig != nil}).Info("starting server")
+	errs := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			// the certificate comes from the TLS configuration
+			errs <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		l.WithError(err).Error("ListenAndServe error")
 		return err
+	case <-ctx.Done():
 	}
 
-	fmt.Println(os.Getenv("NAME"))
-	l.Info("Starting server on port " + os.Getenv("SERVER_PORT"))
-	if err = srv.ListenAndServe(); err != nil {
-		l.Error("Httpserver: ListenAndServe() error: " + err.Error())
+	drainDelay := s.Config.Server.ShutdownDrainDelay
+	l.WithField("drain_delay", drainDelay.String()).Info("shutdown requested, failing readiness before draining")
+	s.Readiness.Drain()
+	time.Sleep(drainDelay)
+
+	timeout := s.Config.Server.ShutdownTimeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		l.WithError(err).WithField("shutdown_timeout", timeout.String()).Error("Shutdown error, requests still in flight are cut")
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
+	l.Info("server drained and stopped")
+	return nil
+}
 
-	return err
+// newHTTPServer - the server on the configured port, with read, write and idle timeouts and a header size limit so
+// that slow clients cannot hold connections forever. It serves HTTPS when a certificate is configured
+func newHTTPServer(cfg config.Server, handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.TLS.CertFile == "" {
+		return srv, nil
+	}
+	tlsConfig, err := certs.ServerConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = tlsConfig
+	return srv, nil
 }
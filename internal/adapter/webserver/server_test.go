@@ -1,18 +1,337 @@
 package webserver
 
 import (
-	"os"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/cache"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs/certstest"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
 )
 
 func TestServer(t *testing.T) {
 	t.Run("TestServer should fail(invalid port)", func(t *testing.T) {
-		os.Setenv("SERVER_PORT", "999999999")
-		server := NewServer(Services{})
+		cfg := config.Default()
+		cfg.Server.Port = 999999999
+		server := NewServer(cfg, Services{})
 		err := server.Run()
 		if err != nil && err.Error() != "listen tcp: address 999999999: invalid port" {
 			t.Errorf("TestServer should fail(invalid port) expected(%v) got (%v)", "listen tcp: address 999999999: invalid port", err)
 		}
 
 	})
+
+	t.Run("TestServer should pass(drain on shutdown)", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Port = 0
+		cfg.Server.ShutdownDrainDelay = 0
+		cfg.Server.ShutdownTimeout = time.Second
+
+		server := NewServer(cfg, Services{})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := server.Serve(ctx); err != nil {
+			t.Errorf("TestServer should pass(drain on shutdown) expected(nil) got (%v)", err)
+		}
+
+		rr := httptest.NewRecorder()
+		server.Routes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/probes/readiness", nil))
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("TestServer readiness after shutdown expected(%v) got (%v)", http.StatusServiceUnavailable, rr.Code)
+		}
+	})
+}
+
+func TestRequestLogger(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	server := NewServer(config.Default(), Services{})
+
+	tests := []struct {
+		testName  string
+		requestID string
+		generated bool
+	}{
+		{"RequestLogger: should pass(propagated request id)", "req-42", false},
+		{"RequestLogger: should pass(generated request id)", "", true},
+		{"RequestLogger: should pass(invalid request id replaced)", "req 42\n", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			hook.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/probes/liveness", nil)
+			if test.requestID != "" {
+				req.Header.Set(logging.RequestIDHeader, test.requestID)
+			}
+			rr := httptest.NewRecorder()
+			server.Routes().ServeHTTP(rr, req)
+
+			requestID := rr.Header().Get(logging.RequestIDHeader)
+			if test.generated && (len(requestID) != 32 || requestID == test.requestID) {
+				t.Errorf("%s expected a generated request id got (%s)", test.testName, requestID)
+			}
+			if !test.generated && requestID != test.requestID {
+				t.Errorf("%s expected(%s) got (%s)", test.testName, test.requestID, requestID)
+			}
+
+			if len(hook.AllEntries()) != 1 {
+				t.Fatalf("%s expected(1) access log line got (%d)", test.testName, len(hook.AllEntries()))
+			}
+			entry := hook.LastEntry()
+			want := map[string]interface{}{logging.FieldRequestID: requestID, logging.FieldMethod: http.MethodGet,
+				logging.FieldRoute: "/api/v1/probes/liveness", logging.FieldStatus: http.StatusOK}
+			for field, value := range want {
+				if entry.Data[field] != value {
+					t.Errorf("%s %s expected(%v) got (%v)", test.testName, field, value, entry.Data[field])
+				}
+			}
+			if _, ok := entry.Data[logging.FieldLatency].(float64); !ok {
+				t.Errorf("%s expected a %s field got (%v)", test.testName, logging.FieldLatency, entry.Data)
+			}
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	tests := []struct {
+		testName    string
+		timeout     time.Duration
+		hasDeadline bool
+	}{
+		{"RequestTimeout: should pass(deadline set)", time.Second, true},
+		{"RequestTimeout: should pass(disabled)", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, r := gin.CreateTestContext(rr)
+			var hasDeadline bool
+			r.GET("/", requestTimeout(test.timeout), func(c *gin.Context) {
+				_, hasDeadline = c.Request.Context().Deadline()
+			})
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			r.HandleContext(c)
+
+			if hasDeadline != test.hasDeadline {
+				t.Errorf("%s expected(%v) got (%v)", test.testName, test.hasDeadline, hasDeadline)
+			}
+		})
+	}
+}
+
+func TestCacheBypass(t *testing.T) {
+	tests := []struct {
+		testName string
+		header   string
+		bypass   bool
+	}{
+		{"CacheBypass: should pass(bypassed)", "true", true},
+		{"CacheBypass: should pass(not bypassed)", "false", false},
+		{"CacheBypass: should pass(no header)", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, r := gin.CreateTestContext(rr)
+			var bypass bool
+			r.GET("/", cacheBypass(), func(c *gin.Context) {
+				bypass = cache.Bypassed(c.Request.Context())
+			})
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				c.Request.Header.Set(cache.BypassHeader, test.header)
+			}
+			r.HandleContext(c)
+
+			if bypass != test.bypass {
+				t.Errorf("%s expected(%v) got (%v)", test.testName, test.bypass, bypass)
+			}
+		})
+	}
+}
+
+func TestTransientErrors(t *testing.T) {
+	tests := []struct {
+		testName   string
+		err        error
+		status     int
+		retryAfter string
+	}{
+		{"TransientErrors: should fail(timeout)", entity.TimeoutError{Message: "get book error:timed out"}, http.StatusGatewayTimeout, ""},
+		{"TransientErrors: should fail(circuit breaker open)", entity.UnavailableError{Message: "circuit breaker open", RetryAfter: 1500 * time.Millisecond}, http.StatusServiceUnavailable, "2"},
+		{"TransientErrors: should fail(cluster unavailable)", entity.UnavailableError{Message: "get book error:cluster temporarily unavailable"}, http.StatusServiceUnavailable, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			handleErrorTypes(c, test.err)
+
+			if rr.Code != test.status {
+				t.Errorf("%s expected(%d) got (%d)", test.testName, test.status, rr.Code)
+			}
+			if got := rr.Header().Get("Retry-After"); got != test.retryAfter {
+				t.Errorf("%s expected Retry-After(%s) got (%s)", test.testName, test.retryAfter, got)
+			}
+		})
+	}
+}
+
+func TestNewHTTPServer(t *testing.T) {
+	cfg := config.Default().Server
+	cfg.ReadTimeout = 10 * time.Second
+	cfg.MaxHeaderBytes = 2048
+
+	srv, err := newHTTPServer(cfg, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("TestNewHTTPServer expected(nil) got (%v)", err)
+	}
+	if srv.TLSConfig != nil {
+		t.Errorf("TestNewHTTPServer expected plain HTTP without a certificate")
+	}
+	if srv.Addr != ":9000" || srv.ReadTimeout != 10*time.Second || srv.MaxHeaderBytes != 2048 {
+		t.Errorf("TestNewHTTPServer expected(:9000, 10s, 2048) got (%s, %v, %d)", srv.Addr, srv.ReadTimeout, srv.MaxHeaderBytes)
+	}
+	if srv.ReadHeaderTimeout != cfg.ReadHeaderTimeout || srv.WriteTimeout != cfg.WriteTimeout || srv.IdleTimeout != cfg.IdleTimeout {
+		t.Errorf("TestNewHTTPServer expected the configured timeouts got (%v, %v, %v)", srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.MaxBodyBytes = 16
+	server := NewServer(cfg, Services{})
+	payload := `{"isbn":"TEST-ISBN-1","title":"Test Title"}`
+
+	tests := []struct {
+		testName string
+		chunked  bool
+	}{
+		{"BodyLimit: should fail(content length over the limit)", false},
+		{"BodyLimit: should fail(chunked body over the limit)", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/book", strings.NewReader(payload))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+			server.Routes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("%s expected(%d) got (%d)", test.testName, http.StatusRequestEntityTooLarge, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), "request body too large. Expected at most 16 bytes") {
+				t.Errorf("%s expected the limit in the message got (%s)", test.testName, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	tests := []struct {
+		testName string
+		strict   bool
+		err      string
+	}{
+		{"BindJSON: should fail(strict)", true, `Invalid request. Unknown field "nam", expected one of name, aliases, parent, created, updated, created_by, updated_by`},
+		{"BindJSON: should pass(not strict)", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/genres", strings.NewReader(`{"name":"Fantasy","nam":"typo"}`))
+			var genre entity.Genre
+
+			cfg := config.Default()
+			cfg.Server.StrictJSON = test.strict
+			err := NewServer(cfg, Services{}).bindJSON(c, &genre)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("%s expected(%s) got (%v)", test.testName, test.err, err)
+				}
+				return
+			}
+			if err != nil || genre.Name != "Fantasy" {
+				t.Errorf("%s expected(Fantasy) got (%s, %v)", test.testName, genre.Name, err)
+			}
+		})
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	files := certstest.Write(t, t.TempDir())
+	roots, err := certs.CertPool(files.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := tls.LoadX509KeyPair(files.ClientCertFile, files.ClientKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		testName   string
+		clientCA   string
+		clientAuth string
+		withCert   bool
+		ok         bool
+	}{
+		{"ServerTLS: should pass(TLS only)", "", "", false, true},
+		{"ServerTLS: should fail(mTLS without client certificate)", files.CAFile, "", false, false},
+		{"ServerTLS: should pass(mTLS with client certificate)", files.CAFile, config.ClientAuthRequire, true, true},
+		{"ServerTLS: should pass(verify if given without client certificate)", files.CAFile, config.ClientAuthVerifyIfGiven, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			tlsConfig, err := certs.ServerConfig(config.ServerTLS{CertFile: files.ServerCertFile, KeyFile: files.ServerKeyFile,
+				ClientCAFile: test.clientCA, ClientAuth: test.clientAuth})
+			if err != nil {
+				t.Fatalf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+			go srv.Serve(tls.NewListener(ln, tlsConfig))
+			defer srv.Close()
+
+			clientConfig := &tls.Config{RootCAs: roots}
+			if test.withCert {
+				clientConfig.Certificates = []tls.Certificate{clientCert}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+			resp, err := client.Get("https://" + ln.Addr().String())
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if test.ok && (err != nil || resp.StatusCode != http.StatusOK) {
+				t.Errorf("%s expected(200) got (%v)", test.testName, err)
+			}
+			if !test.ok && err == nil {
+				t.Errorf("%s expected a handshake error got (%d)", test.testName, resp.StatusCode)
+			}
+		})
+	}
 }
@@ -0,0 +1,121 @@
+package webserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateShelf - checks incoming request and creates the shelf
+func (s *Server) CreateShelf(c *gin.Context) {
+	var shelf entity.Shelf
+
+	if err := s.bindJSON(c, &shelf); err != nil || strings.TrimSpace(shelf.Name) == "" {
+		msg := "Invalid shelf. Expected a name"
+		logger(c).WithError(err).Error("CreateShelf invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	err := s.Services.ShelfTracker.CreateShelf(c.Request.Context(), shelf)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldPayload, shelf).Error("CreateShelf error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "shelf creation successful"))
+}
+
+// ListShelves - lists the shelves and the number of books on each shelf
+func (s *Server) ListShelves(c *gin.Context) {
+	shelves, err := s.Services.ShelfTracker.ListShelves(c.Request.Context())
+	if err != nil {
+		logger(c).WithError(err).Error("ListShelves error")
+		handleError(c, err, "failed to get shelves.Refer to logs for more details")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewShelfResponse(http.StatusOK, "shelves retrieval successful", nil, shelves, nil))
+}
+
+// GetShelf - gets the shelf and the books on it
+func (s *Server) GetShelf(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	shelf, books, err := s.Services.ShelfTracker.GetShelf(c.Request.Context(), name)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldShelf, name).Error("GetShelf error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewShelfResponse(http.StatusOK, "shelf retrieval successful", shelf, nil, books))
+}
+
+// UpdateShelf - updates or renames the shelf. A rename is applied to every book on the shelf
+func (s *Server) UpdateShelf(c *gin.Context) {
+	var shelf entity.Shelf
+	name, _ := c.Params.Get("name")
+
+	if err := s.bindJSON(c, &shelf); err != nil || strings.TrimSpace(shelf.Name) == "" {
+		msg := "Invalid shelf. Expected a name"
+		logger(c).WithError(err).Error("UpdateShelf invalid request")
+		handleBindError(c, err, msg)
+		return
+	}
+
+	err := s.Services.ShelfTracker.UpdateShelf(c.Request.Context(), name, shelf)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldShelf: name, logging.FieldPayload: shelf}).Error("UpdateShelf error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "shelf updated successfully"))
+}
+
+// DeleteShelf - deletes the shelf and removes it from every book on it
+func (s *Server) DeleteShelf(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	err := s.Services.ShelfTracker.DeleteShelf(c.Request.Context(), name)
+	if err != nil {
+		logger(c).WithError(err).WithField(logging.FieldShelf, name).Error("DeleteShelf error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "shelf deleted successfully"))
+}
+
+// AddBookToShelf - puts the book(ISBN) on the shelf
+func (s *Server) AddBookToShelf(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	bookId, _ := c.Params.Get("id")
+	err := s.Services.ShelfTracker.AddBookToShelf(c.Request.Context(), name, bookId)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldShelf: name, logging.FieldISBN: bookId}).Error("AddBookToShelf error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "book added to shelf successfully"))
+}
+
+// RemoveBookFromShelf - takes the book(ISBN) off the shelf
+func (s *Server) RemoveBookFromShelf(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+	bookId, _ := c.Params.Get("id")
+	err := s.Services.ShelfTracker.RemoveBookFromShelf(c.Request.Context(), name, bookId)
+	if err != nil {
+		logger(c).WithError(err).WithFields(logrus.Fields{logging.FieldShelf: name, logging.FieldISBN: bookId}).Error("RemoveBookFromShelf error")
+		handleErrorTypes(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewGenericResponse(http.StatusOK, "book removed from shelf successfully"))
+}
@@ -5,4 +5,25 @@ const (
 	Title   = "title"
 	Status  = "status"
 	Genre   = "genre"
+	From    = "from"
+	To      = "to"
+
+	AutoFinish = "auto_finish"
+
+	Author    = "author"
+	Series    = "series"
+	Publisher = "publisher"
+	Language  = "language"
+	Format    = "format"
+	Year      = "year"
+	Tag       = "tag"
+
+	Limit  = "limit"
+	Length = "length"
+	Mood   = "mood"
+
+	CountsOnly = "counts_only"
+	Sample     = "sample"
+	Offset     = "offset"
+	By         = "by"
 )
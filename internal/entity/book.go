@@ -1,6 +1,9 @@
 package entity
 
 import (
+	"encoding/json"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -9,20 +12,119 @@ const (
 	active      = "true"
 )
 
+const (
+	RoleAuthor      = "author"
+	RoleCoAuthor    = "co-author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+	RoleNarrator    = "narrator"
+)
+
+const (
+	FormatHardcover = "HARDCOVER"
+	FormatPaperback = "PAPERBACK"
+	FormatEbook     = "EBOOK"
+	FormatAudiobook = "AUDIOBOOK"
+)
+
+// Contributor is a person credited on the book. Role defaults to author
+type Contributor struct {
+	Name string `json:"name" yaml:"name"`
+	Role string `json:"role,omitempty" yaml:"role,omitempty"`
+}
+
+// Series - Position is a float so that novellas(1.5) can sit between two books
+type Series struct {
+	Name     string  `json:"name" yaml:"name"`
+	Position float64 `json:"position,omitempty" yaml:"position,omitempty"`
+}
+
 type Book struct {
-	ISBN      string `json:"isbn" binding:"required"`
-	Title     string `json:"title" binding:"required"`
-	Author    string `json:"author" binding:"required"`
-	Genre     string `json:"genre" binding:"required"`
-	Status    string `json:"status,omitempty" yaml:"status,omitempty"`
-	Bookmark  int    `json:"bookmark,omitempty" yaml:"bookmark,omitempty"`
-	Created   int64  `json:"created,omitempty" yaml:"created,omitempty"`
-	Updated   int64  `json:"updated,omitempty" yaml:"updated,omitempty"`
-	CreatedBy string `json:"created_by,omitempty" yaml:"created_by,omitempty"`
-	UpdatedBy string `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
-	Started   int64  `json:"started,omitempty" yaml:"started,omitempty"`
-	Finished  int64  `json:"finished,omitempty" yaml:"finished,omitempty"`
-	Active    string `json:"active,omitempty" yaml:"active,omitempty"`
+	ISBN            string  `json:"isbn" binding:"required"`
+	Title           string  `json:"title" binding:"required"`
+	Author          string  `json:"author" binding:"required"`
+	Genre           string  `json:"genre" binding:"required"`
+	Status          string  `json:"status,omitempty" yaml:"status,omitempty"`
+	Bookmark        int     `json:"bookmark,omitempty" yaml:"bookmark,omitempty"`
+	PageCount       int     `json:"page_count,omitempty" yaml:"page_count,omitempty"`
+	DurationMinutes int     `json:"duration_minutes,omitempty" yaml:"duration_minutes,omitempty"`
+	PercentComplete float64 `json:"percent_complete,omitempty" yaml:"percent_complete,omitempty"`
+	Created         int64   `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated         int64   `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy       string  `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy       string  `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+	Started         int64   `json:"started,omitempty" yaml:"started,omitempty"`
+	Finished        int64   `json:"finished,omitempty" yaml:"finished,omitempty"`
+	Active          string  `json:"active,omitempty" yaml:"active,omitempty"`
+
+	Authors         []Contributor `json:"authors,omitempty" yaml:"authors,omitempty"`
+	Series          *Series       `json:"series,omitempty" yaml:"series,omitempty"`
+	Publisher       string        `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	PublicationYear int           `json:"publication_year,omitempty" yaml:"publication_year,omitempty"`
+	Language        string        `json:"language,omitempty" yaml:"language,omitempty"`
+	Format          string        `json:"format,omitempty" yaml:"format,omitempty"`
+	Description     string        `json:"description,omitempty" yaml:"description,omitempty"`
+
+	Tags   []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Rating float64  `json:"rating,omitempty" yaml:"rating,omitempty"`
+
+	SchemaVersion int `json:"schema_version,omitempty" yaml:"schema_version,omitempty"`
+}
+
+// UnmarshalJSON keeps documents and requests with only the single author string working,
+// and fills the author string(still required) from the author list for new clients
+func (b *Book) UnmarshalJSON(data []byte) error {
+	type book Book
+	var decoded book
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*b = Book(decoded)
+	b.NormalizeAuthors()
+	return nil
+}
+
+// NormalizeAuthors - defaults the contributor roles to author, and fills the author string from the contributors or
+// the contributors from the author string, whichever is missing
+func (b *Book) NormalizeAuthors() {
+	for i := range b.Authors {
+		if b.Authors[i].Role == "" {
+			b.Authors[i].Role = RoleAuthor
+		}
+	}
+	if len(b.Authors) == 0 && b.Author != "" {
+		b.Authors = []Contributor{{Name: b.Author, Role: RoleAuthor}}
+	}
+	if b.Author == "" {
+		b.Author = b.PrimaryAuthor()
+	}
+}
+
+// PrimaryAuthor - the first contributor credited as author(or co-author), else the first contributor
+func (b *Book) PrimaryAuthor() string {
+	for _, contributor := range b.Authors {
+		if contributor.Role == RoleAuthor || contributor.Role == RoleCoAuthor {
+			return contributor.Name
+		}
+	}
+	if len(b.Authors) > 0 {
+		return b.Authors[0].Name
+	}
+	return ""
+}
+
+// HasContributor - case-insensitive match on the author string and the contributor names
+func (b *Book) HasContributor(name string) bool {
+	if strings.EqualFold(b.Author, name) {
+		return true
+	}
+	for _, contributor := range b.Authors {
+		if strings.EqualFold(contributor.Name, name) {
+			return true
+		}
+	}
+	return false
 }
 
 func (b *Book) SetTrackingDetails() {
@@ -32,8 +134,84 @@ func (b *Book) SetTrackingDetails() {
 	b.UpdatedBy = defaultUser
 }
 
+// ProgressTotal - the bookmark is a page for printed books and a minute for audiobooks(no page count)
+func (b *Book) ProgressTotal() int {
+	if b.PageCount > 0 {
+		return b.PageCount
+	}
+	return b.DurationMinutes
+}
+
+// SetPercentComplete - derives the percent complete from the bookmark. It is never taken from the client
+func (b *Book) SetPercentComplete() {
+	total := b.ProgressTotal()
+	if total <= 0 {
+		b.PercentComplete = 0
+		return
+	}
+	b.PercentComplete = math.Round(float64(b.Bookmark)*1000/float64(total)) / 10
+}
+
+// OnLastPage - true when the bookmark has reached the end of the book
+func (b *Book) OnLastPage() bool {
+	total := b.ProgressTotal()
+	return total > 0 && b.Bookmark >= total
+}
+
+// HasTag - tags are compared case-insensitively
+func (b *Book) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag - adds the tag unless the book already carries it. Returns true when the tags changed
+func (b *Book) AddTag(tag string) bool {
+	if b.HasTag(tag) {
+		return false
+	}
+	b.Tags = append(b.Tags, tag)
+	return true
+}
+
+// RemoveTag - returns true when the tags changed
+func (b *Book) RemoveTag(tag string) bool {
+	var tags []string
+	for _, t := range b.Tags {
+		if !strings.EqualFold(t, tag) {
+			tags = append(tags, t)
+		}
+	}
+	changed := len(tags) != len(b.Tags)
+	b.Tags = tags
+	return changed
+}
+
+// NormalizeTags - trims the tags and drops blanks and case-insensitive duplicates
+func (b *Book) NormalizeTags() {
+	tags := b.Tags
+	b.Tags = nil
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			b.AddTag(tag)
+		}
+	}
+}
+
+// NormalizeCase - upper cases the status and the format, the way they are stored
+func (b *Book) NormalizeCase() {
+	b.Status = strings.ToUpper(b.Status)
+	b.Format = strings.ToUpper(b.Format)
+}
+
+// BooksByGenre - Count is the number of books of the genre itself, Total adds the books of its sub-genres
 type BooksByGenre struct {
-	Genre string `json:"genre"`
-	Count int    `json:"count"`
-	Books []Book `json:"books"`
+	Genre  string `json:"genre"`
+	Parent string `json:"parent,omitempty"`
+	Count  int    `json:"count"`
+	Total  int    `json:"total"`
+	Books  []Book `json:"books,omitempty"`
 }
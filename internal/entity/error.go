@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type NotFoundError struct {
 	Message string
 }
@@ -7,3 +9,55 @@ type NotFoundError struct {
 func (e NotFoundError) Error() string {
 	return e.Message
 }
+
+type ConflictError struct {
+	Message string
+}
+
+func (e ConflictError) Error() string {
+	return e.Message
+}
+
+// TimeoutError - the operation did not complete before the deadline of the request
+type TimeoutError struct {
+	Message string
+	Err     error
+}
+
+func (e TimeoutError) Error() string {
+	return e.Message
+}
+
+func (e TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// CanceledError - the request was canceled before the operation completed, usually because the client went away
+type CanceledError struct {
+	Message string
+	Err     error
+}
+
+func (e CanceledError) Error() string {
+	return e.Message
+}
+
+func (e CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// UnavailableError - the repository cannot serve the operation for now(a transient failure, or the circuit breaker is
+// open). RetryAfter is when trying again makes sense, 0 when unknown
+type UnavailableError struct {
+	Message    string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e UnavailableError) Error() string {
+	return e.Message
+}
+
+func (e UnavailableError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,53 @@
+package entity
+
+import "strings"
+
+// BookFilter narrows ListBooks. Empty fields match every book, string matches are case-insensitive
+type BookFilter struct {
+	Author          string
+	Series          string
+	Publisher       string
+	Language        string
+	Format          string
+	PublicationYear int
+	Tag             string
+}
+
+func (f BookFilter) Matches(book Book) bool {
+	if f.Author != "" && !book.HasContributor(f.Author) {
+		return false
+	}
+	if f.Series != "" && (book.Series == nil || !strings.EqualFold(book.Series.Name, f.Series)) {
+		return false
+	}
+	if f.Publisher != "" && !strings.EqualFold(book.Publisher, f.Publisher) {
+		return false
+	}
+	if f.Language != "" && !strings.EqualFold(book.Language, f.Language) {
+		return false
+	}
+	if f.Format != "" && !strings.EqualFold(book.Format, f.Format) {
+		return false
+	}
+	if f.PublicationYear != 0 && book.PublicationYear != f.PublicationYear {
+		return false
+	}
+	if f.Tag != "" && !book.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+// Filter - returns the books matching the filter, in their original order
+func (f BookFilter) Filter(books []Book) []Book {
+	if f == (BookFilter{}) {
+		return books
+	}
+	var matched []Book
+	for _, book := range books {
+		if f.Matches(book) {
+			matched = append(matched, book)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Genre is a canonical genre of the taxonomy. Books whose genre is the name or one of the aliases(regardless of
+// case) belong to it, and they are counted again in every ancestor genre
+type Genre struct {
+	Name      string   `json:"name" binding:"required"`
+	Aliases   []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Parent    string   `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Created   int64    `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated   int64    `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy string   `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy string   `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+// GenreRemap - moves the books of a genre(any of its spellings) to another genre
+type GenreRemap struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+func (g *Genre) SetTrackingDetails() {
+	g.Created = time.Now().Unix()
+	g.Updated = time.Now().Unix()
+	g.CreatedBy = defaultUser
+	g.UpdatedBy = defaultUser
+}
+
+// Key - genre names are unique regardless of case
+func (g *Genre) Key() string {
+	return GenreKey(g.Name)
+}
+
+// Spellings - the keys of the name and the aliases
+func (g *Genre) Spellings() []string {
+	spellings := []string{g.Key()}
+	for _, alias := range g.Aliases {
+		if key := GenreKey(alias); key != "" {
+			spellings = append(spellings, key)
+		}
+	}
+	return spellings
+}
+
+func GenreKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// GenreTaxonomy resolves genre spellings to the canonical genres and their parents
+type GenreTaxonomy struct {
+	canonical map[string]string
+	parents   map[string]string
+	spellings map[string][]string
+}
+
+func NewGenreTaxonomy(genres []Genre) *GenreTaxonomy {
+	taxonomy := &GenreTaxonomy{canonical: map[string]string{}, parents: map[string]string{}, spellings: map[string][]string{}}
+	for _, genre := range genres {
+		if genre.Key() == "" {
+			continue
+		}
+		for _, spelling := range genre.Spellings() {
+			taxonomy.canonical[spelling] = strings.TrimSpace(genre.Name)
+		}
+		taxonomy.parents[genre.Key()] = genre.Parent
+		taxonomy.spellings[genre.Key()] = genre.Spellings()
+	}
+	return taxonomy
+}
+
+// Spellings - the keys of the canonical genre and its aliases. An unknown genre only has its own key
+func (t *GenreTaxonomy) Spellings(genre string) []string {
+	key := GenreKey(t.Canonical(genre))
+	if spellings, ok := t.spellings[key]; ok {
+		return spellings
+	}
+	return []string{key}
+}
+
+// Canonical - the canonical name of the genre. Genres missing from the taxonomy are returned trimmed
+func (t *GenreTaxonomy) Canonical(genre string) string {
+	if canonical, ok := t.canonical[GenreKey(genre)]; ok {
+		return canonical
+	}
+	return strings.TrimSpace(genre)
+}
+
+// Parent - the canonical name of the parent genre, empty for top level and unknown genres
+func (t *GenreTaxonomy) Parent(genre string) string {
+	parent := t.parents[GenreKey(t.Canonical(genre))]
+	if parent == "" {
+		return ""
+	}
+	return t.Canonical(parent)
+}
+
+// Ancestors - the parent, grandparent... of the genre. A cycle stops the walk
+func (t *GenreTaxonomy) Ancestors(genre string) []string {
+	var ancestors []string
+	seen := map[string]bool{GenreKey(t.Canonical(genre)): true}
+	for parent := t.Parent(genre); parent != "" && !seen[GenreKey(parent)]; parent = t.Parent(parent) {
+		seen[GenreKey(parent)] = true
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+const (
+	GoalOnTrack   = "ON TRACK"
+	GoalBehind    = "BEHIND"
+	GoalCompleted = "COMPLETED"
+	GoalMissed    = "MISSED"
+
+	// MinGoalYear - the first year a goal can be set for, the last one is next year
+	MinGoalYear = 1900
+)
+
+// Goal is a yearly reading challenge. At least one of Books or Pages is expected to be set
+type Goal struct {
+	Year      int    `json:"year" binding:"required"`
+	Books     int    `json:"books,omitempty" yaml:"books,omitempty"`
+	Pages     int    `json:"pages,omitempty" yaml:"pages,omitempty"`
+	Created   int64  `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated   int64  `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy string `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+// MaxGoalYear - goals can be set up to next year
+func MaxGoalYear() int {
+	return time.Now().Year() + 1
+}
+
+// YearValid - the year is between MinGoalYear and MaxGoalYear
+func (g *Goal) YearValid() bool {
+	return g.Year >= MinGoalYear && g.Year <= MaxGoalYear()
+}
+
+func (g *Goal) SetTrackingDetails() {
+	g.Created = time.Now().Unix()
+	g.Updated = time.Now().Unix()
+	g.CreatedBy = defaultUser
+	g.UpdatedBy = defaultUser
+}
+
+// TargetProgress is the progress towards a single goal target(books or pages)
+type TargetProgress struct {
+	Target    int     `json:"target"`
+	Actual    int     `json:"actual"`
+	Percent   float64 `json:"percent"`
+	Expected  int     `json:"expected"`
+	Projected int     `json:"projected"`
+	Status    string  `json:"status"`
+}
+
+type GoalProgress struct {
+	Goal          Goal            `json:"goal"`
+	Status        string          `json:"status"`
+	DaysElapsed   int             `json:"days_elapsed"`
+	DaysRemaining int             `json:"days_remaining"`
+	Books         *TargetProgress `json:"books,omitempty"`
+	Pages         *TargetProgress `json:"pages,omitempty"`
+}
@@ -0,0 +1,49 @@
+package entity
+
+// GroupOptions - CountsOnly leaves the books out of the groups, Sample keeps at most that many books per group(0 keeps all)
+type GroupOptions struct {
+	CountsOnly bool
+	Sample     int
+}
+
+// Page - window of a listing ordered by title
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// Window - the bounds of the page within a listing of total items
+func (p Page) Window(total int) (int, int) {
+	from := p.Offset
+	if from > total {
+		from = total
+	}
+	to := from + p.Limit
+	if p.Limit <= 0 || to > total {
+		to = total
+	}
+	return from, to
+}
+
+// Book groupings offered by the group listing
+const (
+	GroupByGenre        = "genre"
+	GroupByAuthor       = "author"
+	GroupByStatus       = "status"
+	GroupByYearFinished = "year_finished"
+	GroupByLanguage     = "language"
+	GroupByTag          = "tag"
+)
+
+// Groupings - the values accepted for the grouping of books
+var Groupings = []string{GroupByGenre, GroupByAuthor, GroupByStatus, GroupByYearFinished, GroupByLanguage, GroupByTag}
+
+// BookGroup - Count is the number of books of the group itself, Total adds the books of its sub-groups(only genres
+// have them)
+type BookGroup struct {
+	Group  string `json:"group"`
+	Parent string `json:"parent,omitempty"`
+	Count  int    `json:"count"`
+	Total  int    `json:"total"`
+	Books  []Book `json:"books,omitempty"`
+}
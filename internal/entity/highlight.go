@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+const (
+	ColorYellow = "YELLOW"
+	ColorBlue   = "BLUE"
+	ColorPink   = "PINK"
+	ColorOrange = "ORANGE"
+
+	SourceManual = "MANUAL"
+	SourceKindle = "KINDLE"
+)
+
+// Highlight is a quote of the book, located by page and/or the e-reader location(e.g. 170-172)
+type Highlight struct {
+	ID        string `json:"id" yaml:"id"`
+	ISBN      string `json:"isbn" yaml:"isbn"`
+	Text      string `json:"text" yaml:"text" binding:"required"`
+	Page      int    `json:"page,omitempty" yaml:"page,omitempty"`
+	Location  string `json:"location,omitempty" yaml:"location,omitempty"`
+	Note      string `json:"note,omitempty" yaml:"note,omitempty"`
+	Color     string `json:"color,omitempty" yaml:"color,omitempty"`
+	Source    string `json:"source,omitempty" yaml:"source,omitempty"`
+	Created   int64  `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated   int64  `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy string `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+func (h *Highlight) SetTrackingDetails() {
+	h.Created = time.Now().Unix()
+	h.Updated = time.Now().Unix()
+	h.CreatedBy = defaultUser
+	h.UpdatedBy = defaultUser
+}
+
+// Clipping is an entry of a Kindle "My Clippings.txt" file
+type Clipping struct {
+	Title    string
+	Author   string
+	Kind     string
+	Page     int
+	Location string
+	Added    string
+	Text     string
+}
+
+// UnmatchedBook - clippings of a book that is not tracked
+type UnmatchedBook struct {
+	Title  string `json:"title"`
+	Author string `json:"author,omitempty"`
+	Count  int    `json:"count"`
+}
+
+// ImportResult - outcome of a clippings import. Duplicates were imported before and are left untouched
+type ImportResult struct {
+	Imported   int             `json:"imported"`
+	Duplicates int             `json:"duplicates"`
+	Skipped    int             `json:"skipped"`
+	Unmatched  []UnmatchedBook `json:"unmatched,omitempty"`
+}
@@ -0,0 +1,43 @@
+package entity
+
+const (
+	LengthShort  = "short"
+	LengthMedium = "medium"
+	LengthLong   = "long"
+
+	// page counts bounding the medium length. Audiobooks are measured in minutes, a page is read in about 1.5
+	shortPages     = 250
+	longPages      = 450
+	minutesPerPage = 1.5
+)
+
+// RecommendationFilter - optional length(short, medium, long) and mood(a known mood or any genre/tag) of the picks
+type RecommendationFilter struct {
+	Length string
+	Mood   string
+	Limit  int
+}
+
+// Recommendation is an UNREAD book with its score and the reasons adding up to it
+type Recommendation struct {
+	Book    Book     `json:"book"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Length - the length bucket of the book, empty when neither the page count nor the duration is known
+func (b *Book) Length() string {
+	pages := float64(b.PageCount)
+	if pages <= 0 {
+		pages = float64(b.DurationMinutes) / minutesPerPage
+	}
+	switch {
+	case pages <= 0:
+		return ""
+	case pages < shortPages:
+		return LengthShort
+	case pages > longPages:
+		return LengthLong
+	}
+	return LengthMedium
+}
@@ -20,6 +20,79 @@ type GroupByGenreResponse struct {
 	Genres []BooksByGenre `json:"genres"`
 }
 
+type BookGroupResponse struct {
+	GenericResponse
+	By     string      `json:"by"`
+	Groups []BookGroup `json:"groups"`
+}
+
+type BookPageResponse struct {
+	GenericResponse
+	Total  int    `json:"total"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Count  int    `json:"count"`
+	Books  []Book `json:"books"`
+}
+
+type GroupByTagResponse struct {
+	GenericResponse
+	Tags []BooksByTag `json:"tags"`
+}
+
+type ShelfResponse struct {
+	GenericResponse
+	Shelf   *Shelf  `json:"shelf,omitempty"`
+	Shelves []Shelf `json:"shelves,omitempty"`
+	Books   []Book  `json:"books,omitempty"`
+}
+
+type ReviewResponse struct {
+	GenericResponse
+	Review *Review `json:"review,omitempty"`
+}
+
+type NoteResponse struct {
+	GenericResponse
+	Note  *Note  `json:"note,omitempty"`
+	Count int    `json:"count,omitempty"`
+	Notes []Note `json:"notes,omitempty"`
+}
+
+type HighlightResponse struct {
+	GenericResponse
+	Highlight  *Highlight  `json:"highlight,omitempty"`
+	Count      int         `json:"count,omitempty"`
+	Highlights []Highlight `json:"highlights,omitempty"`
+}
+
+type ImportResponse struct {
+	GenericResponse
+	Result *ImportResult `json:"result,omitempty"`
+}
+
+type RecommendationResponse struct {
+	GenericResponse
+	Count           int              `json:"count"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+type GenreResponse struct {
+	GenericResponse
+	Genres []Genre `json:"genres"`
+}
+
+type GoalResponse struct {
+	GenericResponse
+	Goals    []Goal        `json:"goals,omitempty"`
+	Progress *GoalProgress `json:"progress,omitempty"`
+}
+
+type StatsResponse struct {
+	GenericResponse
+	Stats *ReadingStats `json:"stats,omitempty"`
+}
+
 func NewGenericResponse(code int, msg string) GenericResponse {
 	return GenericResponse{Code: code, Status: http.StatusText(code), Message: msg}
 }
@@ -46,3 +119,157 @@ func NewGroupByGenreResponse(code int, msg string, genres []BooksByGenre) GroupB
 		Genres: genres,
 	}
 }
+
+func NewBookGroupResponse(code int, msg string, by string, groups []BookGroup) BookGroupResponse {
+	return BookGroupResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		By:     by,
+		Groups: groups,
+	}
+}
+
+func NewStatsResponse(code int, msg string, stats *ReadingStats) StatsResponse {
+	return StatsResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Stats: stats,
+	}
+}
+
+func NewGoalResponse(code int, msg string, goals []Goal, progress *GoalProgress) GoalResponse {
+	return GoalResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Goals:    goals,
+		Progress: progress,
+	}
+}
+
+func NewGroupByTagResponse(code int, msg string, tags []BooksByTag) GroupByTagResponse {
+	return GroupByTagResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Tags: tags,
+	}
+}
+
+func NewShelfResponse(code int, msg string, shelf *Shelf, shelves []Shelf, books []Book) ShelfResponse {
+	return ShelfResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Shelf:   shelf,
+		Shelves: shelves,
+		Books:   books,
+	}
+}
+
+func NewReviewResponse(code int, msg string, review *Review) ReviewResponse {
+	return ReviewResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Review: review,
+	}
+}
+
+func NewNoteResponse(code int, msg string, note *Note, notes []Note) NoteResponse {
+	return NoteResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Note:  note,
+		Notes: notes,
+		Count: len(notes),
+	}
+}
+
+func NewHighlightResponse(code int, msg string, highlight *Highlight, highlights []Highlight) HighlightResponse {
+	return HighlightResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Highlight:  highlight,
+		Highlights: highlights,
+		Count:      len(highlights),
+	}
+}
+
+func NewImportResponse(code int, msg string, result *ImportResult) ImportResponse {
+	return ImportResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Result: result,
+	}
+}
+
+func NewRecommendationResponse(code int, msg string, recommendations []Recommendation) RecommendationResponse {
+	if recommendations == nil {
+		recommendations = []Recommendation{}
+	}
+	return RecommendationResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Count:           len(recommendations),
+		Recommendations: recommendations,
+	}
+}
+
+func NewGenreResponse(code int, msg string, genres []Genre) GenreResponse {
+	if genres == nil {
+		genres = []Genre{}
+	}
+	return GenreResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Genres: genres,
+	}
+}
+
+func NewBookPageResponse(code int, msg string, page Page, total int, books []Book) BookPageResponse {
+	if books == nil {
+		books = []Book{}
+	}
+	return BookPageResponse{
+		GenericResponse: GenericResponse{
+			Code:    code,
+			Status:  http.StatusText(code),
+			Message: msg,
+		},
+		Total:  total,
+		Offset: page.Offset,
+		Limit:  page.Limit,
+		Count:  len(books),
+		Books:  books,
+	}
+}
@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// Review is private to the reader: it is only returned by the review sub-resource of the book and by the export.
+// The rating is also kept on the book, where it is public
+type Review struct {
+	ISBN      string  `json:"isbn" yaml:"-"`
+	Rating    float64 `json:"rating,omitempty" yaml:"rating,omitempty"`
+	Text      string  `json:"review,omitempty" yaml:"review,omitempty"`
+	Spoiler   bool    `json:"spoiler,omitempty" yaml:"spoiler,omitempty"`
+	Created   int64   `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated   int64   `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy string  `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy string  `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+// Note is a private, timestamped note tied to a page of the book
+type Note struct {
+	ID        string `json:"id" yaml:"id"`
+	ISBN      string `json:"isbn" yaml:"-"`
+	Page      int    `json:"page,omitempty" yaml:"page,omitempty"`
+	Text      string `json:"text" yaml:"text" binding:"required"`
+	Created   int64  `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated   int64  `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy string `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+func (r *Review) SetTrackingDetails() {
+	r.Created = time.Now().Unix()
+	r.Updated = time.Now().Unix()
+	r.CreatedBy = defaultUser
+	r.UpdatedBy = defaultUser
+}
+
+func (n *Note) SetTrackingDetails() {
+	n.Created = time.Now().Unix()
+	n.Updated = time.Now().Unix()
+	n.CreatedBy = defaultUser
+	n.UpdatedBy = defaultUser
+}
+
+// BookExport is a book with its private review and notes, used by the export only
+type BookExport struct {
+	Book   `yaml:",inline"`
+	Review *Review `json:"review,omitempty" yaml:"review,omitempty"`
+	Notes  []Note  `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
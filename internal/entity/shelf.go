@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Shelf is a named, managed tag. A book is on the shelf when it carries the shelf name as a tag
+type Shelf struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Count       int    `json:"count,omitempty" yaml:"count,omitempty"`
+	Created     int64  `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated     int64  `json:"updated,omitempty" yaml:"updated,omitempty"`
+	CreatedBy   string `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedBy   string `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+func (s *Shelf) SetTrackingDetails() {
+	s.Created = time.Now().Unix()
+	s.Updated = time.Now().Unix()
+	s.CreatedBy = defaultUser
+	s.UpdatedBy = defaultUser
+}
+
+// Key - shelf names are unique regardless of case
+func (s *Shelf) Key() string {
+	return ShelfKey(s.Name)
+}
+
+func ShelfKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+type BooksByTag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+	Books []Book `json:"books"`
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Book fields the books can be ordered by
+const (
+	SortTitle    = "title"
+	SortAuthor   = "author"
+	SortStatus   = "status"
+	SortGenre    = "genre"
+	SortCreated  = "created"
+	SortUpdated  = "updated"
+	SortStarted  = "started"
+	SortFinished = "finished"
+	SortRating   = "rating"
+)
+
+// SortFields - the fields accepted by ParseBookSort
+var SortFields = []string{SortTitle, SortAuthor, SortStatus, SortGenre, SortCreated, SortUpdated, SortStarted, SortFinished, SortRating}
+
+// collatedFields - the string fields, ordered with the Unicode collation
+var collatedFields = []string{SortTitle, SortAuthor, SortStatus, SortGenre}
+
+// SortKey - a field to order by, Descending when it was prefixed with "-"
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// BookSort - the keys in order of precedence. Books equal on every key are ordered by ISBN
+type BookSort []SortKey
+
+// ParseBookSort - parses a comma separated list of fields(e.g. "-finished,title"), an empty value is no ordering
+func ParseBookSort(value string) (BookSort, error) {
+	var sort BookSort
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		key := SortKey{Field: strings.TrimPrefix(field, "-"), Descending: strings.HasPrefix(field, "-")}
+		if !sortField(key.Field) {
+			return nil, fmt.Errorf("Invalid sort key %s. Expected a comma separated list of %s, prefixed with - for descending order",
+				key.Field, strings.Join(SortFields, ", "))
+		}
+		sort = append(sort, key)
+	}
+	return sort, nil
+}
+
+func sortField(field string) bool {
+	for _, f := range SortFields {
+		if field == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Collated - one of the keys is a string field, which needs the Unicode collation of the service to be ordered
+func (s BookSort) Collated() bool {
+	for _, key := range s {
+		for _, field := range collatedFields {
+			if key.Field == field {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package entity
+
+// StatsFilter restricts the reading statistics to books finished within [From, To] (epoch seconds).
+// A zero value on either side leaves that side of the range open.
+type StatsFilter struct {
+	From int64
+	To   int64
+}
+
+// Includes reports whether the finished timestamp falls within the filter range
+func (f StatsFilter) Includes(finished int64) bool {
+	if finished <= 0 {
+		return false
+	}
+	if f.From > 0 && finished < f.From {
+		return false
+	}
+	if f.To > 0 && finished > f.To {
+		return false
+	}
+	return true
+}
+
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+type NamedCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type ReadDuration struct {
+	ISBN  string  `json:"isbn"`
+	Title string  `json:"title"`
+	Days  float64 `json:"days"`
+}
+
+type ReadingStats struct {
+	BooksFinished       int           `json:"books_finished"`
+	FinishedPerMonth    []PeriodCount `json:"finished_per_month"`
+	FinishedPerYear     []PeriodCount `json:"finished_per_year"`
+	AverageDaysToFinish float64       `json:"average_days_to_finish"`
+	PagesRead           int           `json:"pages_read"`
+	Genres              []NamedCount  `json:"genres"`
+	Authors             []NamedCount  `json:"authors"`
+	LongestRead         *ReadDuration `json:"longest_read,omitempty"`
+	ShortestRead        *ReadDuration `json:"shortest_read,omitempty"`
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	StatusUnread     = "UNREAD"
+	StatusInProgress = "IN PROGRESS"
+	StatusFinished   = "FINISHED"
+)
+
+var (
+	Statuses         = []string{StatusUnread, StatusInProgress, StatusFinished}
+	Formats          = []string{FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook}
+	ContributorRoles = []string{RoleAuthor, RoleCoAuthor, RoleEditor, RoleTranslator, RoleIllustrator, RoleNarrator}
+)
+
+// Invalid - validates the optional fields that binding cannot express. Returns the first violation, empty when valid
+func (b *Book) Invalid() string {
+	if msg := b.bibliographicError(); msg != "" {
+		return msg
+	}
+	return b.progressError()
+}
+
+// bibliographicError - validates the format, contributor roles, series position and publication year
+func (b *Book) bibliographicError() string {
+	if !FormatValid(b.Format) {
+		return fmt.Sprintf("Invalid format %s. Expected one of %s", b.Format, strings.Join(Formats, ", "))
+	}
+	for _, contributor := range b.Authors {
+		if contributor.Name == "" || !roleValid(contributor.Role) {
+			return fmt.Sprintf("Invalid author %+v. Expected a name and a role among %s", contributor, strings.Join(ContributorRoles, ", "))
+		}
+	}
+	if b.Series != nil && (b.Series.Name == "" || b.Series.Position < 0) {
+		return "Invalid series. Expected a name and a positive position"
+	}
+	if b.PublicationYear < 0 {
+		return "Invalid publication_year. Expected a positive value"
+	}
+	return ""
+}
+
+// progressError - validates the bookmark against the page count(or the duration for audiobooks)
+func (b *Book) progressError() string {
+	if b.PageCount < 0 || b.DurationMinutes < 0 {
+		return "Invalid page_count or duration_minutes. Expected a positive value"
+	}
+	if b.Bookmark < 0 {
+		return "Invalid bookmark. Expected a positive value"
+	}
+	if total := b.ProgressTotal(); total > 0 && b.Bookmark > total {
+		return fmt.Sprintf("Invalid bookmark %d. Expected a value between 0 and %d", b.Bookmark, total)
+	}
+	return ""
+}
+
+// StatusValid - one of Statuses(case-insensitive), or empty
+func StatusValid(status string) bool {
+	return status == "" || contains(Statuses, strings.ToUpper(status))
+}
+
+// FormatValid - one of Formats(case-insensitive), or empty
+func FormatValid(format string) bool {
+	return format == "" || contains(Formats, strings.ToUpper(format))
+}
+
+func roleValid(role string) bool {
+	return contains(ContributorRoles, strings.ToLower(role))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
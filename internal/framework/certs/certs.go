@@ -0,0 +1,145 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+)
+
+// defaultCheckInterval - how often the files are checked for a change, at most
+const defaultCheckInterval = 10 * time.Second
+
+// Reloader - a certificate and its key, loaded again when either file changes so that a renewed certificate(e.g. by
+// cert-manager) is served without a restart. The files are checked on handshake, at most once per check interval.
+// A renewal that cannot be loaded(e.g. the key is not written yet) keeps the previous certificate
+type Reloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified time.Time
+	checked  time.Time
+}
+
+// NewReloader - fails when the certificate and key cannot be loaded
+func NewReloader(certFile string, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, interval: defaultCheckInterval}
+	modified, err := r.modTime()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.load(modified); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate - the server certificate, for tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate - the client certificate, for tls.Config
+func (r *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// Certificate - the current certificate, loaded again first when the files changed
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < r.interval {
+		return r.cert
+	}
+	r.checked = time.Now()
+	modified, err := r.modTime()
+	if err != nil {
+		logrus.WithError(err).WithField("cert_file", r.certFile).Error("certificate check error, keeping the loaded certificate")
+		return r.cert
+	}
+	if !modified.Equal(r.modified) {
+		if err = r.load(modified); err != nil {
+			logrus.WithError(err).WithField("cert_file", r.certFile).Error("certificate reload error, keeping the loaded certificate")
+		} else {
+			logrus.WithField("cert_file", r.certFile).Info("certificate reloaded")
+		}
+	}
+	return r.cert
+}
+
+func (r *Reloader) load(modified time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("certificate error:%s", err.Error())
+	}
+	r.cert = &cert
+	r.modified = modified
+	return nil
+}
+
+// modTime - the latest modification time of the certificate and key files
+func (r *Reloader) modTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("certificate error:%s", err.Error())
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// CertPool - the certificates of the PEM bundle, e.g. a private CA
+func CertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("CA bundle error:%s", err.Error())
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("CA bundle error:no certificate found in " + file)
+	}
+	return pool, nil
+}
+
+// ServerConfig - TLS 1.2 or later with the certificate of the reloader, so that a renewed certificate is served
+// without a restart. With a client CA the client certificates are verified(mTLS), required unless the client auth is
+// verify-if-given(e.g. so that the kubelet probes, which have no certificate, keep working). Shared by the HTTP and gRPC servers
+func ServerConfig(cfg config.ServerTLS) (*tls.Config, error) {
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: reloader.GetCertificate}
+	if cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	tlsConfig.ClientCAs, err = CertPool(cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.ClientAuth {
+	case config.ClientAuthNone:
+		tlsConfig.ClientAuth = tls.NoClientCert
+	case config.ClientAuthVerifyIfGiven:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,78 @@
+package certs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs/certstest"
+)
+
+func TestReloader(t *testing.T) {
+	files := certstest.Write(t, t.TempDir())
+	reloader, err := NewReloader(files.ServerCertFile, files.ServerKeyFile)
+	if err != nil {
+		t.Fatalf("TestReloader expected(nil) got (%v)", err)
+	}
+	reloader.interval = 0
+	first := reloader.Certificate()
+
+	t.Run("Reloader: should pass(unchanged files)", func(t *testing.T) {
+		if got := reloader.Certificate(); got != first {
+			t.Errorf("Reloader: should pass(unchanged files) expected the loaded certificate")
+		}
+	})
+
+	t.Run("Reloader: should pass(renewed certificate)", func(t *testing.T) {
+		renewed := certstest.Write(t, t.TempDir())
+		replace(t, renewed.ServerCertFile, files.ServerCertFile)
+		replace(t, renewed.ServerKeyFile, files.ServerKeyFile)
+
+		got := reloader.Certificate()
+		if got == first || string(got.Certificate[0]) == string(first.Certificate[0]) {
+			t.Errorf("Reloader: should pass(renewed certificate) expected the renewed certificate")
+		}
+	})
+
+	t.Run("Reloader: should pass(keeps the certificate on a partial renewal)", func(t *testing.T) {
+		loaded := reloader.Certificate()
+		renewed := certstest.Write(t, t.TempDir())
+		replace(t, renewed.ServerCertFile, files.ServerCertFile)
+
+		if got := reloader.Certificate(); got != loaded {
+			t.Errorf("Reloader: should pass(keeps the certificate on a partial renewal) expected the loaded certificate")
+		}
+	})
+
+	t.Run("Reloader: should fail(missing key)", func(t *testing.T) {
+		if _, err := NewReloader(files.ServerCertFile, filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+			t.Errorf("Reloader: should fail(missing key) expected an error")
+		}
+	})
+}
+
+func TestCertPool(t *testing.T) {
+	files := certstest.Write(t, t.TempDir())
+	if _, err := CertPool(files.CAFile); err != nil {
+		t.Errorf("TestCertPool expected(nil) got (%v)", err)
+	}
+	if _, err := CertPool(files.ServerKeyFile); err == nil || err.Error() != "CA bundle error:no certificate found in "+files.ServerKeyFile {
+		t.Errorf("TestCertPool expected a bundle error got (%v)", err)
+	}
+}
+
+// replace - overwrites the file, with a modification time later than the loaded one
+func replace(t *testing.T, from string, to string) {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(to, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(to, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
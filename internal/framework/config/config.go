@@ -0,0 +1,473 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/tracing"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	redacted           = "******"
+	couchbaseTLSScheme = "couchbases://"
+
+	ClientAuthNone          = "none"
+	ClientAuthVerifyIfGiven = "verify-if-given"
+	ClientAuthRequire       = "require"
+)
+
+// Config - the configuration of the service. Every setting has a default, can be set in the YAML file(--config or
+// CONFIG_FILE), overridden by its environment variable and then by its flag(the variable in lower case with dashes,
+// e.g. --server-port for SERVER_PORT)
+type Config struct {
+	Name       string     `yaml:"name" env:"NAME" help:"service name reported by the probes, the logs and the traces"`
+	LogLevel   string     `yaml:"log_level" env:"LOG_LEVEL" help:"trace, debug, info, warn or error"`
+	Server     Server     `yaml:"server"`
+	GRPC       GRPC       `yaml:"grpc"`
+	Couchbase  Couchbase  `yaml:"couchbase"`
+	Probes     Probes     `yaml:"probes"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Resilience Resilience `yaml:"resilience"`
+	Cache      Cache      `yaml:"cache"`
+
+	// File - the YAML file the configuration was read from, empty when there is none
+	File string `yaml:"-"`
+	// PrintConfig - print the configuration(secrets redacted) and exit instead of starting
+	PrintConfig bool `yaml:"-"`
+}
+
+type Server struct {
+	Port               int           `yaml:"port" env:"SERVER_PORT" help:"port the HTTP server listens on"`
+	RequestTimeout     time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT" help:"deadline of every api request, 0 disables it"`
+	ReadHeaderTimeout  time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" help:"time to read the request headers"`
+	ReadTimeout        time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" help:"time to read the whole request"`
+	WriteTimeout       time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" help:"time to write the response"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" help:"time a keep-alive connection stays idle"`
+	MaxHeaderBytes     int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" help:"size limit of the request headers"`
+	MaxBodyBytes       int64         `yaml:"max_body_bytes" env:"MAX_BODY_BYTES" help:"size limit of the request bodies"`
+	MaxUploadBytes     int64         `yaml:"max_upload_bytes" env:"MAX_UPLOAD_BYTES" help:"size limit of the clippings upload"`
+	StrictJSON         bool          `yaml:"strict_json" env:"STRICT_JSON" help:"reject the JSON bodies with unknown fields"`
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY" help:"time the readiness probe fails before the server stops accepting connections"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" help:"time the requests in flight get on shutdown"`
+	TLS                ServerTLS     `yaml:"tls"`
+}
+
+// ServerTLS - HTTPS when the certificate and key are set, reloaded when the files change. Client certificates signed by
+// the client CA are verified when given(verify-if-given) or required(require, the default with a client CA)
+type ServerTLS struct {
+	CertFile     string `yaml:"cert_file" env:"SERVER_TLS_CERT_FILE" help:"PEM certificate of the HTTPS server"`
+	KeyFile      string `yaml:"key_file" env:"SERVER_TLS_KEY_FILE" help:"PEM key of the HTTPS server"`
+	ClientCAFile string `yaml:"client_ca_file" env:"SERVER_TLS_CLIENT_CA_FILE" help:"PEM bundle of the CAs of the client certificates(mTLS)"`
+	ClientAuth   string `yaml:"client_auth" env:"SERVER_TLS_CLIENT_AUTH" help:"none, verify-if-given or require"`
+}
+
+// GRPC - the gRPC api next to the HTTP one, with the TLS settings, request timeout and shutdown of the HTTP server
+type GRPC struct {
+	Port       int  `yaml:"port" env:"GRPC_PORT" help:"port the gRPC server listens on, 0 disables it"`
+	Reflection bool `yaml:"reflection" env:"GRPC_REFLECTION" help:"serve the gRPC reflection service"`
+}
+
+type Couchbase struct {
+	Host           string `yaml:"host" env:"COUCHBASE_HOST" help:"Couchbase connection string or host"`
+	Bucket         string `yaml:"bucket" env:"COUCHBASE_BUCKET" help:"Couchbase bucket"`
+	User           string `yaml:"user" env:"COUCHBASE_USER" help:"Couchbase user"`
+	Password       string `yaml:"password" env:"COUCHBASE_PASSWORD" secret:"true" help:"Couchbase password"`
+	VerboseLogging bool   `yaml:"verbose_logging" env:"ENABLE_DB_VERBOSE_LOGGING" help:"gocb verbose logging"`
+	// the TLS settings need a couchbases:// connection string. The client certificate replaces the user and password
+	CAFile   string `yaml:"ca_file" env:"COUCHBASE_TLS_CA_FILE" help:"PEM bundle of the CAs of the Couchbase certificates"`
+	CertFile string `yaml:"cert_file" env:"COUCHBASE_TLS_CERT_FILE" help:"PEM client certificate for Couchbase"`
+	KeyFile  string `yaml:"key_file" env:"COUCHBASE_TLS_KEY_FILE" help:"PEM client key for Couchbase"`
+}
+
+// TLS - the connection string is couchbases://
+func (c Couchbase) TLS() bool {
+	return strings.HasPrefix(c.Host, couchbaseTLSScheme)
+}
+
+// ClientCertificate - the cluster authenticates the client certificate instead of the user and password
+func (c Couchbase) ClientCertificate() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// Resilience - the reads are retried on transient failures with a jittered exponential backoff, and every operation
+// fails fast once the circuit breaker opened after consecutive failures, until a trial succeeds
+type Resilience struct {
+	Retries            int           `yaml:"retries" env:"RESILIENCE_RETRIES" help:"retries of a read on a transient failure, 0 disables them"`
+	RetryBaseDelay     time.Duration `yaml:"retry_base_delay" env:"RESILIENCE_RETRY_BASE_DELAY" help:"backoff before the first retry, doubled for each next one"`
+	RetryMaxDelay      time.Duration `yaml:"retry_max_delay" env:"RESILIENCE_RETRY_MAX_DELAY" help:"backoff cap of a retry"`
+	BreakerFailures    int           `yaml:"breaker_failures" env:"RESILIENCE_BREAKER_FAILURES" help:"consecutive failures that open the circuit breaker, 0 disables it"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout" env:"RESILIENCE_BREAKER_OPEN_TIMEOUT" help:"time the circuit breaker stays open before a trial"`
+}
+
+// Cache - the books and the listings read from Couchbase are kept in memory until they expire or a book write
+// invalidates them. The cache of every replica only sees the writes of that replica, the TTLs bound how stale a book
+// or listing written through another replica can be
+type Cache struct {
+	Size    int           `yaml:"size" env:"CACHE_SIZE" help:"books and listings kept in the cache, 0 disables it"`
+	BookTTL time.Duration `yaml:"book_ttl" env:"CACHE_BOOK_TTL" help:"time a book is cached, 0 disables it"`
+	ListTTL time.Duration `yaml:"list_ttl" env:"CACHE_LIST_TTL" help:"time a listing(books, groups, stats) is cached, 0 disables it"`
+}
+
+type Probes struct {
+	Timeout  time.Duration `yaml:"timeout" env:"PROBE_TIMEOUT" help:"time a dependency ping gets"`
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"PROBE_CACHE_TTL" help:"time the outcome of the pings is reused"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" help:"otlp, stdout or none"`
+}
+
+// Default - the configuration without file, environment or flags
+func Default() Config {
+	return Config{
+		Name:     "book-tracker-service",
+		LogLevel: "info",
+		Server: Server{
+			Port:               9000,
+			RequestTimeout:     30 * time.Second,
+			ReadHeaderTimeout:  5 * time.Second,
+			ReadTimeout:        30 * time.Second,
+			WriteTimeout:       60 * time.Second,
+			IdleTimeout:        120 * time.Second,
+			MaxHeaderBytes:     64 << 10,
+			MaxBodyBytes:       1 << 20,
+			MaxUploadBytes:     32 << 20,
+			StrictJSON:         true,
+			ShutdownDrainDelay: 5 * time.Second,
+			ShutdownTimeout:    30 * time.Second,
+		},
+		GRPC:    GRPC{Port: 50051, Reflection: true},
+		Probes:  Probes{Timeout: 2 * time.Second, CacheTTL: 5 * time.Second},
+		Tracing: Tracing{Exporter: tracing.ExporterNone},
+		Resilience: Resilience{
+			Retries:            2,
+			RetryBaseDelay:     50 * time.Millisecond,
+			RetryMaxDelay:      time.Second,
+			BreakerFailures:    5,
+			BreakerOpenTimeout: 30 * time.Second,
+		},
+		Cache: Cache{Size: 10000, BookTTL: time.Minute, ListTTL: 5 * time.Second},
+	}
+}
+
+// Load - registers the flags on the flag set(next to the ones of the command), parses the arguments and returns the
+// validated configuration
+func Load(flags *flag.FlagSet, args []string) (Config, error) {
+	cfg := Default()
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+	flags.BoolVar(&cfg.PrintConfig, "print-config", false, "print the configuration(secrets redacted) and exit")
+
+	var settings []setting
+	collect(reflect.ValueOf(&cfg).Elem(), &settings)
+	overrides := map[string]string{}
+	for _, s := range settings {
+		name := s.flag()
+		flags.Var(&flagValue{name: name, overrides: overrides, boolean: s.value.Kind() == reflect.Bool}, name, s.help)
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *file != "" {
+		if err := cfg.readFile(*file); err != nil {
+			return cfg, err
+		}
+		cfg.File = *file
+	}
+
+	var problems []string
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", s.env, err.Error()))
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := overrides[s.flag()]; ok {
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("--%s: %s", s.flag(), err.Error()))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate - every problem of the configuration, named by the YAML path and the environment variable of the setting
+func (c Config) Validate() error {
+	var problems []string
+	invalid := func(path string, env string, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s(%s) %s", path, env, fmt.Sprintf(format, args...)))
+	}
+
+	if strings.TrimSpace(c.Name) == "" {
+		invalid("name", "NAME", "is required")
+	}
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		invalid("log_level", "LOG_LEVEL", "must be one of trace, debug, info, warn, error, got %q", c.LogLevel)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server.port", "SERVER_PORT", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.GRPC.Port < 0 || c.GRPC.Port > 65535 {
+		invalid("grpc.port", "GRPC_PORT", "must be between 0 and 65535, got %d", c.GRPC.Port)
+	} else if c.GRPC.Port == c.Server.Port {
+		invalid("grpc.port", "GRPC_PORT", "must differ from server.port(SERVER_PORT), got %d", c.GRPC.Port)
+	}
+	for _, d := range []struct {
+		path  string
+		env   string
+		value time.Duration
+	}{
+		{"server.request_timeout", "REQUEST_TIMEOUT", c.Server.RequestTimeout},
+		{"server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", "SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"server.write_timeout", "SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"server.idle_timeout", "SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"server.shutdown_drain_delay", "SHUTDOWN_DRAIN_DELAY", c.Server.ShutdownDrainDelay},
+		{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"probes.timeout", "PROBE_TIMEOUT", c.Probes.Timeout},
+		{"probes.cache_ttl", "PROBE_CACHE_TTL", c.Probes.CacheTTL},
+		{"resilience.retry_base_delay", "RESILIENCE_RETRY_BASE_DELAY", c.Resilience.RetryBaseDelay},
+		{"resilience.retry_max_delay", "RESILIENCE_RETRY_MAX_DELAY", c.Resilience.RetryMaxDelay},
+		{"resilience.breaker_open_timeout", "RESILIENCE_BREAKER_OPEN_TIMEOUT", c.Resilience.BreakerOpenTimeout},
+		{"cache.book_ttl", "CACHE_BOOK_TTL", c.Cache.BookTTL},
+		{"cache.list_ttl", "CACHE_LIST_TTL", c.Cache.ListTTL},
+	} {
+		if d.value < 0 {
+			invalid(d.path, d.env, "must not be negative, got %s", d.value)
+		}
+	}
+	for _, size := range []struct {
+		path  string
+		env   string
+		value int64
+	}{
+		{"server.max_header_bytes", "SERVER_MAX_HEADER_BYTES", int64(c.Server.MaxHeaderBytes)},
+		{"server.max_body_bytes", "MAX_BODY_BYTES", c.Server.MaxBodyBytes},
+		{"server.max_upload_bytes", "MAX_UPLOAD_BYTES", c.Server.MaxUploadBytes},
+	} {
+		if size.value <= 0 {
+			invalid(size.path, size.env, "must be positive, got %d", size.value)
+		}
+	}
+	if c.Server.RequestTimeout > 0 && c.Server.WriteTimeout > 0 && c.Server.WriteTimeout <= c.Server.RequestTimeout {
+		invalid("server.write_timeout", "SERVER_WRITE_TIMEOUT", "must be above the request timeout(%s) so that timeouts reach the client, got %s", c.Server.RequestTimeout, c.Server.WriteTimeout)
+	}
+	if c.Resilience.Retries < 0 {
+		invalid("resilience.retries", "RESILIENCE_RETRIES", "must not be negative, got %d", c.Resilience.Retries)
+	}
+	if c.Cache.Size < 0 {
+		invalid("cache.size", "CACHE_SIZE", "must not be negative, got %d", c.Cache.Size)
+	}
+	if c.Resilience.BreakerFailures < 0 {
+		invalid("resilience.breaker_failures", "RESILIENCE_BREAKER_FAILURES", "must not be negative, got %d", c.Resilience.BreakerFailures)
+	}
+	if c.Resilience.RetryMaxDelay < c.Resilience.RetryBaseDelay {
+		invalid("resilience.retry_max_delay", "RESILIENCE_RETRY_MAX_DELAY", "must not be below the base delay(%s), got %s", c.Resilience.RetryBaseDelay, c.Resilience.RetryMaxDelay)
+	}
+	if c.Resilience.BreakerFailures > 0 && c.Resilience.BreakerOpenTimeout <= 0 {
+		invalid("resilience.breaker_open_timeout", "RESILIENCE_BREAKER_OPEN_TIMEOUT", "must be positive with the circuit breaker, got %s", c.Resilience.BreakerOpenTimeout)
+	}
+	tls := c.Server.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		invalid("server.tls.cert_file", "SERVER_TLS_CERT_FILE", "and server.tls.key_file(SERVER_TLS_KEY_FILE) must be set together")
+	}
+	switch tls.ClientAuth {
+	case "", ClientAuthNone:
+	case ClientAuthVerifyIfGiven, ClientAuthRequire:
+		if tls.ClientCAFile == "" {
+			invalid("server.tls.client_auth", "SERVER_TLS_CLIENT_AUTH", "%s needs server.tls.client_ca_file(SERVER_TLS_CLIENT_CA_FILE)", tls.ClientAuth)
+		}
+	default:
+		invalid("server.tls.client_auth", "SERVER_TLS_CLIENT_AUTH", "must be one of %s, %s, %s, got %q", ClientAuthNone, ClientAuthVerifyIfGiven, ClientAuthRequire, tls.ClientAuth)
+	}
+	if tls.ClientCAFile != "" && tls.CertFile == "" {
+		invalid("server.tls.client_ca_file", "SERVER_TLS_CLIENT_CA_FILE", "needs server.tls.cert_file(SERVER_TLS_CERT_FILE)")
+	}
+
+	type requiredSetting struct {
+		path  string
+		env   string
+		value string
+	}
+	required := []requiredSetting{
+		{"couchbase.host", "COUCHBASE_HOST", c.Couchbase.Host},
+		{"couchbase.bucket", "COUCHBASE_BUCKET", c.Couchbase.Bucket},
+	}
+	if c.Couchbase.ClientCertificate() {
+		required = append(required, requiredSetting{"couchbase.cert_file", "COUCHBASE_TLS_CERT_FILE", c.Couchbase.CertFile},
+			requiredSetting{"couchbase.key_file", "COUCHBASE_TLS_KEY_FILE", c.Couchbase.KeyFile})
+	} else {
+		required = append(required, requiredSetting{"couchbase.user", "COUCHBASE_USER", c.Couchbase.User},
+			requiredSetting{"couchbase.password", "COUCHBASE_PASSWORD", c.Couchbase.Password})
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			invalid(r.path, r.env, "is required")
+		}
+	}
+	if (c.Couchbase.CAFile != "" || c.Couchbase.ClientCertificate()) && c.Couchbase.Host != "" && !c.Couchbase.TLS() {
+		invalid("couchbase.host", "COUCHBASE_HOST", "must be a %s connection string with the TLS settings, got %q", couchbaseTLSScheme, c.Couchbase.Host)
+	}
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "", tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		invalid("tracing.exporter", "OTEL_TRACES_EXPORTER", "must be one of %s, %s, %s, got %q", tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone, c.Tracing.Exporter)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// String - the configuration as YAML, with the secrets redacted so that it can be printed or logged
+func (c Config) String() string {
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(printable(reflect.ValueOf(c))); err != nil {
+		return err.Error()
+	}
+	return out.String()
+}
+
+// readFile - the settings of the YAML file over the defaults. Unknown keys are rejected, like unknown JSON fields
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file error:%s", err.Error())
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s error:%s", path, err.Error())
+	}
+	return nil
+}
+
+// setting - a leaf of the configuration, set from its environment variable or flag
+type setting struct {
+	env   string
+	help  string
+	value reflect.Value
+}
+
+// flag - the flag of the setting, SERVER_PORT is --server-port
+func (s setting) flag() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+func (s setting) set(value string) error {
+	switch s.value.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		s.value.SetInt(int64(d))
+		return nil
+	}
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		s.value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		s.value.SetInt(i)
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// collect - the settings of the struct, the fields with an env tag
+func collect(v reflect.Value, settings *[]setting) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			collect(v.Field(i), settings)
+			continue
+		}
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		*settings = append(*settings, setting{env: env, help: field.Tag.Get("help"), value: v.Field(i)})
+	}
+}
+
+// printable - the YAML document of the struct: durations as strings(30s) and secrets redacted
+func printable(v reflect.Value) map[string]interface{} {
+	out := map[string]interface{}{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		value := v.Field(i)
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if value.String() != "" {
+				out[name] = redacted
+			} else {
+				out[name] = ""
+			}
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			out[name] = time.Duration(value.Int()).String()
+		case field.Type.Kind() == reflect.Struct:
+			out[name] = printable(value)
+		default:
+			out[name] = value.Interface()
+		}
+	}
+	return out
+}
+
+// flagValue - a setting given on the command line, applied after the file and the environment
+type flagValue struct {
+	name      string
+	overrides map[string]string
+	boolean   bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.overrides == nil {
+		return ""
+	}
+	return f.overrides[f.name]
+}
+
+func (f *flagValue) Set(value string) error {
+	f.overrides[f.name] = value
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.boolean
+}
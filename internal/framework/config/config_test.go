@@ -0,0 +1,279 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const configFile = `
+name: reading-list
+server:
+  port: 8080
+  request_timeout: 10s
+couchbase:
+  host: couchbase://db
+  bucket: reading-list
+  user: reader
+  password: file-secret
+`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		testName string
+		file     string
+		env      map[string]string
+		args     []string
+		check    func(Config) bool
+		err      string
+	}{
+		{
+			"Load: should pass(file)",
+			configFile,
+			nil,
+			nil,
+			func(c Config) bool {
+				return c.Name == "reading-list" && c.Server.Port == 8080 && c.Server.RequestTimeout == 10*time.Second &&
+					c.Server.ShutdownTimeout == 30*time.Second && c.Couchbase.Password == "file-secret"
+			},
+			"",
+		},
+		{
+			"Load: should pass(environment over file)",
+			configFile,
+			map[string]string{"SERVER_PORT": "9090", "STRICT_JSON": "false", "COUCHBASE_PASSWORD": "env-secret"},
+			nil,
+			func(c Config) bool {
+				return c.Server.Port == 9090 && !c.Server.StrictJSON && c.Couchbase.Password == "env-secret"
+			},
+			"",
+		},
+		{
+			"Load: should pass(flags over environment)",
+			configFile,
+			map[string]string{"SERVER_PORT": "9090", "LOG_LEVEL": "debug"},
+			[]string{"--server-port", "9191", "--enable-db-verbose-logging", "--probe-timeout=1s"},
+			func(c Config) bool {
+				return c.Server.Port == 9191 && c.LogLevel == "debug" && c.Couchbase.VerboseLogging && c.Probes.Timeout == time.Second
+			},
+			"",
+		},
+		{
+			"Load: should fail(invalid environment value)",
+			configFile,
+			map[string]string{"REQUEST_TIMEOUT": "soon"},
+			nil,
+			nil,
+			`invalid configuration: REQUEST_TIMEOUT: invalid duration "soon"`,
+		},
+		{
+			"Load: should fail(unknown file key)",
+			configFile + "\nsrever:\n  port: 1\n",
+			nil,
+			nil,
+			nil,
+			"field srever not found in type config.Config",
+		},
+		{
+			"Load: should fail(validation)",
+			"",
+			nil,
+			[]string{"--server-port", "0"},
+			nil,
+			"server.port(SERVER_PORT) must be between 1 and 65535, got 0; couchbase.host(COUCHBASE_HOST) is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			args := test.args
+			if test.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(test.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"--config", path}, args...)
+			}
+
+			cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("%s expected(%s) got (%v)", test.testName, test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			if !test.check(cfg) {
+				t.Errorf("%s unexpected configuration got (%s)", test.testName, cfg)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Couchbase = Couchbase{Host: "localhost", Bucket: "reading-list", User: "reader", Password: "secret"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("TestValidate expected(nil) got (%v)", err)
+	}
+
+	cfg.Server.WriteTimeout = cfg.Server.RequestTimeout
+	cfg.Server.MaxBodyBytes = 0
+	cfg.Probes.CacheTTL = -time.Second
+	cfg.Tracing.Exporter = "jaeger"
+	want := "invalid configuration: probes.cache_ttl(PROBE_CACHE_TTL) must not be negative, got -1s; " +
+		"server.max_body_bytes(MAX_BODY_BYTES) must be positive, got 0; server.write_timeout(SERVER_WRITE_TIMEOUT) must " +
+		"be above the request timeout(30s) so that timeouts reach the client, got 30s; tracing.exporter(OTEL_TRACES_EXPORTER) " +
+		`must be one of otlp, stdout, none, got "jaeger"`
+	if err := cfg.Validate(); err == nil || err.Error() != want {
+		t.Errorf("TestValidate expected(%s) got (%v)", want, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	cfg := Default()
+	cfg.Couchbase.Password = "secret"
+
+	printed := cfg.String()
+	if strings.Contains(printed, "secret") || !strings.Contains(printed, "password: '******'") {
+		t.Errorf("TestString expected a redacted password got (%s)", printed)
+	}
+	if !strings.Contains(printed, "request_timeout: 30s") {
+		t.Errorf("TestString expected readable durations got (%s)", printed)
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		testName string
+		update   func(*Config)
+		err      string
+	}{
+		{
+			"ValidateTLS: should pass(client certificate instead of password)",
+			func(c *Config) {
+				c.Couchbase = Couchbase{Host: "couchbases://db", Bucket: "reading-list", CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client-key.pem"}
+			},
+			"",
+		},
+		{
+			"ValidateTLS: should fail(certificate without key)",
+			func(c *Config) { c.Server.TLS.CertFile = "server.pem" },
+			"server.tls.cert_file(SERVER_TLS_CERT_FILE) and server.tls.key_file(SERVER_TLS_KEY_FILE) must be set together",
+		},
+		{
+			"ValidateTLS: should fail(client auth without client CA)",
+			func(c *Config) {
+				c.Server.TLS = ServerTLS{CertFile: "server.pem", KeyFile: "server-key.pem", ClientAuth: ClientAuthRequire}
+			},
+			"server.tls.client_auth(SERVER_TLS_CLIENT_AUTH) require needs server.tls.client_ca_file(SERVER_TLS_CLIENT_CA_FILE)",
+		},
+		{
+			"ValidateTLS: should fail(Couchbase TLS over couchbase://)",
+			func(c *Config) { c.Couchbase.CAFile = "ca.pem" },
+			`couchbase.host(COUCHBASE_HOST) must be a couchbases:// connection string with the TLS settings, got "couchbase://db"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			cfg := Default()
+			cfg.Couchbase = Couchbase{Host: "couchbase://db", Bucket: "reading-list", User: "reader", Password: "secret"}
+			test.update(&cfg)
+
+			err := cfg.Validate()
+			if test.err == "" && err != nil {
+				t.Errorf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			if test.err != "" && (err == nil || err.Error() != "invalid configuration: "+test.err) {
+				t.Errorf("%s expected(%s) got (%v)", test.testName, test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		testName string
+		update   func(*Config)
+		err      string
+	}{
+		{
+			"ValidateStorage: should pass(retries, circuit breaker and cache disabled)",
+			func(c *Config) { c.Resilience, c.Cache = Resilience{}, Cache{} },
+			"",
+		},
+		{
+			"ValidateStorage: should fail(negative cache size and TTL)",
+			func(c *Config) { c.Cache = Cache{Size: -1, ListTTL: -time.Second} },
+			"cache.list_ttl(CACHE_LIST_TTL) must not be negative, got -1s; cache.size(CACHE_SIZE) must not be negative, got -1",
+		},
+		{
+			"ValidateStorage: should fail(negative retries)",
+			func(c *Config) { c.Resilience.Retries = -1 },
+			"resilience.retries(RESILIENCE_RETRIES) must not be negative, got -1",
+		},
+		{
+			"ValidateStorage: should fail(max delay below base delay)",
+			func(c *Config) { c.Resilience.RetryMaxDelay = 10 * time.Millisecond },
+			"resilience.retry_max_delay(RESILIENCE_RETRY_MAX_DELAY) must not be below the base delay(50ms), got 10ms",
+		},
+		{
+			"ValidateStorage: should fail(circuit breaker without open timeout)",
+			func(c *Config) { c.Resilience.BreakerOpenTimeout = 0 },
+			"resilience.breaker_open_timeout(RESILIENCE_BREAKER_OPEN_TIMEOUT) must be positive with the circuit breaker, got 0s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			cfg := Default()
+			cfg.Couchbase = Couchbase{Host: "couchbase://db", Bucket: "reading-list", User: "reader", Password: "secret"}
+			test.update(&cfg)
+
+			err := cfg.Validate()
+			if test.err == "" && err != nil {
+				t.Errorf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			if test.err != "" && (err == nil || err.Error() != "invalid configuration: "+test.err) {
+				t.Errorf("%s expected(%s) got (%v)", test.testName, test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateGRPC(t *testing.T) {
+	tests := []struct {
+		testName string
+		grpc     GRPC
+		err      string
+	}{
+		{"ValidateGRPC: should pass(disabled)", GRPC{}, ""},
+		{"ValidateGRPC: should fail(port out of range)", GRPC{Port: 70000}, "grpc.port(GRPC_PORT) must be between 0 and 65535, got 70000"},
+		{"ValidateGRPC: should fail(port of the HTTP server)", GRPC{Port: 9000}, "grpc.port(GRPC_PORT) must differ from server.port(SERVER_PORT), got 9000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			cfg := Default()
+			cfg.Couchbase = Couchbase{Host: "couchbase://db", Bucket: "reading-list", User: "reader", Password: "secret"}
+			cfg.GRPC = test.grpc
+
+			err := cfg.Validate()
+			if test.err == "" && err != nil {
+				t.Errorf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			if test.err != "" && (err == nil || err.Error() != "invalid configuration: "+test.err) {
+				t.Errorf("%s expected(%s) got (%v)", test.testName, test.err, err)
+			}
+		})
+	}
+}
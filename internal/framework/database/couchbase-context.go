@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+// timeLeft - the time before the deadline of the context, 0(the gocb default timeout) when it has none. gocb cancels
+// the operation on the context, the timeout also bounds the N1QL query on the server
+func timeLeft(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	if left := time.Until(deadline); left > 0 {
+		return left
+	}
+	// already expired, gocb gives up on the done context right away
+	return time.Nanosecond
+}
+
+func queryOptions(ctx context.Context, params map[string]interface{}) *gocb.QueryOptions {
+	return &gocb.QueryOptions{NamedParameters: params, Context: ctx, Timeout: timeLeft(ctx), ParentSpan: parentSpan(ctx)}
+}
+
+func getOptions(ctx context.Context) *gocb.GetOptions {
+	return &gocb.GetOptions{Context: ctx, Timeout: timeLeft(ctx), ParentSpan: parentSpan(ctx)}
+}
+
+func upsertOptions(ctx context.Context) *gocb.UpsertOptions {
+	return &gocb.UpsertOptions{Context: ctx, Timeout: timeLeft(ctx), ParentSpan: parentSpan(ctx)}
+}
+
+func replaceOptions(ctx context.Context, cas gocb.Cas) *gocb.ReplaceOptions {
+	return &gocb.ReplaceOptions{Cas: cas, Context: ctx, Timeout: timeLeft(ctx), ParentSpan: parentSpan(ctx)}
+}
+
+func removeOptions(ctx context.Context) *gocb.RemoveOptions {
+	return &gocb.RemoveOptions{Context: ctx, Timeout: timeLeft(ctx), ParentSpan: parentSpan(ctx)}
+}
+
+// storageError - the error prefixed with the operation. Timeouts and cancellations(of gocb or of the context) are
+// returned as entity.TimeoutError and entity.CanceledError, and the transient failures of the cluster(see transient)
+// as entity.UnavailableError, so that callers can tell them from failures
+func storageError(prefix string, err error) error {
+	var timeout entity.TimeoutError
+	var canceled entity.CanceledError
+	var unavailable entity.UnavailableError
+	switch {
+	case errors.As(err, &timeout):
+		return entity.TimeoutError{Message: prefix + timeout.Message, Err: timeout.Err}
+	case errors.As(err, &canceled):
+		return entity.CanceledError{Message: prefix + canceled.Message, Err: canceled.Err}
+	case errors.As(err, &unavailable):
+		return entity.UnavailableError{Message: prefix + unavailable.Message, RetryAfter: unavailable.RetryAfter, Err: unavailable.Err}
+	case transient(err):
+		return entity.UnavailableError{Message: prefix + "cluster temporarily unavailable", Err: err}
+	case errors.Is(err, gocb.ErrTimeout) || errors.Is(err, context.DeadlineExceeded):
+		return entity.TimeoutError{Message: prefix + "operation timed out", Err: err}
+	case errors.Is(err, gocb.ErrRequestCanceled) || errors.Is(err, context.Canceled):
+		return entity.CanceledError{Message: prefix + "operation canceled", Err: err}
+	}
+	return fmt.Errorf("%s%s", prefix, err.Error())
+}
+
+// transient - the failures that the same operation may not hit a moment later: the node is busy, rebalancing or
+// failing over, or the document is locked
+func transient(err error) bool {
+	for _, target := range []error{gocb.ErrTemporaryFailure, gocb.ErrOverload, gocb.ErrServiceNotAvailable,
+		gocb.ErrDocumentLocked, gocb.ErrDurableWriteInProgress, gocb.ErrJobQueueFull} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,18 +3,23 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
+	"time"
 
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
 
 	"github.com/couchbase/gocb/v2"
 )
 
 const testFolderPath = "../../../tests/"
 
+const legacyDocument = `{"isbn": "isbn-0", "title": "title-0", "author": "author-0", "genre": "Horror", "status": "finished"}`
+
 var count int = 0
 
 // Couchbase fake
@@ -52,12 +57,61 @@ func NewFakeCouchbaseStorage(force string) (repository.Storage, error) {
 }
 
 // NewCouchbaseStorage is here only to avoid the error on main.go
-func NewCouchbaseStorage() (repository.Storage, error) {
+func NewCouchbaseStorage(cfg config.Couchbase) (repository.Storage, error) {
 	return nil, nil
 }
 
+// Close - override the original gocb implementation
+func (c *Couchbase) Close() error {
+	if c.Cluster.Force == "close-error" {
+		return errors.New("forced cluster close error")
+	}
+	return nil
+}
+
+// PingCluster - override the original gocb implementation
+func (c *Couchbase) PingCluster(_ time.Duration) error {
+	if c.Cluster.Force == "ping-error" {
+		return errors.New("cluster ping error:forced ping error")
+	}
+	return nil
+}
+
+// PingBucket - override the original gocb implementation
+func (c *Couchbase) PingBucket(_ time.Duration) error {
+	if c.Bucket.Force == "ping-error" {
+		return errors.New("bucket ping error:forced ping error")
+	}
+	return nil
+}
+
+// contextError - the errors gocb returns for a done context, or for the forced timeout and temporary failure
+func contextError(ctx context.Context, force string) error {
+	switch force {
+	case "timeout":
+		return gocb.ErrUnambiguousTimeout
+	case "unavailable":
+		return gocb.ErrTemporaryFailure
+	}
+	if ctx == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return gocb.ErrUnambiguousTimeout
+	case context.Canceled:
+		return gocb.ErrRequestCanceled
+	}
+	return nil
+}
+
 // Query - inject our implementation for testing
-func (fs *FakeScope) Query(_ string, _ *gocb.QueryOptions) (*FakeResult, error) {
+func (fs *FakeScope) Query(_ string, opts *gocb.QueryOptions) (*FakeResult, error) {
+	if opts != nil {
+		if err := contextError(opts.Context, fs.Force); err != nil {
+			return &FakeResult{}, err
+		}
+	}
 	if fs.Force == "query-error" {
 		return &FakeResult{}, errors.New("forced query error")
 	}
@@ -75,7 +129,12 @@ func (fs *FakeScope) Collection(_ string) *FakeCollection {
 }
 
 // Get - override the original golang implementation
-func (fc *FakeCollection) Get(_ string, _ interface{}) (*FakeResult, error) {
+func (fc *FakeCollection) Get(_ string, opts interface{}) (*FakeResult, error) {
+	if opts, ok := opts.(*gocb.GetOptions); ok && opts != nil {
+		if err := contextError(opts.Context, fc.Force); err != nil {
+			return &FakeResult{}, err
+		}
+	}
 	if fc.Force == "true" {
 		return &FakeResult{}, errors.New("forced collection error")
 	}
@@ -95,15 +154,29 @@ func (fr *FakeResult) Next() bool {
 	return false
 }
 
-// Row - override the original golang implementation
+// Row - override the original golang implementation. Rows other than books are decoded from the same fake book
 func (fr *FakeResult) Row(ptr interface{}) error {
+	if fr.Force == "row-error" {
+		return errors.New("forced row error")
+	}
 	book := entity.Book{
 		ISBN:  "isbn-1",
 		Title: "title-1",
 		Genre: "Horror",
 	}
-	*ptr.(*entity.Book) = book
-	return nil
+	switch row := ptr.(type) {
+	case *entity.Book:
+		*row = book
+		return nil
+	case *int:
+		*row = 2
+		return nil
+	case *string:
+		*row = book.ISBN
+		return nil
+	}
+	data, _ := json.Marshal(book)
+	return json.Unmarshal(data, ptr)
 }
 
 // One - override the original golang implementation
@@ -121,11 +194,36 @@ func (fr *FakeResult) Content(ptr interface{}) error {
 		return errors.New("forced content error")
 	}
 
-	_, ok := ptr.(*entity.Book)
-	if ok {
+	switch ptr.(type) {
+	case *entity.Book:
 		data, _ := os.ReadFile(testFolderPath + "book.json")
 		_ = json.Unmarshal(data, &book)
 		*ptr.(*entity.Book) = book
+	case *map[string]interface{}:
+		if fr.Force == "legacy-document" {
+			_ = json.Unmarshal([]byte(legacyDocument), ptr)
+			break
+		}
+		data, _ := os.ReadFile(testFolderPath + "book.json")
+		_ = json.Unmarshal(data, ptr)
+	case *entity.Goal:
+		data, _ := os.ReadFile(testFolderPath + "goal.json")
+		_ = json.Unmarshal(data, ptr)
+	case *entity.Shelf:
+		data, _ := os.ReadFile(testFolderPath + "shelf.json")
+		_ = json.Unmarshal(data, ptr)
+	case *entity.Review:
+		data, _ := os.ReadFile(testFolderPath + "review.json")
+		_ = json.Unmarshal(data, ptr)
+	case *entity.Note:
+		data, _ := os.ReadFile(testFolderPath + "note.json")
+		_ = json.Unmarshal(data, ptr)
+	case *entity.Highlight:
+		data, _ := os.ReadFile(testFolderPath + "highlight.json")
+		_ = json.Unmarshal(data, ptr)
+	case *entity.Genre:
+		data, _ := os.ReadFile(testFolderPath + "genre.json")
+		_ = json.Unmarshal(data, ptr)
 	}
 
 	return nil
@@ -140,9 +238,38 @@ func (fr *FakeResult) Close() error {
 }
 
 // Upsert : wrapper function for couchbase upsert
-func (fc *FakeCollection) Upsert(_ string, _ interface{}, _ *gocb.UpsertOptions) (*gocb.MutationResult, error) {
+func (fc *FakeCollection) Upsert(_ string, _ interface{}, opts *gocb.UpsertOptions) (*gocb.MutationResult, error) {
+	if opts != nil {
+		if err := contextError(opts.Context, fc.Force); err != nil {
+			return &gocb.MutationResult{}, err
+		}
+	}
 	if fc.Force == "error" || fc.Force == "update-error" {
 		return &gocb.MutationResult{}, errors.New("forced collection upsert error")
 	}
 	return &gocb.MutationResult{}, nil
 }
+
+// Replace : wrapper function for couchbase replace
+func (fc *FakeCollection) Replace(_ string, _ interface{}, _ *gocb.ReplaceOptions) (*gocb.MutationResult, error) {
+	if fc.Force == "cas-mismatch" {
+		return &gocb.MutationResult{}, gocb.ErrCasMismatch
+	}
+	if fc.Force == "update-error" {
+		return &gocb.MutationResult{}, errors.New("forced collection replace error")
+	}
+	return &gocb.MutationResult{}, nil
+}
+
+// Remove : wrapper function for couchbase remove
+func (fc *FakeCollection) Remove(_ string, opts *gocb.RemoveOptions) (*gocb.MutationResult, error) {
+	if opts != nil {
+		if err := contextError(opts.Context, fc.Force); err != nil {
+			return &gocb.MutationResult{}, err
+		}
+	}
+	if fc.Force == "remove-error" {
+		return &gocb.MutationResult{}, errors.New("forced collection remove error")
+	}
+	return &gocb.MutationResult{}, nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const genreCollection = "genre"
+
+// GetGenre - wrapper to get a genre of the taxonomy
+func (c *Couchbase) GetGenre(ctx context.Context, key string) (*entity.Genre, error) {
+	var genre entity.Genre
+
+	collection := c.Bucket.Scope(defaultScope).Collection(genreCollection)
+	result, err := collection.Get(key, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get genre error:", err)
+	}
+	err = result.Content(&genre)
+	if err != nil {
+		return nil, storageError("get genre content error:", err)
+	}
+	return &genre, nil
+}
+
+// GetAllGenres - wrapper to list the genre taxonomy ordered by name
+func (c *Couchbase) GetAllGenres(ctx context.Context) ([]entity.Genre, error) {
+	genres, err := queryRows[entity.Genre](ctx, c, "select raw g from genre g order by lower(g.name)", nil)
+	if err != nil {
+		return nil, storageError("GetAllGenres ", err)
+	}
+	return genres, nil
+}
+
+// UpsertGenre :  wrapper to create or update a genre of the taxonomy
+func (c *Couchbase) UpsertGenre(ctx context.Context, key string, value interface{}) error {
+	opts := upsertOptions(ctx)
+	collection := c.Bucket.Scope(defaultScope).Collection(genreCollection)
+	_, err := collection.Upsert(key, value, opts)
+	if err != nil {
+		return storageError("UpsertGenre error:", err)
+	}
+	return nil
+}
+
+// RemoveGenre :  wrapper to delete a genre of the taxonomy
+func (c *Couchbase) RemoveGenre(ctx context.Context, key string) error {
+	collection := c.Bucket.Scope(defaultScope).Collection(genreCollection)
+	_, err := collection.Remove(key, removeOptions(ctx))
+	if err != nil {
+		return storageError("RemoveGenre error:", err)
+	}
+	return nil
+}
+
+// RemapGenre - sets the genre of every book whose genre is one of the spellings(lower case). Returns the number of
+// books updated
+func (c *Couchbase) RemapGenre(ctx context.Context, spellings []string, to string) (int, error) {
+	ids, err := queryRows[string](ctx, c, "update book b set b.genre = $to, b.updated = $now, b.updated_by = $user "+
+		"where lower(trim(b.genre)) in $spellings returning raw meta(b).id",
+		map[string]interface{}{"spellings": spellings, "to": to, "now": time.Now().Unix(), "user": systemUser})
+	if err != nil {
+		return 0, storageError("RemapGenre ", err)
+	}
+	return len(ids), nil
+}
+
+// GenreCounts - number of books per genre, regardless of case. The name is one of the spellings of the genre
+func (c *Couchbase) GenreCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	counts, err := queryRows[entity.NamedCount](ctx, c, "select min(trim(ifmissingornull(b.genre, ''))) as name, count(1) as count "+
+		"from book b group by lower(trim(ifmissingornull(b.genre, '')))", nil)
+	if err != nil {
+		return nil, storageError("GenreCounts ", err)
+	}
+	return counts, nil
+}
+
+// StatusCounts - number of books per status, upper cased. Books without a status are left out
+func (c *Couchbase) StatusCounts(ctx context.Context) ([]entity.NamedCount, error) {
+	counts, err := queryRows[entity.NamedCount](ctx, c, "select upper(trim(b.status)) as name, count(1) as count "+
+		"from book b where trim(ifmissingornull(b.status, '')) != '' group by upper(trim(b.status))", nil)
+	if err != nil {
+		return nil, storageError("StatusCounts ", err)
+	}
+	return counts, nil
+}
+
+// GenreBooks - the page(ordered by title) of the books whose genre is one of the spellings(lower case), with the
+// total number of books of the genre
+func (c *Couchbase) GenreBooks(ctx context.Context, spellings []string, page entity.Page) ([]entity.Book, int, error) {
+	params := map[string]interface{}{"spellings": spellings}
+	total, err := queryRows[int](ctx, c, "select raw count(1) from book b "+
+		"where lower(trim(ifmissingornull(b.genre, ''))) in $spellings", params)
+	if err != nil {
+		return nil, 0, storageError("GenreBooks count ", err)
+	}
+
+	params["offset"], params["limit"] = page.Offset, page.Limit
+	docs, err := queryRows[map[string]interface{}](ctx, c, "select raw b from book b "+
+		"where lower(trim(ifmissingornull(b.genre, ''))) in $spellings order by b.title, meta(b).id offset $offset limit $limit", params)
+	if err != nil {
+		return nil, 0, storageError("GenreBooks ", err)
+	}
+
+	books := make([]entity.Book, 0, len(docs))
+	for _, doc := range docs {
+		book, err := decodeBook(doc)
+		if err != nil {
+			return nil, 0, storageError("GenreBooks migration error:", err)
+		}
+		books = append(books, *book)
+	}
+	if len(total) == 0 {
+		return books, 0, nil
+	}
+	return books, total[0], nil
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const goalCollection = "goal"
+
+// GetGoal - wrapper to get the reading goal of a year
+func (c *Couchbase) GetGoal(ctx context.Context, year string) (*entity.Goal, error) {
+	var goal entity.Goal
+
+	collection := c.Bucket.Scope(defaultScope).Collection(goalCollection)
+	result, err := collection.Get(year, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get goal error:", err)
+	}
+	err = result.Content(&goal)
+	if err != nil {
+		return nil, storageError("get goal content error:", err)
+	}
+	return &goal, nil
+}
+
+// GetAllGoals - wrapper to list all reading goals ordered by year
+func (c *Couchbase) GetAllGoals(ctx context.Context) ([]entity.Goal, error) {
+	goals, err := queryRows[entity.Goal](ctx, c, "select raw g from goal g order by g.year", nil)
+	if err != nil {
+		return nil, storageError("GetAllGoals ", err)
+	}
+	return goals, nil
+}
+
+// UpsertGoal :  wrapper to create or update a reading goal
+func (c *Couchbase) UpsertGoal(ctx context.Context, key string, value interface{}) error {
+	opts := upsertOptions(ctx)
+	collection := c.Bucket.Scope(defaultScope).Collection(goalCollection)
+	_, err := collection.Upsert(key, value, opts)
+	if err != nil {
+		return storageError("UpsertGoal error:", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const highlightCollection = "highlight"
+
+// GetHighlight - wrapper to get a highlight
+func (c *Couchbase) GetHighlight(ctx context.Context, id string) (*entity.Highlight, error) {
+	var highlight entity.Highlight
+
+	collection := c.Bucket.Scope(defaultScope).Collection(highlightCollection)
+	result, err := collection.Get(id, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get highlight error:", err)
+	}
+	err = result.Content(&highlight)
+	if err != nil {
+		return nil, storageError("get highlight content error:", err)
+	}
+	return &highlight, nil
+}
+
+// GetHighlights - wrapper to list the highlights of a book ordered by page
+func (c *Couchbase) GetHighlights(ctx context.Context, isbn string) ([]entity.Highlight, error) {
+	highlights, err := queryRows[entity.Highlight](ctx, c, "select raw h from highlight h where h.isbn = $isbn order by h.page, h.created",
+		map[string]interface{}{"isbn": isbn})
+	if err != nil {
+		return nil, storageError("GetHighlights ", err)
+	}
+	return highlights, nil
+}
+
+// GetAllHighlights - wrapper to list the highlights of all books
+func (c *Couchbase) GetAllHighlights(ctx context.Context) ([]entity.Highlight, error) {
+	highlights, err := queryRows[entity.Highlight](ctx, c, "select raw h from highlight h order by h.isbn, h.page, h.created", nil)
+	if err != nil {
+		return nil, storageError("GetAllHighlights ", err)
+	}
+	return highlights, nil
+}
+
+// UpsertHighlight :  wrapper to create or update a highlight
+func (c *Couchbase) UpsertHighlight(ctx context.Context, id string, value interface{}) error {
+	opts := upsertOptions(ctx)
+	collection := c.Bucket.Scope(defaultScope).Collection(highlightCollection)
+	_, err := collection.Upsert(id, value, opts)
+	if err != nil {
+		return storageError("UpsertHighlight error:", err)
+	}
+	return nil
+}
+
+// RemoveHighlight :  wrapper to delete a highlight
+func (c *Couchbase) RemoveHighlight(ctx context.Context, id string) error {
+	collection := c.Bucket.Scope(defaultScope).Collection(highlightCollection)
+	_, err := collection.Remove(id, removeOptions(ctx))
+	if err != nil {
+		return storageError("RemoveHighlight error:", err)
+	}
+	return nil
+}
@@ -1,11 +1,11 @@
 package database
 
 import (
-	"fmt"
-	"github.com/couchbase/gocb/v2"
+	"context"
 	"github.com/sirupsen/logrus"
 
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
 )
 
 const (
@@ -13,57 +13,90 @@ const (
 	bookCollection = "book"
 )
 
-var l = logrus.StandardLogger()
-
-// Get - wrapper to get a book resource
-func (c *Couchbase) Get(id string) (*entity.Book, error) {
-	var book entity.Book
+// Get - wrapper to get a book resource. Older documents are upgraded to the current schema version on read
+func (c *Couchbase) Get(ctx context.Context, id string) (*entity.Book, error) {
+	var doc map[string]interface{}
 
 	collection := c.Bucket.Scope(defaultScope).Collection(bookCollection)
-	result, err := collection.Get(id, nil)
+	result, err := collection.Get(id, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get error:", err)
+	}
+	err = result.Content(&doc)
 	if err != nil {
-		return nil, fmt.Errorf("get error:%s", err.Error())
+		return nil, storageError("get content error:", err)
 	}
-	err = result.Content(&book)
+	book, err := decodeBook(doc)
 	if err != nil {
-		return nil, fmt.Errorf("get content error:%s", err.Error())
+		return nil, storageError("get migration error:", err)
 	}
-	return &book, nil
+	return book, nil
 }
 
 // GetAll - wrapper to list all book resources
-func (c *Couchbase) GetAll() ([]entity.Book, error) {
+func (c *Couchbase) GetAll(ctx context.Context) ([]entity.Book, error) {
 	var books []entity.Book
 
 	query := "select raw b from book b"
 
-	l.Tracef("Function GetAll %s", query)
-	res, err := c.Bucket.Scope(defaultScope).Query(query, nil)
+	logging.FromContext(ctx).WithField("query", query).Trace("GetAll")
+	res, err := c.Bucket.Scope(defaultScope).Query(query, queryOptions(ctx, nil))
 	if err != nil {
-		return nil, fmt.Errorf("GetAll query error:%s", err.Error())
+		return nil, storageError("GetAll query error:", err)
 	}
 
-	l.Tracef("Function GetAll next()")
 	for res.Next() {
-		var row entity.Book
-		err = res.Row(&row)
-		l.Tracef("Function GetAll data %+v", row)
-		books = append(books, row)
+		var doc map[string]interface{}
+		if err = res.Row(&doc); err != nil {
+			return nil, storageError("GetAll row error:", err)
+		}
+		row, err := decodeBook(doc)
+		if err != nil {
+			return nil, storageError("GetAll migration error:", err)
+		}
+		logging.FromContext(ctx).WithField("row", row).Trace("GetAll row")
+		books = append(books, *row)
 	}
 	if err = res.Close(); err != nil {
-		return nil, fmt.Errorf("GetAll result close error:%s", err.Error())
+		return nil, storageError("GetAll result close error:", err)
 	}
 
 	return books, nil
 }
 
-// Upsert :  wrapper to update a book resource
-func (c *Couchbase) Upsert(key string, value interface{}) error {
-	opts := &gocb.UpsertOptions{}
+// Upsert :  wrapper to update a book resource. Books are stamped with the current schema version(see stampBook)
+func (c *Couchbase) Upsert(ctx context.Context, key string, value interface{}) error {
+	value = stampBook(value)
+	opts := upsertOptions(ctx)
 	collection := c.Bucket.Scope(defaultScope).Collection(bookCollection)
 	_, err := collection.Upsert(key, value, opts)
 	if err != nil {
-		return fmt.Errorf("Upsert error:%s", err.Error())
+		return storageError("Upsert error:", err)
 	}
 	return nil
 }
+
+// queryRows - runs a N1QL query against the default scope and decodes every row into T. The query span of gocb is a
+// child of the span of the context
+func queryRows[T any](ctx context.Context, c *Couchbase, query string, params map[string]interface{}) ([]T, error) {
+	var rows []T
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{"query": query, "params": params}).Trace("queryRows")
+	res, err := c.Bucket.Scope(defaultScope).Query(query, queryOptions(ctx, params))
+	if err != nil {
+		return nil, storageError("query error:", err)
+	}
+
+	for res.Next() {
+		var row T
+		if err = res.Row(&row); err != nil {
+			return nil, storageError("query row error:", err)
+		}
+		rows = append(rows, row)
+	}
+	if err = res.Close(); err != nil {
+		return nil, storageError("query result close error:", err)
+	}
+
+	return rows, nil
+}
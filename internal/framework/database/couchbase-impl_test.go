@@ -3,10 +3,22 @@
 package database
 
 import (
+	"context"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs/certstest"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+
+	"github.com/couchbase/gocb/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 const (
@@ -15,6 +27,23 @@ const (
 	getAllMethod            = "ListBooks"
 	newFakeCouchbaseStorage = "NewFakeCouchbaseStorage"
 	newCouchbaseStorage     = "NewCouchbaseStorage"
+	statsMethod             = "Stats"
+	upsertGoalMethod        = "UpsertGoal"
+	getGoalMethod           = "GetGoal"
+	getAllGoalsMethod       = "GetAllGoals"
+	migrateAllMethod        = "MigrateAll"
+	renameTagMethod         = "RenameTag"
+	removeTagMethod         = "RemoveTag"
+	getShelfMethod          = "GetShelf"
+	removeShelfMethod       = "RemoveShelf"
+	genreCountsMethod       = "GenreCounts"
+	statusCountsMethod      = "StatusCounts"
+	genreBooksMethod        = "GenreBooks"
+	remapGenreMethod        = "RemapGenre"
+	getAllSortedMethod      = "GetAllSorted"
+	closeMethod             = "Close"
+	pingClusterMethod       = "PingCluster"
+	pingBucketMethod        = "PingBucket"
 )
 
 func TestCouchbaseImpl(t *testing.T) {
@@ -97,24 +126,268 @@ func TestCouchbaseImpl(t *testing.T) {
 			newCouchbaseStorage,
 			nil,
 		},
+		{
+			"Stats: should pass",
+			"",
+			"",
+			statsMethod,
+			nil,
+		},
+		{
+			"Stats: should fail (query error)",
+			"query-error",
+			"",
+			statsMethod,
+			errors.New("Stats summary query error:forced query error"),
+		},
+		{
+			"Stats: should fail (row error)",
+			"row-error",
+			"",
+			statsMethod,
+			errors.New("Stats summary query row error:forced row error"),
+		},
+		{
+			"UpsertGoal: should fail (force update-error)",
+			"update-error",
+			"2023",
+			upsertGoalMethod,
+			errors.New("UpsertGoal error:forced collection upsert error"),
+		},
+		{
+			"GetGoal: should pass",
+			"",
+			"2023",
+			getGoalMethod,
+			nil,
+		},
+		{
+			"GetGoal: should fail (content error)",
+			"error",
+			"2023",
+			getGoalMethod,
+			errors.New("get goal content error:forced content error"),
+		},
+		{
+			"GetAllGoals: should pass",
+			"",
+			"",
+			getAllGoalsMethod,
+			nil,
+		},
+		{
+			"MigrateAll: should pass",
+			"",
+			"",
+			migrateAllMethod,
+			nil,
+		},
+		{
+			"MigrateAll: should pass (concurrent update skipped)",
+			"cas-mismatch",
+			"",
+			migrateAllMethod,
+			nil,
+		},
+		{
+			"MigrateAll: should fail (replace error)",
+			"update-error",
+			"",
+			migrateAllMethod,
+			errors.New("MigrateAll replace  error:forced collection replace error"),
+		},
+		{
+			"MigrateAll: should fail (query error)",
+			"query-error",
+			"",
+			migrateAllMethod,
+			errors.New("MigrateAll count query error:forced query error"),
+		},
+		{
+			"RenameTag: should pass",
+			"",
+			"owned",
+			renameTagMethod,
+			nil,
+		},
+		{
+			"RemoveTag: should fail (query error)",
+			"query-error",
+			"owned",
+			removeTagMethod,
+			errors.New("RemoveTag query error:forced query error"),
+		},
+		{
+			"GetShelf: should fail (collection error)",
+			"true",
+			"owned",
+			getShelfMethod,
+			errors.New("get shelf error:forced collection error"),
+		},
+		{
+			"RemoveShelf: should fail (remove error)",
+			"remove-error",
+			"owned",
+			removeShelfMethod,
+			errors.New("RemoveShelf error:forced collection remove error"),
+		},
+		{
+			"GenreCounts: should pass",
+			"",
+			"",
+			genreCountsMethod,
+			nil,
+		},
+		{
+			"GenreCounts: should fail (query error)",
+			"query-error",
+			"",
+			genreCountsMethod,
+			errors.New("GenreCounts query error:forced query error"),
+		},
+		{
+			"StatusCounts: should pass",
+			"",
+			"",
+			statusCountsMethod,
+			nil,
+		},
+		{
+			"StatusCounts: should fail (query error)",
+			"query-error",
+			"",
+			statusCountsMethod,
+			errors.New("StatusCounts query error:forced query error"),
+		},
+		{
+			"GenreBooks: should pass",
+			"",
+			"horror",
+			genreBooksMethod,
+			nil,
+		},
+		{
+			"GenreBooks: should fail (row error)",
+			"row-error",
+			"horror",
+			genreBooksMethod,
+			errors.New("GenreBooks count query row error:forced row error"),
+		},
+		{
+			"GetAllSorted: should pass",
+			"",
+			"-finished",
+			getAllSortedMethod,
+			nil,
+		},
+		{
+			"GetAllSorted: should fail (query error)",
+			"query-error",
+			"-finished",
+			getAllSortedMethod,
+			errors.New("GetAllSorted query error:forced query error"),
+		},
+		{
+			"GetAllSorted: should fail (unknown sort key)",
+			"",
+			"isbn",
+			getAllSortedMethod,
+			errors.New("GetAllSorted unknown sort key isbn"),
+		},
+		{
+			"PingCluster: should pass",
+			"",
+			"",
+			pingClusterMethod,
+			nil,
+		},
+		{
+			"PingCluster: should fail (ping error)",
+			"ping-error",
+			"",
+			pingClusterMethod,
+			errors.New("cluster ping error:forced ping error"),
+		},
+		{
+			"PingBucket: should fail (ping error)",
+			"ping-error",
+			"",
+			pingBucketMethod,
+			errors.New("bucket ping error:forced ping error"),
+		},
+		{
+			"Close: should pass",
+			"",
+			"",
+			closeMethod,
+			nil,
+		},
+		{
+			"Close: should fail (close error)",
+			"close-error",
+			"",
+			closeMethod,
+			errors.New("forced cluster close error"),
+		},
+		{
+			"RemapGenre: should fail (query error)",
+			"query-error",
+			"sci-fi",
+			remapGenreMethod,
+			errors.New("RemapGenre query error:forced query error"),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
-			mockCouchbase := &Couchbase{Bucket: &FakeBucket{Force: test.errorFlag}, Cluster: &FakeCluster{}}
+			mockCouchbase := &Couchbase{Bucket: &FakeBucket{Force: test.errorFlag}, Cluster: &FakeCluster{Force: test.errorFlag}}
 
 			var err error
 			switch test.method {
 			case upsertMethod:
-				err = mockCouchbase.Upsert(test.arg, entity.Book{})
+				err = mockCouchbase.Upsert(context.Background(), test.arg, entity.Book{})
 			case getMethod:
-				_, err = mockCouchbase.Get(test.arg)
+				_, err = mockCouchbase.Get(context.Background(), test.arg)
 			case getAllMethod:
-				_, err = mockCouchbase.GetAll()
+				_, err = mockCouchbase.GetAll(context.Background())
 			case newFakeCouchbaseStorage:
 				_, err = NewFakeCouchbaseStorage("")
 			case newCouchbaseStorage:
-				_, err = NewCouchbaseStorage()
+				_, err = NewCouchbaseStorage(config.Default().Couchbase)
+			case upsertGoalMethod:
+				err = mockCouchbase.UpsertGoal(context.Background(), test.arg, entity.Goal{})
+			case getGoalMethod:
+				_, err = mockCouchbase.GetGoal(context.Background(), test.arg)
+			case getAllGoalsMethod:
+				_, err = mockCouchbase.GetAllGoals(context.Background())
+			case renameTagMethod:
+				_, err = mockCouchbase.RenameTag(context.Background(), test.arg, "lent out")
+			case removeTagMethod:
+				_, err = mockCouchbase.RemoveTag(context.Background(), test.arg)
+			case getShelfMethod:
+				_, err = mockCouchbase.GetShelf(context.Background(), test.arg)
+			case removeShelfMethod:
+				err = mockCouchbase.RemoveShelf(context.Background(), test.arg)
+			case genreCountsMethod:
+				_, err = mockCouchbase.GenreCounts(context.Background())
+			case statusCountsMethod:
+				_, err = mockCouchbase.StatusCounts(context.Background())
+			case genreBooksMethod:
+				_, _, err = mockCouchbase.GenreBooks(context.Background(), []string{test.arg}, entity.Page{Limit: 10})
+			case getAllSortedMethod:
+				_, err = mockCouchbase.GetAllSorted(context.Background(), entity.BookSort{{Field: strings.TrimPrefix(test.arg, "-"), Descending: strings.HasPrefix(test.arg, "-")}})
+			case pingClusterMethod:
+				err = mockCouchbase.PingCluster(time.Second)
+			case pingBucketMethod:
+				err = mockCouchbase.PingBucket(time.Second)
+			case closeMethod:
+				err = mockCouchbase.Close()
+			case remapGenreMethod:
+				_, err = mockCouchbase.RemapGenre(context.Background(), []string{test.arg}, "Science Fiction")
+			case migrateAllMethod:
+				_, err = mockCouchbase.MigrateAll(context.Background(), "", 1, nil)
+			case statsMethod:
+				_, err = mockCouchbase.Stats(context.Background(), entity.StatsFilter{From: 1, To: 2})
 			}
 
 			if err == nil && test.expected != err {
@@ -127,3 +400,205 @@ func TestCouchbaseImpl(t *testing.T) {
 		})
 	}
 }
+
+func TestMigrations(t *testing.T) {
+	t.Run("Get: legacy document is upgraded on read", func(t *testing.T) {
+		mockCouchbase := &Couchbase{Bucket: &FakeBucket{Force: "legacy-document"}, Cluster: &FakeCluster{}}
+		book, err := mockCouchbase.Get(context.Background(), "isbn-0")
+		if err != nil {
+			t.Fatalf("Should not fail: found error %v ", err)
+		}
+		if book.SchemaVersion != CurrentSchemaVersion || book.Status != "FINISHED" {
+			t.Errorf("Get legacy document got (version %d, status %s) wanted (version %d, status FINISHED)", book.SchemaVersion, book.Status, CurrentSchemaVersion)
+		}
+		if len(book.Authors) != 1 || book.Authors[0] != (entity.Contributor{Name: "author-0", Role: entity.RoleAuthor}) {
+			t.Errorf("Get legacy document authors got (%+v)", book.Authors)
+		}
+	})
+
+	t.Run("migrateDocument: current document is untouched", func(t *testing.T) {
+		doc := map[string]interface{}{"status": "finished", schemaVersionField: float64(CurrentSchemaVersion)}
+		changed, err := migrateDocument(doc)
+		if err != nil || changed || doc["status"] != "finished" {
+			t.Errorf("migrateDocument got (changed %v, err %v, doc %v) wanted an untouched document", changed, err, doc)
+		}
+	})
+
+	t.Run("stampBook: written books have the shape of the current version", func(t *testing.T) {
+		book := entity.Book{Author: "author-0", Status: "finished", Format: "ebook"}
+		for _, value := range []interface{}{book, &book} {
+			stamped, ok := stampBook(value).(entity.Book)
+			if !ok || stamped.SchemaVersion != CurrentSchemaVersion || stamped.Status != "FINISHED" ||
+				stamped.Format != entity.FormatEbook || len(stamped.Authors) != 1 {
+				t.Errorf("stampBook got (%+v) wanted version %d with upper case status and format", stamped, CurrentSchemaVersion)
+			}
+		}
+		if goal := (entity.Goal{Year: 2023}); stampBook(goal) != goal {
+			t.Errorf("stampBook got a changed goal wanted it as is")
+		}
+	})
+
+	t.Run("migrateDocument: resumes from the stored version", func(t *testing.T) {
+		doc := map[string]interface{}{"author": "author-0", "format": "ebook", schemaVersionField: float64(1)}
+		changed, err := migrateDocument(doc)
+		if err != nil || !changed || doc["format"] != entity.FormatEbook || doc["authors"] != nil {
+			t.Errorf("migrateDocument got (changed %v, err %v, doc %v) wanted only version 2 applied", changed, err, doc)
+		}
+	})
+
+	t.Run("MigrateAll: progress is reported", func(t *testing.T) {
+		mockCouchbase := &Couchbase{Bucket: &FakeBucket{}, Cluster: &FakeCluster{}}
+		reports := 0
+		progress, err := mockCouchbase.MigrateAll(context.Background(), "", 100, func(MigrationProgress) { reports++ })
+		if err != nil || reports != 1 || progress.Migrated != 2 || progress.Pending != 2 {
+			t.Errorf("MigrateAll got (progress %+v, reports %d, err %v) wanted 2 migrated in 1 report", progress, reports, err)
+		}
+	})
+}
+
+func TestOrderBy(t *testing.T) {
+	keys, err := entity.ParseBookSort("-finished, Rating")
+	if err != nil {
+		t.Fatalf("ParseBookSort error %s", err.Error())
+	}
+	got, err := orderBy(keys)
+	want := "ifmissingornull(b.finished, 0) desc, ifmissingornull(b.rating, 0), b.isbn"
+	if err != nil || got != want {
+		t.Errorf("orderBy got (%s, %v) wanted (%s)", got, err, want)
+	}
+
+	// the string keys are collated by the service
+	if _, err = orderBy(entity.BookSort{{Field: entity.SortTitle}}); err == nil {
+		t.Errorf("orderBy(title) got no error wanted unknown sort key title")
+	}
+}
+
+func TestRequestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "BookTracker.ListBooks")
+	tracer := NewRequestTracer()
+	query := tracer.RequestSpan(parentSpan(ctx).Context(), "query")
+	query.SetAttribute("db.statement", "select raw b from book b")
+	query.SetAttribute("db.couchbase.retries", uint32(2))
+	dispatch := tracer.RequestSpan(query.Context(), "dispatch_to_server")
+	dispatch.End()
+	query.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("TestRequestTracer expected(3) spans got (%d)", len(spans))
+	}
+	dispatchSpan, querySpan := spans[0], spans[1]
+	if querySpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("TestRequestTracer query expected parent(%s) got (%s)", parent.SpanContext().SpanID(), querySpan.Parent().SpanID())
+	}
+	if dispatchSpan.Parent().SpanID() != querySpan.SpanContext().SpanID() {
+		t.Errorf("TestRequestTracer dispatch expected parent(%s) got (%s)", querySpan.SpanContext().SpanID(), dispatchSpan.Parent().SpanID())
+	}
+	want := []attribute.KeyValue{attribute.String("db.statement", "select raw b from book b"), attribute.Int64("db.couchbase.retries", 2)}
+	for i, kv := range want {
+		if got := querySpan.Attributes(); len(got) != len(want) || got[i] != kv {
+			t.Errorf("TestRequestTracer query expected attributes(%v) got (%v)", want, got)
+			break
+		}
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		testName  string
+		errorFlag string
+		ctx       context.Context
+		method    string
+		expected  error
+	}{
+		{"Get: should fail(deadline exceeded)", "", expired, getMethod, entity.TimeoutError{Message: "get error:operation timed out"}},
+		{"Get: should fail(canceled)", "", canceled, getMethod, entity.CanceledError{Message: "get error:operation canceled"}},
+		{"GetAllSorted: should fail(gocb timeout)", "timeout", context.Background(), getAllSortedMethod, entity.TimeoutError{Message: "GetAllSorted query error:operation timed out"}},
+		{"Upsert: should fail(deadline exceeded)", "", expired, upsertMethod, entity.TimeoutError{Message: "Upsert error:operation timed out"}},
+		{"RemoveShelf: should fail(canceled)", "", canceled, removeShelfMethod, entity.CanceledError{Message: "RemoveShelf error:operation canceled"}},
+		{"Stats: should fail(gocb timeout)", "timeout", context.Background(), statsMethod, entity.TimeoutError{Message: "Stats summary query error:operation timed out"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			cbStorage, _ := NewFakeCouchbaseStorage(test.errorFlag)
+			var err error
+			switch test.method {
+			case getMethod:
+				_, err = cbStorage.Get(test.ctx, "ISBN-01")
+			case getAllSortedMethod:
+				_, err = cbStorage.GetAllSorted(test.ctx, entity.BookSort{{Field: entity.SortFinished}})
+			case upsertMethod:
+				err = cbStorage.Upsert(test.ctx, "ISBN-01", entity.Book{})
+			case removeShelfMethod:
+				err = cbStorage.RemoveShelf(test.ctx, "favourites")
+			case statsMethod:
+				_, err = cbStorage.Stats(test.ctx, entity.StatsFilter{})
+			}
+			if err == nil || reflect.TypeOf(err) != reflect.TypeOf(test.expected) || err.Error() != test.expected.Error() {
+				t.Errorf("%s expected(%T %v) got (%T %v)", test.testName, test.expected, test.expected, err, err)
+			}
+		})
+	}
+}
+
+func TestTimeLeft(t *testing.T) {
+	if got := timeLeft(context.Background()); got != 0 {
+		t.Errorf("TestTimeLeft without deadline expected(0) got (%v)", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if got := timeLeft(ctx); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("TestTimeLeft expected about a minute got (%v)", got)
+	}
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	if got := timeLeft(expired); got != time.Nanosecond {
+		t.Errorf("TestTimeLeft expired expected(1ns) got (%v)", got)
+	}
+}
+
+func TestClusterOptions(t *testing.T) {
+	files := certstest.Write(t, t.TempDir())
+
+	t.Run("ClusterOptions: should pass(user and password)", func(t *testing.T) {
+		opts, err := clusterOptions(config.Couchbase{Host: "couchbase://localhost", User: "reader", Password: "secret"})
+		if err != nil {
+			t.Fatalf("ClusterOptions: should pass(user and password) expected(nil) got (%v)", err)
+		}
+		if auth, ok := opts.Authenticator.(gocb.PasswordAuthenticator); !ok || auth.Username != "reader" || opts.SecurityConfig.TLSRootCAs != nil {
+			t.Errorf("ClusterOptions: should pass(user and password) expected a password authenticator got (%T)", opts.Authenticator)
+		}
+	})
+
+	t.Run("ClusterOptions: should pass(client certificate and CA bundle)", func(t *testing.T) {
+		opts, err := clusterOptions(config.Couchbase{Host: "couchbases://localhost", CAFile: files.CAFile, CertFile: files.ClientCertFile, KeyFile: files.ClientKeyFile})
+		if err != nil {
+			t.Fatalf("ClusterOptions: should pass(client certificate and CA bundle) expected(nil) got (%v)", err)
+		}
+		cert, err := opts.Authenticator.Certificate(gocb.AuthCertRequest{})
+		if err != nil || cert == nil || opts.Authenticator.SupportsNonTLS() {
+			t.Errorf("ClusterOptions: should pass(client certificate and CA bundle) expected a TLS only client certificate got (%v, %v)", cert, err)
+		}
+		if opts.SecurityConfig.TLSRootCAs == nil {
+			t.Errorf("ClusterOptions: should pass(client certificate and CA bundle) expected the CA bundle")
+		}
+	})
+
+	t.Run("ClusterOptions: should fail(invalid CA bundle)", func(t *testing.T) {
+		if _, err := clusterOptions(config.Couchbase{Host: "couchbases://localhost", CAFile: files.ClientKeyFile}); err == nil {
+			t.Errorf("ClusterOptions: should fail(invalid CA bundle) expected an error")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+const defaultMigrationBatchSize = 100
+
+// MigrationProgress is reported after every batch. LastID is the checkpoint to resume from
+type MigrationProgress struct {
+	Pending  int
+	Scanned  int
+	Migrated int
+	Skipped  int
+	LastID   string
+}
+
+type migrationRow struct {
+	ID  string                 `json:"id"`
+	Cas gocb.Cas               `json:"cas"`
+	Doc map[string]interface{} `json:"doc"`
+}
+
+// MigrateAll - rewrites the book documents below the current schema version in batches ordered by document id,
+// starting after the `after` id(empty to start from the beginning). Documents changed concurrently are skipped:
+// the service writes them at the current version anyway.
+func (c *Couchbase) MigrateAll(ctx context.Context, after string, batchSize int, report func(MigrationProgress)) (MigrationProgress, error) {
+	progress := MigrationProgress{LastID: after}
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	pending, err := queryRows[int](ctx, c, "select raw count(1) from book b "+
+		"where meta(b).id > $after and ifmissingornull(b.schema_version, 0) < $version",
+		map[string]interface{}{"after": after, "version": CurrentSchemaVersion})
+	if err != nil {
+		return progress, storageError("MigrateAll count ", err)
+	}
+	if len(pending) > 0 {
+		progress.Pending = pending[0]
+	}
+
+	collection := c.Bucket.Scope(defaultScope).Collection(bookCollection)
+	for {
+		before := progress.LastID
+		rows, err := queryRows[migrationRow](ctx, c, "select meta(b).id as id, meta(b).cas as cas, b as doc from book b "+
+			"where meta(b).id > $after and ifmissingornull(b.schema_version, 0) < $version order by meta(b).id limit $limit",
+			map[string]interface{}{"after": progress.LastID, "version": CurrentSchemaVersion, "limit": batchSize})
+		if err != nil {
+			return progress, storageError("MigrateAll batch ", err)
+		}
+
+		for _, row := range rows {
+			if row.Doc == nil {
+				row.Doc = map[string]interface{}{}
+			}
+			if _, err = migrateDocument(row.Doc); err != nil {
+				return progress, storageError("MigrateAll document "+row.ID+" ", err)
+			}
+			_, err = collection.Replace(row.ID, row.Doc, replaceOptions(ctx, row.Cas))
+			switch {
+			case err == nil:
+				progress.Migrated++
+			case errors.Is(err, gocb.ErrCasMismatch) || errors.Is(err, gocb.ErrDocumentNotFound):
+				progress.Skipped++
+			default:
+				return progress, storageError("MigrateAll replace "+row.ID+" error:", err)
+			}
+			progress.Scanned++
+			progress.LastID = row.ID
+		}
+
+		if report != nil {
+			report(progress)
+		}
+		if len(rows) < batchSize || progress.LastID == before {
+			return progress, nil
+		}
+	}
+}
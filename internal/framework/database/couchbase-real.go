@@ -3,10 +3,12 @@
 package database
 
 import (
+	"fmt"
 	"github.com/couchbase/gocb/v2"
-	"os"
+	"time"
 
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/adapter/repository"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
 )
 
 type Couchbase struct {
@@ -14,21 +16,61 @@ type Couchbase struct {
 	Cluster *gocb.Cluster
 }
 
-func NewCouchbaseStorage() (repository.Storage, error) {
-	opts := gocb.ClusterOptions{
-		Username: os.Getenv("COUCHBASE_USER"),
-		Password: os.Getenv("COUCHBASE_PASSWORD"),
+func NewCouchbaseStorage(cfg config.Couchbase) (repository.Storage, error) {
+	opts, err := clusterOptions(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	if os.Getenv("ENABLE_DB_VERBOSE_LOGGING") == "true" {
+	if cfg.VerboseLogging {
 		gocb.SetLogger(gocb.VerboseStdioLogger())
 	}
-	cluster, err := gocb.Connect(os.Getenv("COUCHBASE_HOST"), opts)
+	cluster, err := gocb.Connect(cfg.Host, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	bucket := cluster.Bucket(os.Getenv("COUCHBASE_BUCKET"))
+	bucket := cluster.Bucket(cfg.Bucket)
 
 	return &Couchbase{Bucket: bucket, Cluster: cluster}, nil
 }
+
+// Close - closes the cluster connection once the server has drained
+func (c *Couchbase) Close() error {
+	return c.Cluster.Close(nil)
+}
+
+// PingCluster - pings the query service of the cluster, every listing goes through it
+func (c *Couchbase) PingCluster(timeout time.Duration) error {
+	result, err := c.Cluster.Ping(&gocb.PingOptions{Timeout: timeout, ServiceTypes: []gocb.ServiceType{gocb.ServiceTypeQuery}})
+	if err != nil {
+		return fmt.Errorf("cluster ping error:%s", err.Error())
+	}
+	return pingError("cluster", result)
+}
+
+// PingBucket - pings the key value service of the bucket
+func (c *Couchbase) PingBucket(timeout time.Duration) error {
+	result, err := c.Bucket.Ping(&gocb.PingOptions{Timeout: timeout, ServiceTypes: []gocb.ServiceType{gocb.ServiceTypeKeyValue}})
+	if err != nil {
+		return fmt.Errorf("bucket ping error:%s", err.Error())
+	}
+	return pingError("bucket", result)
+}
+
+// pingError - the first endpoint that did not answer, an error as well when no endpoint was pinged at all
+func pingError(target string, result *gocb.PingResult) error {
+	pinged := 0
+	for _, reports := range result.Services {
+		for _, report := range reports {
+			if report.State != gocb.PingStateOk {
+				return fmt.Errorf("%s ping error:endpoint %s %s", target, report.Remote, report.Error)
+			}
+			pinged++
+		}
+	}
+	if pinged == 0 {
+		return fmt.Errorf("%s ping error:no endpoint available", target)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const (
+	reviewCollection = "review"
+	noteCollection   = "note"
+)
+
+// GetReview - wrapper to get the review of a book
+func (c *Couchbase) GetReview(ctx context.Context, isbn string) (*entity.Review, error) {
+	var review entity.Review
+
+	collection := c.Bucket.Scope(defaultScope).Collection(reviewCollection)
+	result, err := collection.Get(isbn, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get review error:", err)
+	}
+	err = result.Content(&review)
+	if err != nil {
+		return nil, storageError("get review content error:", err)
+	}
+	return &review, nil
+}
+
+// GetAllReviews - wrapper to list the reviews of all books
+func (c *Couchbase) GetAllReviews(ctx context.Context) ([]entity.Review, error) {
+	reviews, err := queryRows[entity.Review](ctx, c, "select raw r from review r order by r.isbn", nil)
+	if err != nil {
+		return nil, storageError("GetAllReviews ", err)
+	}
+	return reviews, nil
+}
+
+// UpsertReview :  wrapper to create or update the review of a book
+func (c *Couchbase) UpsertReview(ctx context.Context, isbn string, value interface{}) error {
+	opts := upsertOptions(ctx)
+	collection := c.Bucket.Scope(defaultScope).Collection(reviewCollection)
+	_, err := collection.Upsert(isbn, value, opts)
+	if err != nil {
+		return storageError("UpsertReview error:", err)
+	}
+	return nil
+}
+
+// RemoveReview :  wrapper to delete the review of a book
+func (c *Couchbase) RemoveReview(ctx context.Context, isbn string) error {
+	collection := c.Bucket.Scope(defaultScope).Collection(reviewCollection)
+	_, err := collection.Remove(isbn, removeOptions(ctx))
+	if err != nil {
+		return storageError("RemoveReview error:", err)
+	}
+	return nil
+}
+
+// GetNote - wrapper to get a note
+func (c *Couchbase) GetNote(ctx context.Context, id string) (*entity.Note, error) {
+	var note entity.Note
+
+	collection := c.Bucket.Scope(defaultScope).Collection(noteCollection)
+	result, err := collection.Get(id, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get note error:", err)
+	}
+	err = result.Content(&note)
+	if err != nil {
+		return nil, storageError("get note content error:", err)
+	}
+	return &note, nil
+}
+
+// GetNotes - wrapper to list the notes of a book ordered by page
+func (c *Couchbase) GetNotes(ctx context.Context, isbn string) ([]entity.Note, error) {
+	notes, err := queryRows[entity.Note](ctx, c, "select raw n from note n where n.isbn = $isbn order by n.page, n.created",
+		map[string]interface{}{"isbn": isbn})
+	if err != nil {
+		return nil, storageError("GetNotes ", err)
+	}
+	return notes, nil
+}
+
+// GetAllNotes - wrapper to list the notes of all books
+func (c *Couchbase) GetAllNotes(ctx context.Context) ([]entity.Note, error) {
+	notes, err := queryRows[entity.Note](ctx, c, "select raw n from note n order by n.isbn, n.page, n.created", nil)
+	if err != nil {
+		return nil, storageError("GetAllNotes ", err)
+	}
+	return notes, nil
+}
+
+// UpsertNote :  wrapper to create or update a note
+func (c *Couchbase) UpsertNote(ctx context.Context, id string, value interface{}) error {
+	opts := upsertOptions(ctx)
+	collection := c.Bucket.Scope(defaultScope).Collection(noteCollection)
+	_, err := collection.Upsert(id, value, opts)
+	if err != nil {
+		return storageError("UpsertNote error:", err)
+	}
+	return nil
+}
+
+// RemoveNote :  wrapper to delete a note
+func (c *Couchbase) RemoveNote(ctx context.Context, id string) error {
+	collection := c.Bucket.Scope(defaultScope).Collection(noteCollection)
+	_, err := collection.Remove(id, removeOptions(ctx))
+	if err != nil {
+		return storageError("RemoveNote error:", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const (
+	shelfCollection = "shelf"
+	systemUser      = "SYSTEM"
+)
+
+// GetShelf - wrapper to get a shelf resource
+func (c *Couchbase) GetShelf(ctx context.Context, key string) (*entity.Shelf, error) {
+	var shelf entity.Shelf
+
+	collection := c.Bucket.Scope(defaultScope).Collection(shelfCollection)
+	result, err := collection.Get(key, getOptions(ctx))
+	if err != nil {
+		return nil, storageError("get shelf error:", err)
+	}
+	err = result.Content(&shelf)
+	if err != nil {
+		return nil, storageError("get shelf content error:", err)
+	}
+	return &shelf, nil
+}
+
+// GetAllShelves - wrapper to list all shelves ordered by name
+func (c *Couchbase) GetAllShelves(ctx context.Context) ([]entity.Shelf, error) {
+	shelves, err := queryRows[entity.Shelf](ctx, c, "select raw s from shelf s order by lower(s.name)", nil)
+	if err != nil {
+		return nil, storageError("GetAllShelves ", err)
+	}
+	return shelves, nil
+}
+
+// UpsertShelf :  wrapper to create or update a shelf resource
+func (c *Couchbase) UpsertShelf(ctx context.Context, key string, value interface{}) error {
+	opts := upsertOptions(ctx)
+	collection := c.Bucket.Scope(defaultScope).Collection(shelfCollection)
+	_, err := collection.Upsert(key, value, opts)
+	if err != nil {
+		return storageError("UpsertShelf error:", err)
+	}
+	return nil
+}
+
+// RemoveShelf :  wrapper to delete a shelf resource
+func (c *Couchbase) RemoveShelf(ctx context.Context, key string) error {
+	collection := c.Bucket.Scope(defaultScope).Collection(shelfCollection)
+	_, err := collection.Remove(key, removeOptions(ctx))
+	if err != nil {
+		return storageError("RemoveShelf error:", err)
+	}
+	return nil
+}
+
+// renamedTags - the tags of the book with $from(lower case) renamed to $to
+const renamedTags = "array case when lower(t) = $from then $to else t end for t in b.tags end"
+
+// RenameTag - renames the tag(case-insensitive) on every book carrying it. Returns the number of books updated.
+// The tags keep their order and, as entity.Book.NormalizeTags does, the first spelling of a case-insensitive duplicate
+func (c *Couchbase) RenameTag(ctx context.Context, from, to string) (int, error) {
+	ids, err := queryRows[string](ctx, c, "update book b "+
+		"set b.tags = array t for i:t in ("+renamedTags+") when every u in ("+renamedTags+")[0:i] satisfies lower(u) != lower(t) end end, "+
+		"b.updated = $now, b.updated_by = $user "+
+		"where any t in b.tags satisfies lower(t) = $from end returning raw meta(b).id",
+		map[string]interface{}{"from": strings.ToLower(from), "to": to, "now": time.Now().Unix(), "user": systemUser})
+	if err != nil {
+		return 0, storageError("RenameTag ", err)
+	}
+	return len(ids), nil
+}
+
+// RemoveTag - removes the tag(case-insensitive) from every book carrying it. Returns the number of books updated
+func (c *Couchbase) RemoveTag(ctx context.Context, tag string) (int, error) {
+	ids, err := queryRows[string](ctx, c, "update book b "+
+		"set b.tags = array t for t in b.tags when lower(t) != $tag end, b.updated = $now, b.updated_by = $user "+
+		"where any t in b.tags satisfies lower(t) = $tag end returning raw meta(b).id",
+		map[string]interface{}{"tag": strings.ToLower(tag), "now": time.Now().Unix(), "user": systemUser})
+	if err != nil {
+		return 0, storageError("RemoveTag ", err)
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+// sortExpressions - the numeric fields only. N1QL has no collations, the string fields are ordered by the service(see
+// entity.BookSort.Collated) so that the order does not depend on the repository. Missing values order like the zero
+// values the service sorts them as
+var sortExpressions = map[string]string{
+	entity.SortCreated:  "ifmissingornull(b.created, 0)",
+	entity.SortUpdated:  "ifmissingornull(b.updated, 0)",
+	entity.SortStarted:  "ifmissingornull(b.started, 0)",
+	entity.SortFinished: "ifmissingornull(b.finished, 0)",
+	entity.SortRating:   "ifmissingornull(b.rating, 0)",
+}
+
+// GetAllSorted - lists all book resources ordered by the numeric sort keys, then by ISBN
+func (c *Couchbase) GetAllSorted(ctx context.Context, keys entity.BookSort) ([]entity.Book, error) {
+	orderBy, err := orderBy(keys)
+	if err != nil {
+		return nil, storageError("GetAllSorted ", err)
+	}
+	docs, err := queryRows[map[string]interface{}](ctx, c, "select raw b from book b order by "+orderBy, nil)
+	if err != nil {
+		return nil, storageError("GetAllSorted ", err)
+	}
+
+	books := make([]entity.Book, 0, len(docs))
+	for _, doc := range docs {
+		book, err := decodeBook(doc)
+		if err != nil {
+			return nil, storageError("GetAllSorted migration error:", err)
+		}
+		books = append(books, *book)
+	}
+	return books, nil
+}
+
+// orderBy - the ORDER BY terms of the sort keys. Only known fields are accepted, they are not parameters
+func orderBy(keys entity.BookSort) (string, error) {
+	terms := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		expression, ok := sortExpressions[key.Field]
+		if !ok {
+			return "", fmt.Errorf("unknown sort key %s", key.Field)
+		}
+		if key.Descending {
+			expression += " desc"
+		}
+		terms = append(terms, expression)
+	}
+	return strings.Join(append(terms, "b.isbn"), ", "), nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const (
+	secondsPerDay = 86400
+	monthFormat   = "1111-11"
+	yearFormat    = "1111"
+)
+
+type statsSummaryRow struct {
+	BooksFinished int      `json:"books_finished"`
+	AverageDays   *float64 `json:"average_days"`
+	PagesRead     int      `json:"pages_read"`
+}
+
+type readDurationRow struct {
+	ISBN  string  `json:"isbn"`
+	Title string  `json:"title"`
+	Days  float64 `json:"days"`
+}
+
+// Stats - computes the reading statistics for books finished within the filter range using N1QL aggregates
+func (c *Couchbase) Stats(ctx context.Context, filter entity.StatsFilter) (*entity.ReadingStats, error) {
+	where, params := statsWhereClause(filter)
+	stats := &entity.ReadingStats{}
+
+	summary, err := queryRows[statsSummaryRow](ctx, c, fmt.Sprintf("select count(1) as books_finished, "+
+		"avg(case when b.started > 0 and b.finished >= b.started then (b.finished - b.started) / %d end) as average_days, "+
+		"sum(case when b.page_count > 0 then b.page_count else ifmissingornull(b.bookmark, 0) end) as pages_read "+
+		"from book b where %s", secondsPerDay, where), params)
+	if err != nil {
+		return nil, storageError("Stats summary ", err)
+	}
+	if len(summary) > 0 {
+		stats.BooksFinished = summary[0].BooksFinished
+		stats.PagesRead = summary[0].PagesRead
+		if summary[0].AverageDays != nil {
+			stats.AverageDaysToFinish = *summary[0].AverageDays
+		}
+	}
+
+	if stats.FinishedPerMonth, err = c.finishedPerPeriod(ctx, monthFormat, where, params); err != nil {
+		return nil, err
+	}
+	if stats.FinishedPerYear, err = c.finishedPerPeriod(ctx, yearFormat, where, params); err != nil {
+		return nil, err
+	}
+	if stats.Genres, err = c.countBy(ctx, "b.genre", where, params); err != nil {
+		return nil, err
+	}
+	if stats.Authors, err = c.countBy(ctx, "b.author", where, params); err != nil {
+		return nil, err
+	}
+	if stats.LongestRead, err = c.readDuration(ctx, "desc", where, params); err != nil {
+		return nil, err
+	}
+	if stats.ShortestRead, err = c.readDuration(ctx, "asc", where, params); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (c *Couchbase) finishedPerPeriod(ctx context.Context, format, where string, params map[string]interface{}) ([]entity.PeriodCount, error) {
+	query := fmt.Sprintf("select period, count(1) as `count` from book b "+
+		"let period = millis_to_utc(b.finished * 1000, \"%s\") where %s group by period order by period", format, where)
+	periods, err := queryRows[entity.PeriodCount](ctx, c, query, params)
+	if err != nil {
+		return nil, storageError("Stats per period ", err)
+	}
+	return periods, nil
+}
+
+func (c *Couchbase) countBy(ctx context.Context, field, where string, params map[string]interface{}) ([]entity.NamedCount, error) {
+	query := fmt.Sprintf("select %[1]s as name, count(1) as `count` from book b where %[2]s "+
+		"group by %[1]s order by count(1) desc, name", field, where)
+	counts, err := queryRows[entity.NamedCount](ctx, c, query, params)
+	if err != nil {
+		return nil, storageError("Stats count by "+field+" ", err)
+	}
+	return counts, nil
+}
+
+func (c *Couchbase) readDuration(ctx context.Context, direction, where string, params map[string]interface{}) (*entity.ReadDuration, error) {
+	query := fmt.Sprintf("select b.isbn, b.title, (b.finished - b.started) / %d as days from book b "+
+		"where %s and b.started > 0 and b.finished >= b.started order by b.finished - b.started %s limit 1",
+		secondsPerDay, where, direction)
+	rows, err := queryRows[readDurationRow](ctx, c, query, params)
+	if err != nil {
+		return nil, storageError("Stats read duration ", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &entity.ReadDuration{ISBN: rows[0].ISBN, Title: rows[0].Title, Days: rows[0].Days}, nil
+}
+
+func statsWhereClause(filter entity.StatsFilter) (string, map[string]interface{}) {
+	where := "b.finished > 0"
+	params := map[string]interface{}{}
+	if filter.From > 0 {
+		where += " and b.finished >= $from"
+		params["from"] = filter.From
+	}
+	if filter.To > 0 {
+		where += " and b.finished <= $to"
+		params["to"] = filter.To
+	}
+	return where, params
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"crypto/tls"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/certs"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/config"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// certificateAuthenticator - the client certificate authentication of gocb, with the certificate loaded again when its
+// files change so that new connections use the renewed one
+type certificateAuthenticator struct {
+	gocb.CertificateAuthenticator
+	reloader *certs.Reloader
+}
+
+func (a certificateAuthenticator) Certificate(gocb.AuthCertRequest) (*tls.Certificate, error) {
+	return a.reloader.Certificate(), nil
+}
+
+// clusterOptions - the user and password or, over couchbases://, the client certificate, and the CA bundle the cluster
+// certificates are verified against(the system roots when it is not set)
+func clusterOptions(cfg config.Couchbase) (gocb.ClusterOptions, error) {
+	opts := gocb.ClusterOptions{Tracer: NewRequestTracer()}
+	if cfg.ClientCertificate() {
+		reloader, err := certs.NewReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Authenticator = certificateAuthenticator{reloader: reloader}
+	} else {
+		opts.Authenticator = gocb.PasswordAuthenticator{Username: cfg.User, Password: cfg.Password}
+	}
+	if cfg.CAFile != "" {
+		pool, err := certs.CertPool(cfg.CAFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.SecurityConfig.TLSRootCAs = pool
+	}
+	return opts, nil
+}
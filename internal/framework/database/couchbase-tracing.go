@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/anushasankaranarayanan/book-tracker-service/internal/framework/database"
+
+// requestTracer - the gocb request tracer hooks on top of OpenTelemetry. gocb hands back the context of the parent
+// span(see parentSpan) so that its spans(query, get, upsert, dispatch to server...) nest under the service spans
+type requestTracer struct{}
+
+// requestSpan - Context is the context.Context carrying the span, which is what the child spans start from
+type requestSpan struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+// NewRequestTracer - the gocb.ClusterOptions Tracer recording to the global tracer provider
+func NewRequestTracer() gocb.RequestTracer {
+	return requestTracer{}
+}
+
+func (requestTracer) RequestSpan(parent gocb.RequestSpanContext, operation string) gocb.RequestSpan {
+	ctx, ok := parent.(context.Context)
+	if !ok || ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	return requestSpan{ctx: ctx, span: span}
+}
+
+func (s requestSpan) End() {
+	s.span.End()
+}
+
+func (s requestSpan) Context() gocb.RequestSpanContext {
+	return s.ctx
+}
+
+func (s requestSpan) AddEvent(name string, timestamp time.Time) {
+	s.span.AddEvent(name, trace.WithTimestamp(timestamp))
+}
+
+func (s requestSpan) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case uint32:
+		s.span.SetAttributes(attribute.Int64(key, int64(v)))
+	case uint64:
+		s.span.SetAttributes(attribute.Int64(key, int64(v)))
+	case float64:
+		s.span.SetAttributes(attribute.Float64(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// parentSpan - the span of the context as the ParentSpan of gocb options. gocb never ends it
+func parentSpan(ctx context.Context) gocb.RequestSpan {
+	return requestSpan{ctx: ctx, span: trace.SpanFromContext(ctx)}
+}
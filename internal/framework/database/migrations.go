@@ -0,0 +1,119 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const schemaVersionField = "schema_version"
+
+// Migration upgrades a raw book document from Version-1 to Version
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(map[string]interface{}) error
+}
+
+// migrations - append only. Never reorder or change a released migration, add a new one instead
+var migrations = []Migration{
+	{Version: 1, Description: "authors list from the single author string", Up: migrateAuthors},
+	{Version: 2, Description: "upper case status and format", Up: migrateUpperCase},
+}
+
+// CurrentSchemaVersion is the version stamped on every book written by the service
+var CurrentSchemaVersion = migrations[len(migrations)-1].Version
+
+// stampBook - a book(or book pointer) in the shape the migrations produce, stamped with the current schema version so
+// that it is not migrated again on read. Other documents are returned as is
+func stampBook(value interface{}) interface{} {
+	var book entity.Book
+	switch v := value.(type) {
+	case entity.Book:
+		book = v
+	case *entity.Book:
+		if v == nil {
+			return value
+		}
+		book = *v
+	default:
+		return value
+	}
+	// version 1
+	book.NormalizeAuthors()
+	// version 2
+	book.NormalizeCase()
+	book.SchemaVersion = CurrentSchemaVersion
+	return book
+}
+
+// migrateDocument - runs the pending migrations in order. Returns true when the document was changed
+func migrateDocument(doc map[string]interface{}) (bool, error) {
+	version := documentVersion(doc)
+	if version >= CurrentSchemaVersion {
+		return false, nil
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+		if err := migration.Up(doc); err != nil {
+			return false, fmt.Errorf("migration %d(%s) error:%s", migration.Version, migration.Description, err.Error())
+		}
+		doc[schemaVersionField] = migration.Version
+	}
+	return true, nil
+}
+
+// decodeBook - upgrades the raw document and decodes it into a book
+func decodeBook(doc map[string]interface{}) (*entity.Book, error) {
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	if _, err := migrateDocument(doc); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var book entity.Book
+	if err = json.Unmarshal(data, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+func documentVersion(doc map[string]interface{}) int {
+	// json numbers decode to float64
+	if version, ok := doc[schemaVersionField].(float64); ok {
+		return int(version)
+	}
+	if version, ok := doc[schemaVersionField].(int); ok {
+		return version
+	}
+	return 0
+}
+
+func migrateAuthors(doc map[string]interface{}) error {
+	if _, ok := doc["authors"]; ok {
+		return nil
+	}
+	if author, ok := doc["author"].(string); ok && author != "" {
+		doc["authors"] = []interface{}{map[string]interface{}{"name": author, "role": entity.RoleAuthor}}
+	}
+	return nil
+}
+
+func migrateUpperCase(doc map[string]interface{}) error {
+	for _, field := range []string{"status", "format"} {
+		if value, ok := doc[field].(string); ok {
+			doc[field] = strings.ToUpper(value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader - the request id header, propagated when the caller sends one and generated otherwise
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// the fields shared by every log line, so that logs can be searched the same way whichever package wrote them
+const (
+	FieldRequestID   = "request_id"
+	FieldTraceID     = "trace_id"
+	FieldMethod      = "method"
+	FieldRoute       = "route"
+	FieldPath        = "path"
+	FieldStatus      = "status"
+	FieldLatency     = "latency_ms"
+	FieldClientIP    = "client_ip"
+	FieldISBN        = "isbn"
+	FieldGenre       = "genre"
+	FieldShelf       = "shelf"
+	FieldNoteID      = "note_id"
+	FieldHighlightID = "highlight_id"
+	FieldYear        = "year"
+	FieldGroupBy     = "by"
+	FieldPayload     = "payload"
+	FieldReason      = "reason"
+)
+
+type loggerKey struct{}
+
+// Init - JSON output on the standard logger, at the level(trace, debug, info, warn, error; default info)
+func Init(level string) error {
+	logrus.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	logrus.SetOutput(os.Stdout)
+
+	parsed := logrus.InfoLevel
+	if level != "" {
+		var err error
+		if parsed, err = logrus.ParseLevel(level); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %s", level)
+		}
+	}
+	logrus.SetLevel(parsed)
+	return nil
+}
+
+// ValidRequestID - a propagated request id is kept when it is short and printable
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID - a random 128 bit hex id
+func NewRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// WithLogger - the context carrying the logger of the request
+func WithLogger(ctx context.Context, logger *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext - the logger of the request, the standard logger outside of one. The trace id of the span of the
+// context is added, so that the log lines can be found from a trace
+func FromContext(ctx context.Context) *logrus.Entry {
+	logger, ok := ctx.Value(loggerKey{}).(*logrus.Entry)
+	if !ok {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		logger = logger.WithField(FieldTraceID, span.TraceID().String())
+	}
+	return logger
+}
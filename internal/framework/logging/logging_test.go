@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		testName string
+		level    string
+		want     logrus.Level
+		err      string
+	}{
+		{"Init: should pass(default level)", "", logrus.InfoLevel, ""},
+		{"Init: should pass(upper case level)", "DEBUG", logrus.DebugLevel, ""},
+		{"Init: should pass(warn level)", "warn", logrus.WarnLevel, ""},
+		{"Init: should fail(invalid level)", "verbose", logrus.InfoLevel, "invalid LOG_LEVEL verbose"},
+	}
+	defer logrus.SetLevel(logrus.InfoLevel)
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			logrus.SetLevel(logrus.InfoLevel)
+			err := Init(test.level)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("%s expected(%s) got (%v)", test.testName, test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			if got := logrus.GetLevel(); got != test.want {
+				t.Errorf("%s expected(%v) got (%v)", test.testName, test.want, got)
+			}
+			if _, ok := logrus.StandardLogger().Formatter.(*logrus.JSONFormatter); !ok {
+				t.Errorf("%s expected a JSON formatter got (%T)", test.testName, logrus.StandardLogger().Formatter)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	ctx := WithLogger(context.Background(), logger.WithField(FieldRequestID, "request-1"))
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	FromContext(ctx).WithField(FieldISBN, "isbn-1").Info("book inserted")
+
+	entry := hook.LastEntry()
+	for field, want := range map[string]string{FieldRequestID: "request-1", FieldTraceID: traceID.String(), FieldISBN: "isbn-1"} {
+		if got := entry.Data[field]; got != want {
+			t.Errorf("TestFromContext %s expected(%s) got (%v)", field, want, got)
+		}
+	}
+
+	if got := FromContext(context.Background()).Logger; got != logrus.StandardLogger() {
+		t.Errorf("TestFromContext without a request logger expected the standard logger")
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	first, second := NewRequestID(), NewRequestID()
+	if len(first) != 32 || first == second {
+		t.Errorf("TestNewRequestID expected distinct 32 char ids got (%s) (%s)", first, second)
+	}
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Init - installs the global tracer provider and the W3C trace context propagator for the service name. The exporter
+// (OTEL_TRACES_EXPORTER) is otlp(OTLP over HTTP, configured with the standard OTEL_EXPORTER_OTLP_* variables), stdout for local use,
+// or none(the default) which keeps the propagation without recording spans. The returned function flushes and stops
+// the exporter
+func Init(ctx context.Context, name string, exporterName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName = strings.ToLower(exporterName); exporterName {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("invalid OTEL_TRACES_EXPORTER %s. Expected one of %s, %s, %s", exporterName, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("trace exporter error:%s", err.Error())
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(name))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -1,34 +1,47 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
-	"github.com/sirupsen/logrus"
-	"sort"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
 )
 
 const (
 	documentNotFoundError = "document not found"
+	finishedStatus        = "FINISHED"
 )
 
-var l = logrus.StandardLogger()
+// tracer - the spans of the services, children of the span of the request
+var tracer = otel.Tracer("github.com/anushasankaranarayanan/book-tracker-service/internal/service")
 
 type BookTracker interface {
-	AddBook(entity.Book) error
-	UpdateBook(entity.Book) error
-	ListBooks(string) ([]entity.Book, error)
-	GetBook(string) (*entity.Book, error)
-	GroupBooksByGenre() ([]entity.BooksByGenre, error)
+	AddBook(context.Context, entity.Book) error
+	UpdateBook(context.Context, entity.Book) error
+	ListBooks(context.Context, string, entity.BookFilter) ([]entity.Book, error)
+	GetBook(context.Context, string) (*entity.Book, error)
+	GroupBooksByGenre(context.Context, entity.GroupOptions) ([]entity.BooksByGenre, error)
+	GenreBooks(context.Context, string, entity.Page) ([]entity.Book, int, error)
+	ReadingStats(context.Context, entity.StatsFilter) (*entity.ReadingStats, error)
+	GroupBooksByTag(context.Context) ([]entity.BooksByTag, error)
+	GroupBooks(context.Context, string, entity.GroupOptions) ([]entity.BookGroup, error)
+	RateBook(context.Context, string, float64) error
 }
 
 type BookRepository interface {
-	Upsert(string, interface{}) error
-	GetAll() ([]entity.Book, error)
-	Get(string) (*entity.Book, error)
+	Upsert(context.Context, string, interface{}) error
+	GetAll(context.Context) ([]entity.Book, error)
+	Get(context.Context, string) (*entity.Book, error)
 }
 
 type bookTracker struct {
@@ -39,105 +52,278 @@ func NewBookTracker(tr BookRepository) BookTracker {
 	return &bookTracker{storage: tr}
 }
 
-func (svc *bookTracker) AddBook(book entity.Book) error {
+func (svc *bookTracker) AddBook(ctx context.Context, book entity.Book) (err error) {
+	ctx, span := startSpan(ctx, "BookTracker.AddBook", attribute.String("book.isbn", book.ISBN))
+	defer func() { endSpan(span, err) }()
+
+	// the book with the same ISBN, if any, is replaced
+	existing, err := svc.GetBook(ctx, book.ISBN)
+	var notFound entity.NotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return err
+	}
+
 	book.SetTrackingDetails()
-	err := svc.storage.Upsert(book.ISBN, book)
+	book.SetPercentComplete()
+	book.NormalizeTags()
+	book.NormalizeCase()
+	setFinished(&book, existing, book.Created)
+	// the rating is managed by the review of the book
+	book.Rating = 0
+	if existing != nil {
+		book.Rating = existing.Rating
+	}
+	err = svc.storage.Upsert(ctx, book.ISBN, book)
 
 	if err != nil {
 		return err
 	}
 
-	l.Infof("book %s inserted into couchbase successfully", book.Title)
+	logging.FromContext(ctx).WithField(logging.FieldISBN, book.ISBN).Info("book inserted")
 
 	return nil
 }
 
-func (svc *bookTracker) UpdateBook(book entity.Book) error {
+func (svc *bookTracker) UpdateBook(ctx context.Context, book entity.Book) (err error) {
+	ctx, span := startSpan(ctx, "BookTracker.UpdateBook", attribute.String("book.isbn", book.ISBN))
+	defer func() { endSpan(span, err) }()
+
 	id := book.ISBN
 	book.Updated = time.Now().Unix()
+	book.SetPercentComplete()
+	book.NormalizeTags()
+	book.NormalizeCase()
 
-	_, err := svc.GetBook(id)
+	existing, err := svc.GetBook(ctx, id)
 	if err != nil {
 		return err
 	}
+	setFinished(&book, existing, book.Updated)
+	// the rating is managed by the review of the book
+	book.Rating = existing.Rating
 
-	err = svc.storage.Upsert(id, book)
+	err = svc.storage.Upsert(ctx, id, book)
 	if err != nil {
 		return err
 	}
 
-	l.Infof("book %s updated successfully", id)
+	logging.FromContext(ctx).WithField(logging.FieldISBN, id).Info("book updated")
 	return nil
 }
 
-func (svc *bookTracker) ListBooks(sortKey string) ([]entity.Book, error) {
-	books, err := svc.storage.GetAll()
+// setFinished - goals and stats count books by their finished timestamp. Unless the client supplies it, a book that
+// was already FINISHED keeps the stored one and a book that becomes FINISHED is stamped with now
+func setFinished(book *entity.Book, existing *entity.Book, now int64) {
+	if strings.ToUpper(book.Status) != finishedStatus || book.Finished != 0 {
+		return
+	}
+	if existing != nil && strings.ToUpper(existing.Status) == finishedStatus && existing.Finished != 0 {
+		book.Finished = existing.Finished
+		return
+	}
+	book.Finished = now
+}
+
+// ListBooks - the books matching the filter ordered by the sort keys(see entity.ParseBookSort). The repository orders
+// the numeric keys when it can, the string keys are always collated here so that the order is the same on every
+// repository
+func (svc *bookTracker) ListBooks(ctx context.Context, sortKey string, filter entity.BookFilter) (books []entity.Book, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.ListBooks", attribute.String("books.sort", sortKey))
+	defer func() { endSpan(span, err) }()
+
+	keys, err := entity.ParseBookSort(sortKey)
 	if err != nil {
 		return nil, err
 	}
-	sortBooks(sortKey, books)
+
+	sorter, sorted := svc.storage.(BookSorter)
+	sorted = sorted && len(keys) > 0 && !keys.Collated()
+	if sorted {
+		books, err = sorter.GetAllSorted(ctx, keys)
+	} else {
+		books, err = svc.storage.GetAll(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	books = filter.Filter(books)
+	for i := range books {
+		books[i].SetPercentComplete()
+	}
+	if !sorted {
+		_, sortSpan := tracer.Start(ctx, "sortBooks", trace.WithAttributes(attribute.Int("books.count", len(books))))
+		sortBooks(keys, books)
+		sortSpan.End()
+	}
+	span.SetAttributes(attribute.Int("books.count", len(books)))
 	return books, nil
 }
 
-func (svc *bookTracker) GetBook(id string) (*entity.Book, error) {
-	book, err := svc.storage.Get(id)
+func (svc *bookTracker) GetBook(ctx context.Context, id string) (book *entity.Book, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.GetBook", attribute.String("book.isbn", id))
+	defer func() { endSpan(span, err) }()
+
+	book, err = svc.storage.Get(ctx, id)
 	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
 		return nil, entity.NotFoundError{Message: fmt.Sprintf("book with id %s not found", id)}
 	}
+	if book != nil {
+		book.SetPercentComplete()
+	}
 	return book, err
 }
 
-func (svc *bookTracker) GroupBooksByGenre() ([]entity.BooksByGenre, error) {
-	books, err := svc.ListBooks("")
+// RateBook - sets the public rating of the book, 0 clears it
+func (svc *bookTracker) RateBook(ctx context.Context, id string, rating float64) (err error) {
+	ctx, span := startSpan(ctx, "BookTracker.RateBook", attribute.String("book.isbn", id))
+	defer func() { endSpan(span, err) }()
+
+	book, err := svc.GetBook(ctx, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	sortBooks(consts.Genre, books)
-	genres := groupByGenre(books)
-	return genres, nil
+	book.Rating = rating
+	book.Updated = time.Now().Unix()
+	return svc.storage.Upsert(ctx, id, *book)
 }
 
-func sortBooks(sortKey string, books []entity.Book) {
-	if strings.ToLower(sortKey) == consts.Title {
-		sort.Slice(books, func(i, j int) bool {
-			return books[i].Title < books[j].Title
-		})
+// GroupBooksByGenre - groups the books by canonical genre. Parent genres are listed with the total of their sub-genres
+// even when no book has the parent genre itself. Counts only groups are aggregated by the repository when it can
+func (svc *bookTracker) GroupBooksByGenre(ctx context.Context, options entity.GroupOptions) (_ []entity.BooksByGenre, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.GroupBooksByGenre")
+	defer func() { endSpan(span, err) }()
+
+	if aggregator, ok := svc.storage.(GenreAggregator); ok && options.CountsOnly {
+		counts, err := aggregator.GenreCounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		taxonomy, err := svc.taxonomy(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return countByGenre(counts, taxonomy), nil
 	}
-	if strings.ToLower(sortKey) == consts.Status {
-		sort.Slice(books, func(i, j int) bool {
-			return books[i].Status < books[j].Status
-		})
+
+	books, err := svc.ListBooks(ctx, consts.Title, entity.BookFilter{})
+	if err != nil {
+		return nil, err
 	}
-	if strings.ToLower(sortKey) == consts.Genre {
-		sort.Slice(books, func(i, j int) bool {
-			return books[i].Genre < books[j].Genre
-		})
+	taxonomy, err := svc.taxonomy(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return groupByGenre(books, taxonomy, options), nil
 }
 
-func groupByGenre(books []entity.Book) []entity.BooksByGenre {
-	var genres []entity.BooksByGenre
-	previousGenre := ""
+// GenreBooks - a page of the books of the genre(any of its spellings) ordered by title, and their total
+func (svc *bookTracker) GenreBooks(ctx context.Context, genre string, page entity.Page) (_ []entity.Book, _ int, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.GenreBooks", attribute.String("book.genre", genre))
+	defer func() { endSpan(span, err) }()
 
-	//for each book's genre, get all books with that genre from the (same)array
+	taxonomy, err := svc.taxonomy(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	spellings := taxonomy.Spellings(genre)
+	if aggregator, ok := svc.storage.(GenreAggregator); ok {
+		books, total, err := aggregator.GenreBooks(ctx, spellings, page)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range books {
+			books[i].SetPercentComplete()
+		}
+		return books, total, nil
+	}
+
+	books, err := svc.ListBooks(ctx, consts.Title, entity.BookFilter{})
+	if err != nil {
+		return nil, 0, err
+	}
+	var genreBooks []entity.Book
 	for _, book := range books {
-		// Add books only for new genres
-		if previousGenre != book.Genre {
-			booksForGenre := getBooksForGenre(book.Genre, books)
-			item := entity.BooksByGenre{Genre: book.Genre, Books: booksForGenre, Count: len(booksForGenre)}
-			genres = append(genres, item)
+		if containsKey(spellings, entity.GenreKey(book.Genre)) {
+			genreBooks = append(genreBooks, book)
 		}
-		previousGenre = book.Genre
 	}
-	return genres
+	from, to := page.Window(len(genreBooks))
+	return genreBooks[from:to], len(genreBooks), nil
 }
 
-func getBooksForGenre(genre string, books []entity.Book) []entity.Book {
-	var booksForGenre []entity.Book
-	for _, book := range books {
-		if book.Genre == genre {
-			booksForGenre = append(booksForGenre, book)
+// taxonomy - the genre taxonomy of the repository, an empty one when it does not keep one
+func (svc *bookTracker) taxonomy(ctx context.Context) (*entity.GenreTaxonomy, error) {
+	repository, ok := svc.storage.(TaxonomyRepository)
+	if !ok {
+		return entity.NewGenreTaxonomy(nil), nil
+	}
+	genres, err := repository.GetAllGenres(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return entity.NewGenreTaxonomy(genres), nil
+}
+
+// GroupBooksByTag - lists the tags(ordered by name) and the books carrying each tag
+func (svc *bookTracker) GroupBooksByTag(ctx context.Context) (_ []entity.BooksByTag, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.GroupBooksByTag")
+	defer func() { endSpan(span, err) }()
+
+	books, err := svc.ListBooks(ctx, consts.Title, entity.BookFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return groupByTag(books), nil
+}
+
+// GroupBooks - groups the books by one of entity.Groupings, genres are grouped as GroupBooksByGenre does. Counts only
+// status groups are aggregated by the repository when it can
+func (svc *bookTracker) GroupBooks(ctx context.Context, by string, options entity.GroupOptions) (_ []entity.BookGroup, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.GroupBooks", attribute.String("books.group_by", by))
+	defer func() { endSpan(span, err) }()
+
+	if aggregator, ok := svc.storage.(StatusAggregator); ok && by == entity.GroupByStatus && options.CountsOnly {
+		counts, err := aggregator.StatusCounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return countByStatus(counts), nil
+	}
+
+	books, err := svc.ListBooks(ctx, consts.Title, entity.BookFilter{})
+	if err != nil {
+		return nil, err
+	}
+	taxonomy, err := svc.taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grouping, ok := newGrouping(by, taxonomy)
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %s", by)
+	}
+	return groupBooks(books, grouping, options), nil
+}
+
+// startSpan - starts the span of a service method as a child of the span of the context
+func startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endSpan - ends the span, recording the error of the method if any
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
 		}
 	}
-	return booksForGenre
+	return false
 }
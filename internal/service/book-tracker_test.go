@@ -3,20 +3,40 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
 	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/database"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"testing"
+	"time"
 )
 
 const (
+	testFolderPath    = "../../tests/"
 	createBook        = "AddBook"
 	updateBook        = "UpdateBook"
 	getAllBooks       = "ListBooks"
 	getBook           = "GetBook"
 	groupBooksByGenre = "GroupBooksByGenre"
+	readingStats      = "ReadingStats"
+	setGoal           = "SetGoal"
+	listGoals         = "ListGoals"
+	goalProgress      = "GoalProgress"
+	groupBooksByTag   = "GroupBooksByTag"
+	createShelf       = "CreateShelf"
+	listShelves       = "ListShelves"
+	getShelf          = "GetShelf"
+	renameShelf       = "RenameShelf"
+	deleteShelf       = "DeleteShelf"
+	addBookToShelf    = "AddBookToShelf"
 )
 
 func TestService(t *testing.T) {
@@ -43,7 +63,7 @@ func TestService(t *testing.T) {
 			errors.New("Upsert error:forced collection upsert error"),
 			"",
 			createBook,
-			"error",
+			"update-error",
 			"",
 		},
 		{
@@ -94,6 +114,14 @@ func TestService(t *testing.T) {
 			"",
 			consts.Status,
 		},
+		{
+			"GetBooks: should pass(filtered by format)",
+			nil,
+			entity.FormatEbook,
+			getAllBooks,
+			"",
+			"",
+		},
 		{
 			"GetBook: should fail(book not found)",
 			errors.New("book with id TEST-ISBN not found"),
@@ -126,25 +154,217 @@ func TestService(t *testing.T) {
 			"",
 			"",
 		},
+		{
+			"ReadingStats: should fail(force query error)",
+			errors.New("Stats summary query error:forced query error"),
+			"",
+			readingStats,
+			"query-error",
+			"",
+		},
+		{
+			"ReadingStats: should pass",
+			nil,
+			"",
+			readingStats,
+			"",
+			"",
+		},
+		{
+			"SetGoal: should pass(new goal)",
+			nil,
+			"",
+			setGoal,
+			"not-found-error",
+			"",
+		},
+		{
+			"SetGoal: should pass(existing goal)",
+			nil,
+			"",
+			setGoal,
+			"",
+			"",
+		},
+		{
+			"SetGoal: should fail(get error)",
+			errors.New("get goal error:forced collection error"),
+			"",
+			setGoal,
+			"true",
+			"",
+		},
+		{
+			"SetGoal: should fail(update-error)",
+			errors.New("UpsertGoal error:forced collection upsert error"),
+			"",
+			setGoal,
+			"update-error",
+			"",
+		},
+		{
+			"ListGoals: should fail(force query error)",
+			errors.New("GetAllGoals query error:forced query error"),
+			"",
+			listGoals,
+			"query-error",
+			"",
+		},
+		{
+			"GoalProgress: should fail(goal not found)",
+			errors.New("goal for year 2023 not found"),
+			"",
+			goalProgress,
+			"not-found-error",
+			"",
+		},
+		{
+			"GoalProgress: should pass",
+			nil,
+			"",
+			goalProgress,
+			"",
+			"",
+		},
+		{
+			"GroupBooksByTag: should pass",
+			nil,
+			"",
+			groupBooksByTag,
+			"",
+			"",
+		},
+		{
+			"CreateShelf: should fail(already exists)",
+			errors.New("shelf owned already exists"),
+			"",
+			createShelf,
+			"",
+			"",
+		},
+		{
+			"CreateShelf: should pass",
+			nil,
+			"",
+			createShelf,
+			"not-found-error",
+			"",
+		},
+		{
+			"ListShelves: should fail(force query error)",
+			errors.New("GetAllShelves query error:forced query error"),
+			"",
+			listShelves,
+			"query-error",
+			"",
+		},
+		{
+			"ListShelves: should pass",
+			nil,
+			"",
+			listShelves,
+			"",
+			"",
+		},
+		{
+			"GetShelf: should fail(shelf not found)",
+			errors.New("shelf owned not found"),
+			"",
+			getShelf,
+			"not-found-error",
+			"",
+		},
+		{
+			"GetShelf: should pass",
+			nil,
+			"",
+			getShelf,
+			"",
+			"",
+		},
+		{
+			"RenameShelf: should fail(shelf not found)",
+			errors.New("shelf book club not found"),
+			"",
+			renameShelf,
+			"not-found-error",
+			"",
+		},
+		{
+			"RenameShelf: should fail(already exists)",
+			errors.New("shelf Book club already exists"),
+			"",
+			renameShelf,
+			"",
+			"",
+		},
+		{
+			"DeleteShelf: should fail(remove error)",
+			errors.New("RemoveShelf error:forced collection remove error"),
+			"",
+			deleteShelf,
+			"remove-error",
+			"",
+		},
+		{
+			"DeleteShelf: should pass",
+			nil,
+			"",
+			deleteShelf,
+			"",
+			"",
+		},
+		{
+			"AddBookToShelf: should pass",
+			nil,
+			testBook.ISBN,
+			addBookToShelf,
+			"",
+			"",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 			couchbaseStorage, _ := database.NewFakeCouchbaseStorage(test.errorFlag)
 			bookService := NewBookTracker(couchbaseStorage)
+			goalService := NewGoalTracker(couchbaseStorage, bookService)
+			shelfService := NewShelfTracker(couchbaseStorage, bookService)
 
 			var err error
 			switch test.serviceMethod {
 			case createBook:
-				err = bookService.AddBook(testBook)
+				err = bookService.AddBook(context.Background(), testBook)
 			case updateBook:
-				err = bookService.UpdateBook(testBook)
+				err = bookService.UpdateBook(context.Background(), testBook)
 			case getAllBooks:
-				_, err = bookService.ListBooks(test.sortKey)
+				_, err = bookService.ListBooks(context.Background(), test.sortKey, entity.BookFilter{Format: test.arg})
 			case getBook:
-				_, err = bookService.GetBook(test.arg)
+				_, err = bookService.GetBook(context.Background(), test.arg)
 			case groupBooksByGenre:
-				_, err = bookService.GroupBooksByGenre()
+				_, err = bookService.GroupBooksByGenre(context.Background(), entity.GroupOptions{})
+			case readingStats:
+				_, err = bookService.ReadingStats(context.Background(), entity.StatsFilter{})
+			case setGoal:
+				err = goalService.SetGoal(context.Background(), entity.Goal{Year: 2023, Books: 40})
+			case listGoals:
+				_, err = goalService.ListGoals(context.Background())
+			case goalProgress:
+				_, err = goalService.GoalProgress(context.Background(), 2023)
+			case groupBooksByTag:
+				_, err = bookService.GroupBooksByTag(context.Background())
+			case createShelf:
+				err = shelfService.CreateShelf(context.Background(), entity.Shelf{Name: "owned"})
+			case listShelves:
+				_, err = shelfService.ListShelves(context.Background())
+			case getShelf:
+				_, _, err = shelfService.GetShelf(context.Background(), "owned")
+			case renameShelf:
+				err = shelfService.UpdateShelf(context.Background(), "book club", entity.Shelf{Name: "Book club"})
+			case deleteShelf:
+				err = shelfService.DeleteShelf(context.Background(), "book club")
+			case addBookToShelf:
+				err = shelfService.AddBookToShelf(context.Background(), "book club", test.arg)
 			}
 
 			if err == nil && err != test.errorExpected {
@@ -157,3 +377,624 @@ func TestService(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeStats(t *testing.T) {
+	day := int64(86400)
+	jan := int64(1672531200) // 2023-01-01
+	books := []entity.Book{
+		{ISBN: "1", Title: "Short", Author: "A", Genre: "Horror", Bookmark: 100, PageCount: 120, Started: jan, Finished: jan + 2*day},
+		{ISBN: "2", Title: "Long", Author: "B", Genre: "Horror", Bookmark: 300, Started: jan, Finished: jan + 40*day},
+		{ISBN: "3", Title: "Untimed", Author: "A", Genre: "Mystery", Bookmark: 50, Finished: jan + 400*day},
+		{ISBN: "4", Title: "Unread", Author: "C", Genre: "Mystery"},
+	}
+
+	t.Run("ComputeStats: all finished books", func(t *testing.T) {
+		stats := computeStats(books, entity.StatsFilter{})
+		if stats.BooksFinished != 3 || stats.PagesRead != 470 {
+			t.Errorf("ComputeStats got (%d books, %d pages) wanted (3 books, 470 pages)", stats.BooksFinished, stats.PagesRead)
+		}
+		if stats.AverageDaysToFinish != 21 {
+			t.Errorf("ComputeStats average days got (%v) wanted (21)", stats.AverageDaysToFinish)
+		}
+		if stats.LongestRead.ISBN != "2" || stats.ShortestRead.ISBN != "1" {
+			t.Errorf("ComputeStats longest/shortest got (%s/%s) wanted (2/1)", stats.LongestRead.ISBN, stats.ShortestRead.ISBN)
+		}
+		if len(stats.FinishedPerYear) != 2 || stats.FinishedPerYear[0] != (entity.PeriodCount{Period: "2023", Count: 2}) {
+			t.Errorf("ComputeStats per year got (%+v)", stats.FinishedPerYear)
+		}
+		if stats.Genres[0] != (entity.NamedCount{Name: "Horror", Count: 2}) || stats.Authors[0] != (entity.NamedCount{Name: "A", Count: 2}) {
+			t.Errorf("ComputeStats breakdown got genres (%+v) authors (%+v)", stats.Genres, stats.Authors)
+		}
+	})
+
+	t.Run("ComputeStats: date range", func(t *testing.T) {
+		stats := computeStats(books, entity.StatsFilter{From: jan + 10*day, To: jan + 100*day})
+		if stats.BooksFinished != 1 || len(stats.FinishedPerMonth) != 1 || stats.FinishedPerMonth[0].Period != "2023-02" {
+			t.Errorf("ComputeStats range got (%d books, %+v)", stats.BooksFinished, stats.FinishedPerMonth)
+		}
+	})
+}
+
+func TestProjectProgress(t *testing.T) {
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	midYear := start.AddDate(0, 0, 182)
+
+	tests := []struct {
+		testName string
+		goal     entity.Goal
+		books    int
+		pages    int
+		now      time.Time
+		expected string
+	}{
+		{"ProjectProgress: on track", entity.Goal{Year: 2023, Books: 40}, 20, 0, midYear, entity.GoalOnTrack},
+		{"ProjectProgress: behind on pages", entity.Goal{Year: 2023, Books: 40, Pages: 10000}, 20, 1000, midYear, entity.GoalBehind},
+		{"ProjectProgress: completed", entity.Goal{Year: 2023, Books: 40}, 41, 0, midYear, entity.GoalCompleted},
+		{"ProjectProgress: missed", entity.Goal{Year: 2023, Books: 40}, 39, 0, end.AddDate(0, 1, 0), entity.GoalMissed},
+		{"ProjectProgress: not started", entity.Goal{Year: 2023, Books: 40}, 0, 0, start.AddDate(0, -1, 0), entity.GoalOnTrack},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			progress := projectProgress(test.goal, test.books, test.pages, start, end, test.now)
+			if progress.Status != test.expected {
+				t.Errorf("ProjectProgress status got (%s) wanted (%s)", progress.Status, test.expected)
+			}
+		})
+	}
+
+	t.Run("ProjectProgress: projection", func(t *testing.T) {
+		progress := projectProgress(entity.Goal{Year: 2023, Books: 40}, 10, 0, start, end, start.AddDate(0, 0, 73))
+		if progress.Books.Expected != 8 || progress.Books.Projected != 50 || progress.Books.Percent != 25 {
+			t.Errorf("ProjectProgress got (%+v) wanted expected 8, projected 50, percent 25", progress.Books)
+		}
+	})
+}
+
+func TestBookFilter(t *testing.T) {
+	var legacy, book entity.Book
+	_ = json.Unmarshal([]byte(`{"isbn": "1", "title": "Legacy", "author": "Test Author", "genre": "Horror"}`), &legacy)
+	data, _ := os.ReadFile(testFolderPath + "book-authors.json")
+	_ = json.Unmarshal(data, &book)
+
+	t.Run("BookFilter: backward compatible decoding", func(t *testing.T) {
+		if len(legacy.Authors) != 1 || legacy.Authors[0] != (entity.Contributor{Name: "Test Author", Role: entity.RoleAuthor}) {
+			t.Errorf("BookFilter legacy authors got (%+v)", legacy.Authors)
+		}
+		if book.Author != "Test Author" {
+			t.Errorf("BookFilter author got (%s) wanted (Test Author)", book.Author)
+		}
+	})
+
+	tests := []struct {
+		testName string
+		filter   entity.BookFilter
+		expected int
+	}{
+		{"BookFilter: no filter", entity.BookFilter{}, 2},
+		{"BookFilter: translator", entity.BookFilter{Author: "test translator"}, 1},
+		{"BookFilter: author", entity.BookFilter{Author: "Test Author"}, 2},
+		{"BookFilter: series", entity.BookFilter{Series: "test series"}, 1},
+		{"BookFilter: format and year", entity.BookFilter{Format: "ebook", PublicationYear: 2019}, 1},
+		{"BookFilter: language mismatch", entity.BookFilter{Language: "fr"}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			matched := test.filter.Filter([]entity.Book{legacy, book})
+			if len(matched) != test.expected {
+				t.Errorf("%s got (%d) books wanted (%d)", test.testName, len(matched), test.expected)
+			}
+		})
+	}
+}
+
+func TestGroupByTag(t *testing.T) {
+	books := []entity.Book{
+		{ISBN: "1", Tags: []string{"owned", "book club"}},
+		{ISBN: "2", Tags: []string{"Owned"}},
+		{ISBN: "3"},
+	}
+
+	tags := groupByTag(books)
+	if len(tags) != 2 || tags[0].Tag != "book club" || tags[1].Tag != "owned" || tags[1].Count != 2 {
+		t.Errorf("groupByTag got (%+v) wanted book club(1) and owned(2)", tags)
+	}
+}
+
+func TestExportBooks(t *testing.T) {
+	books := []entity.Book{{ISBN: "1"}, {ISBN: "2"}}
+	reviews := []entity.Review{{ISBN: "1", Rating: 4.5, Spoiler: true}}
+	notes := []entity.Note{{ID: "a", ISBN: "1", Page: 3}, {ID: "b", ISBN: "1", Page: 9}, {ID: "c", ISBN: "3"}}
+
+	exports := exportBooks(books, reviews, notes)
+	if len(exports) != 2 || exports[0].Review == nil || exports[0].Review.Rating != 4.5 || len(exports[0].Notes) != 2 {
+		t.Errorf("exportBooks got (%+v) wanted book 1 with its review and 2 notes", exports)
+	}
+	if exports[1].Review != nil || len(exports[1].Notes) != 0 {
+		t.Errorf("exportBooks got (%+v) wanted book 2 without review and notes", exports[1])
+	}
+}
+
+func TestParseClippings(t *testing.T) {
+	file, err := os.Open(testFolderPath + "my-clippings.txt")
+	if err != nil {
+		t.Fatalf("failed to open clippings %s", err.Error())
+	}
+	defer file.Close()
+
+	clippings, err := ParseClippings(file)
+	if err != nil || len(clippings) != 5 {
+		t.Fatalf("ParseClippings got (%d, %v) wanted 5 clippings", len(clippings), err)
+	}
+	first := clippings[0]
+	if first.Title != "title-1" || first.Author != "Author, Some" || first.Kind != clippingHighlight ||
+		first.Page != 12 || first.Location != "170-172" || first.Text != "The first highlight" {
+		t.Errorf("ParseClippings got (%+v) wanted the first highlight of title-1", first)
+	}
+	if clippings[1].Kind != clippingNote || clippings[2].Kind != clippingBookmark || clippings[4].Location != "88-90" {
+		t.Errorf("ParseClippings got (%+v) wanted a note, a bookmark and a location only highlight", clippings)
+	}
+}
+
+func TestBookMatcher(t *testing.T) {
+	matcher := newBookMatcher([]entity.Book{
+		{ISBN: "1", Title: "The Left Hand of Darkness", Author: "Ursula K. Le Guin"},
+		{ISBN: "2", Title: "Dune", Author: "Frank Herbert"},
+	})
+	tests := []struct {
+		title  string
+		author string
+		isbn   string
+	}{
+		{"The Left Hand of Darkness (Hainish Cycle Book 4)", "Le Guin, Ursula K.", "1"},
+		{"The Left Hand of Darknes", "", "1"},
+		{"Dune: Deluxe Edition", "Herbert, Frank", "2"},
+		{"Dunes", "Frank Herbert", "2"},
+		{"Dunes", "Someone Else", ""},
+		{"Children of Dune", "Frank Herbert", ""},
+	}
+	for _, test := range tests {
+		book := matcher.match(test.title, test.author)
+		if (book == nil && test.isbn != "") || (book != nil && book.ISBN != test.isbn) {
+			t.Errorf("match(%s, %s) got (%+v) wanted book %q", test.title, test.author, book, test.isbn)
+		}
+	}
+}
+
+func TestImportHighlights(t *testing.T) {
+	clippings := []entity.Clipping{
+		{Title: "Dune", Kind: clippingHighlight, Page: 12, Location: "1406-07", Text: "Fear is the mind-killer."},
+		{Title: "Dune", Kind: clippingNote, Page: 12, Location: "1407", Text: "the litany"},
+		{Title: "Dune", Kind: clippingBookmark, Location: "2000"},
+		{Title: "Dune", Kind: clippingHighlight, Page: 12, Location: "1406-07", Text: "Fear is the mind-killer. "},
+		{Title: "Emma", Author: "Jane Austen", Kind: clippingHighlight, Location: "10", Text: "Emma Woodhouse"},
+	}
+	result := &entity.ImportResult{}
+	highlights := importHighlights(clippings, newBookMatcher([]entity.Book{{ISBN: "2", Title: "Dune"}}), result)
+
+	if len(highlights) != 2 || highlights[0].Note != "the litany" || highlights[0].ID != highlights[1].ID {
+		t.Errorf("importHighlights got (%+v) wanted the note on the highlight and the repeat with the same key", highlights)
+	}
+	if result.Skipped != 1 || len(result.Unmatched) != 1 || result.Unmatched[0].Title != "Emma" {
+		t.Errorf("importHighlights got (%+v) wanted 1 skipped bookmark and Emma unmatched", result)
+	}
+}
+
+func TestHighlightsMarkdown(t *testing.T) {
+	books := []entity.Book{{ISBN: "1", Title: "Dune", Author: "Frank Herbert"}, {ISBN: "2", Title: "Emma"}}
+	highlights := []entity.Highlight{
+		{ISBN: "1", Text: "second", Page: 20, Color: entity.ColorBlue},
+		{ISBN: "1", Text: "first", Page: 3, Location: "40-41", Note: "a note"},
+	}
+
+	md := string(highlightsMarkdown(books, highlights))
+	want := "# Highlights\n\n## Dune - Frank Herbert\n\n> first\n\n_page 3 · location 40-41_\n\n**Note:** a note\n\n> second\n\n_page 20 · blue_\n"
+	if md != want {
+		t.Errorf("highlightsMarkdown got (%q) wanted (%q)", md, want)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	now := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) int64 {
+		return now.AddDate(0, 0, -days).Unix()
+	}
+	books := []entity.Book{
+		{ISBN: "f1", Status: "FINISHED", Genre: "Horror", Author: "Stephen King", Rating: 5},
+		{ISBN: "f2", Status: "FINISHED", Genre: "Fantasy", Author: "Robin Hobb", Rating: 4, Series: &entity.Series{Name: "Farseer", Position: 1}},
+		{ISBN: "f3", Status: "FINISHED", Genre: "Romance", Author: "Someone", Rating: 2},
+		{ISBN: "u1", Status: "UNREAD", Genre: "Horror", Author: "Stephen King", PageCount: 500, Created: daysAgo(10)},
+		{ISBN: "u2", Status: "UNREAD", Genre: "Fantasy", Author: "Robin Hobb", PageCount: 400, Created: daysAgo(400), Series: &entity.Series{Name: "Farseer", Position: 2}},
+		{ISBN: "u3", Status: "UNREAD", Genre: "Fantasy", Author: "Robin Hobb", PageCount: 200, Created: daysAgo(400), Series: &entity.Series{Name: "Farseer", Position: 3}},
+		{ISBN: "u4", Status: "UNREAD", Genre: "Romance", Author: "Someone", Tags: []string{"cozy"}, PageCount: 150, Created: daysAgo(5)},
+		{ISBN: "p1", Status: "IN PROGRESS", Genre: "Horror"},
+	}
+
+	tests := []struct {
+		filter entity.RecommendationFilter
+		isbns  []string
+	}{
+		{entity.RecommendationFilter{}, []string{"u2", "u1", "u3", "u4"}},
+		{entity.RecommendationFilter{Limit: 2}, []string{"u2", "u1"}},
+		{entity.RecommendationFilter{Length: entity.LengthShort}, []string{"u3", "u4"}},
+		{entity.RecommendationFilter{Mood: "light"}, []string{"u4"}},
+		{entity.RecommendationFilter{Mood: "fantasy", Length: "medium"}, []string{"u2"}},
+	}
+	for _, test := range tests {
+		picks := recommend(books, test.filter, now)
+		var isbns []string
+		for _, pick := range picks {
+			isbns = append(isbns, pick.Book.ISBN)
+		}
+		if strings.Join(isbns, ",") != strings.Join(test.isbns, ",") {
+			t.Errorf("recommend(%+v) got (%v) wanted (%v)", test.filter, isbns, test.isbns)
+		}
+	}
+
+	picks := recommend(books, entity.RecommendationFilter{}, now)
+	if picks[0].Score != 10.5 || len(picks[0].Reasons) != 4 || picks[0].Reasons[2] != "next in the Farseer series after #1" {
+		t.Errorf("recommend got (%+v) wanted u2 scored 10.5 for genre, author, series and waiting", picks[0])
+	}
+	if picks[2].Reasons[2] != "1 earlier book(s) of the Farseer series are not finished" || len(picks[3].Reasons) != 0 {
+		t.Errorf("recommend got (%+v, %+v) wanted u3 held back and u4 without reasons", picks[2], picks[3])
+	}
+}
+
+func TestGroupByGenre(t *testing.T) {
+	taxonomy := entity.NewGenreTaxonomy([]entity.Genre{
+		{Name: "Fiction"},
+		{Name: "Science Fiction", Aliases: []string{"Sci-Fi", "SF"}, Parent: "Fiction"},
+		{Name: "Space Opera", Parent: "science fiction"},
+	})
+	books := []entity.Book{
+		{ISBN: "1", Genre: "Sci-Fi"},
+		{ISBN: "2", Genre: "sci-fi"},
+		{ISBN: "3", Genre: "science fiction"},
+		{ISBN: "4", Genre: "space opera"},
+		{ISBN: "5", Genre: "Poetry"},
+		{ISBN: "6", Genre: "poetry "},
+	}
+
+	genres := groupByGenre(books, taxonomy, entity.GroupOptions{})
+	want := []entity.BooksByGenre{
+		{Genre: "Fiction", Count: 0, Total: 4},
+		{Genre: "Poetry", Count: 2, Total: 2},
+		{Genre: "Science Fiction", Parent: "Fiction", Count: 3, Total: 4},
+		{Genre: "Space Opera", Parent: "Science Fiction", Count: 1, Total: 1},
+	}
+	if len(genres) != len(want) {
+		t.Fatalf("groupByGenre got (%+v) wanted (%+v)", genres, want)
+	}
+	for i := range want {
+		got := genres[i]
+		if got.Genre != want[i].Genre || got.Parent != want[i].Parent || got.Count != want[i].Count ||
+			got.Total != want[i].Total || len(got.Books) != got.Count {
+			t.Errorf("groupByGenre got (%+v) wanted (%+v)", got, want[i])
+		}
+	}
+}
+
+func TestGroupByGenreOptions(t *testing.T) {
+	taxonomy := entity.NewGenreTaxonomy([]entity.Genre{
+		{Name: "Fiction"},
+		{Name: "Science Fiction", Aliases: []string{"Sci-Fi"}, Parent: "Fiction"},
+	})
+	books := []entity.Book{{ISBN: "1", Genre: "Sci-Fi"}, {ISBN: "2", Genre: "sci-fi"}, {ISBN: "3", Genre: "Science Fiction"}}
+
+	sampled := groupByGenre(books, taxonomy, entity.GroupOptions{Sample: 2})
+	if len(sampled) != 2 || sampled[1].Count != 3 || len(sampled[1].Books) != 2 {
+		t.Errorf("groupByGenre(sample 2) got (%+v) wanted 3 Science Fiction books with 2 of them listed", sampled)
+	}
+	countsOnly := groupByGenre(books, taxonomy, entity.GroupOptions{CountsOnly: true})
+	if len(countsOnly) != 2 || countsOnly[1].Count != 3 || countsOnly[1].Books != nil {
+		t.Errorf("groupByGenre(counts only) got (%+v) wanted 3 Science Fiction books without books", countsOnly)
+	}
+
+	counts := countByGenre([]entity.NamedCount{{Name: "sci-fi", Count: 2}, {Name: "Science Fiction", Count: 1}, {Name: "Poetry", Count: 4}}, taxonomy)
+	if len(counts) != 3 || counts[0].Genre != "Fiction" || counts[0].Total != 3 || counts[2].Count != 3 || counts[2].Parent != "Fiction" {
+		t.Errorf("countByGenre got (%+v) wanted Fiction(total 3), Poetry(4) and Science Fiction(3)", counts)
+	}
+}
+
+func TestGroupBooks(t *testing.T) {
+	finished := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC).Unix()
+	books := []entity.Book{
+		{ISBN: "1", Author: "Terry Pratchett", Authors: []entity.Contributor{{Name: "Terry Pratchett", Role: entity.RoleCoAuthor},
+			{Name: "Neil Gaiman", Role: entity.RoleCoAuthor}, {Name: "Someone", Role: entity.RoleNarrator}},
+			Status: "FINISHED", Finished: finished, Language: "English", Tags: []string{"owned"}},
+		{ISBN: "2", Author: "neil gaiman", Status: "unread", Language: "english", Tags: []string{"Owned", "book club"}},
+		{ISBN: "3", Author: "Ursula K. Le Guin", Status: "FINISHED", Finished: finished},
+	}
+
+	tests := []struct {
+		by       string
+		expected []entity.BookGroup
+	}{
+		{entity.GroupByAuthor, []entity.BookGroup{{Group: "Neil Gaiman", Count: 2}, {Group: "Terry Pratchett", Count: 1}, {Group: "Ursula K. Le Guin", Count: 1}}},
+		{entity.GroupByStatus, []entity.BookGroup{{Group: "FINISHED", Count: 2}, {Group: "UNREAD", Count: 1}}},
+		{entity.GroupByYearFinished, []entity.BookGroup{{Group: "2022", Count: 2}}},
+		{entity.GroupByLanguage, []entity.BookGroup{{Group: "English", Count: 2}}},
+		{entity.GroupByTag, []entity.BookGroup{{Group: "book club", Count: 1}, {Group: "owned", Count: 2}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.by, func(t *testing.T) {
+			grouping, ok := newGrouping(test.by, nil)
+			if !ok {
+				t.Fatalf("newGrouping(%s) is not a grouping", test.by)
+			}
+			groups := groupBooks(books, grouping, entity.GroupOptions{})
+			if len(groups) != len(test.expected) {
+				t.Fatalf("groupBooks(%s) got (%+v) wanted (%+v)", test.by, groups, test.expected)
+			}
+			for i, want := range test.expected {
+				got := groups[i]
+				if got.Group != want.Group || got.Count != want.Count || got.Total != want.Count || len(got.Books) != want.Count {
+					t.Errorf("groupBooks(%s) got (%+v) wanted (%+v)", test.by, got, want)
+				}
+			}
+		})
+	}
+
+	if _, ok := newGrouping("publisher", nil); ok {
+		t.Errorf("newGrouping(publisher) got a grouping wanted none")
+	}
+}
+
+func TestSortBooks(t *testing.T) {
+	books := []entity.Book{
+		{ISBN: "4", Title: "zebra", Finished: 10},
+		{ISBN: "3", Title: "Émile", Finished: 20},
+		{ISBN: "2", Title: "apple", Finished: 20},
+		{ISBN: "1", Title: "Zebra", Finished: 10},
+		{ISBN: "0", Title: "Banana"},
+	}
+
+	tests := []struct {
+		sort     string
+		expected string
+	}{
+		{"title", "2 0 3 4 1"},
+		{"-finished,title", "2 3 4 1 0"},
+		{"-finished", "2 3 1 4 0"},
+		{"", "4 3 2 1 0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sort, func(t *testing.T) {
+			keys, err := entity.ParseBookSort(test.sort)
+			if err != nil {
+				t.Fatalf("ParseBookSort(%s) error %s", test.sort, err.Error())
+			}
+			sorted := append([]entity.Book(nil), books...)
+			sortBooks(keys, sorted)
+			var isbns []string
+			for _, book := range sorted {
+				isbns = append(isbns, book.ISBN)
+			}
+			if got := strings.Join(isbns, " "); got != test.expected {
+				t.Errorf("sortBooks(%s) got (%s) wanted (%s)", test.sort, got, test.expected)
+			}
+		})
+	}
+
+	if _, err := entity.ParseBookSort("title,pages"); err == nil {
+		t.Errorf("ParseBookSort(title,pages) got no error wanted invalid sort key pages")
+	}
+}
+
+// byteSorter - a BookSorter ordering like N1QL does, by the bytes of the lower cased strings, and recording its use
+type byteSorter struct {
+	memoryBooks
+	used *bool
+}
+
+func (b byteSorter) GetAllSorted(ctx context.Context, keys entity.BookSort) ([]entity.Book, error) {
+	*b.used = true
+	books, _ := b.GetAll(ctx)
+	sort.SliceStable(books, func(i, j int) bool {
+		for _, key := range keys {
+			x, y := strings.ToLower(books[i].Title), strings.ToLower(books[j].Title)
+			if key.Field == entity.SortFinished {
+				x, y = strconv.FormatInt(books[i].Finished, 10), strconv.FormatInt(books[j].Finished, 10)
+			}
+			if x != y {
+				return (x < y) != key.Descending
+			}
+		}
+		return books[i].ISBN < books[j].ISBN
+	})
+	return books, nil
+}
+
+func TestListBooksCollation(t *testing.T) {
+	books := memoryBooks{
+		"0": {ISBN: "0", Title: "Zola", Finished: 1},
+		"1": {ISBN: "1", Title: "Émile", Finished: 2},
+		"2": {ISBN: "2", Title: "apple", Finished: 3},
+	}
+
+	tests := []struct {
+		sort     string
+		expected string
+		sorter   bool
+	}{
+		{"title", "2 1 0", false},
+		{"-finished,title", "2 1 0", false},
+		{"-finished", "2 1 0", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sort, func(t *testing.T) {
+			var used bool
+			got, err := NewBookTracker(byteSorter{memoryBooks: books, used: &used}).ListBooks(context.Background(), test.sort, entity.BookFilter{})
+			if err != nil {
+				t.Fatalf("ListBooks(%s) error %s", test.sort, err.Error())
+			}
+			var isbns []string
+			for _, book := range got {
+				isbns = append(isbns, book.ISBN)
+			}
+			if order := strings.Join(isbns, " "); order != test.expected || used != test.sorter {
+				t.Errorf("ListBooks(%s) got (%s, sorter %t) wanted (%s, sorter %t)", test.sort, order, used, test.expected, test.sorter)
+			}
+		})
+	}
+}
+
+func TestPageWindow(t *testing.T) {
+	tests := []struct {
+		page     entity.Page
+		total    int
+		from, to int
+	}{
+		{entity.Page{Offset: 0, Limit: 20}, 5, 0, 5},
+		{entity.Page{Offset: 2, Limit: 2}, 5, 2, 4},
+		{entity.Page{Offset: 10, Limit: 2}, 5, 5, 5},
+		{entity.Page{Offset: 1}, 5, 1, 5},
+	}
+	for _, test := range tests {
+		if from, to := test.page.Window(test.total); from != test.from || to != test.to {
+			t.Errorf("Window(%+v, %d) got (%d, %d) wanted (%d, %d)", test.page, test.total, from, to, test.from, test.to)
+		}
+	}
+}
+
+func TestValidateGenre(t *testing.T) {
+	genres := []entity.Genre{
+		{Name: "Fiction"},
+		{Name: "Science Fiction", Aliases: []string{"Sci-Fi"}, Parent: "Fiction"},
+	}
+	tests := []struct {
+		genre    entity.Genre
+		replaces string
+		err      string
+	}{
+		{entity.Genre{Name: "Fantasy", Parent: "fiction"}, "", ""},
+		{entity.Genre{Name: "SciFi", Aliases: []string{"sci-fi"}}, "", "genre sci-fi is already a spelling of genre Science Fiction"},
+		{entity.Genre{Name: "Fantasy", Parent: "Myth"}, "", "parent genre Myth not found"},
+		{entity.Genre{Name: "Fiction", Parent: "Sci-Fi"}, "fiction", "genre Fiction can not be an ancestor of itself"},
+		{entity.Genre{Name: "Science Fiction", Aliases: []string{"SF"}, Parent: "Fiction"}, "science fiction", ""},
+	}
+	for _, test := range tests {
+		err := validateGenre(test.genre, test.replaces, genres)
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("validateGenre(%+v) got (%v) wanted (%s)", test.genre, err, test.err)
+		}
+	}
+}
+
+// memoryBooks - a BookRepository keeping the books in a map
+type memoryBooks map[string]entity.Book
+
+func (m memoryBooks) Upsert(_ context.Context, id string, doc interface{}) error {
+	m[id] = doc.(entity.Book)
+	return nil
+}
+
+func (m memoryBooks) GetAll(context.Context) ([]entity.Book, error) {
+	var books []entity.Book
+	for _, book := range m {
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+func (m memoryBooks) Get(_ context.Context, id string) (*entity.Book, error) {
+	book, ok := m[id]
+	if !ok {
+		return nil, errors.New("document not found")
+	}
+	return &book, nil
+}
+
+// statusCounts - a StatusAggregator over the books of the map
+type statusCounts struct {
+	memoryBooks
+}
+
+func (s statusCounts) StatusCounts(context.Context) ([]entity.NamedCount, error) {
+	counts := map[string]int{}
+	for _, book := range s.memoryBooks {
+		if book.Status != "" {
+			counts[strings.ToUpper(book.Status)]++
+		}
+	}
+	var named []entity.NamedCount
+	for name, count := range counts {
+		named = append(named, entity.NamedCount{Name: name, Count: count})
+	}
+	return named, nil
+}
+
+func TestGroupBooksByStatus(t *testing.T) {
+	books := memoryBooks{
+		"0": {ISBN: "0", Status: "UNREAD"},
+		"1": {ISBN: "1", Status: "finished"},
+		"2": {ISBN: "2", Status: "FINISHED"},
+		"3": {ISBN: "3"},
+	}
+	expected := []entity.BookGroup{{Group: "FINISHED", Count: 2, Total: 2}, {Group: "UNREAD", Count: 1, Total: 1}}
+
+	for name, storage := range map[string]BookRepository{"aggregated": statusCounts{books}, "in memory": books} {
+		got, err := NewBookTracker(storage).GroupBooks(context.Background(), entity.GroupByStatus, entity.GroupOptions{CountsOnly: true})
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			t.Errorf("GroupBooks(status) %s got (%+v, %v) wanted (%+v)", name, got, err, expected)
+		}
+	}
+}
+
+func TestFinishedTimestamp(t *testing.T) {
+	finished := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tests := []struct {
+		testName string
+		existing *entity.Book
+		book     entity.Book
+		add      bool
+		want     func(int64) bool
+	}{
+		{"AddBook: should pass(created as finished)", nil,
+			entity.Book{Status: "FINISHED"}, true, func(got int64) bool { return got > finished }},
+		{"AddBook: should pass(replacing a finished book)", &entity.Book{Status: "FINISHED", Finished: finished},
+			entity.Book{Status: "finished"}, true, func(got int64) bool { return got == finished }},
+		{"AddBook: should pass(unread)", nil,
+			entity.Book{Status: "UNREAD"}, true, func(got int64) bool { return got == 0 }},
+		{"UpdateBook: should pass(edit of a finished book)", &entity.Book{Status: "FINISHED", Finished: finished},
+			entity.Book{Status: "FINISHED"}, false, func(got int64) bool { return got == finished }},
+		{"UpdateBook: should pass(change to finished)", &entity.Book{Status: "IN PROGRESS"},
+			entity.Book{Status: "FINISHED"}, false, func(got int64) bool { return got > finished }},
+		{"UpdateBook: should pass(supplied finished)", &entity.Book{Status: "FINISHED", Finished: finished},
+			entity.Book{Status: "FINISHED", Finished: finished + 1}, false, func(got int64) bool { return got == finished+1 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			books := memoryBooks{}
+			if test.existing != nil {
+				test.existing.ISBN = "TEST-ISBN"
+				books["TEST-ISBN"] = *test.existing
+			}
+			svc := NewBookTracker(books)
+			book := test.book
+			book.ISBN = "TEST-ISBN"
+
+			var err error
+			if test.add {
+				err = svc.AddBook(context.Background(), book)
+			} else {
+				err = svc.UpdateBook(context.Background(), book)
+			}
+			if err != nil {
+				t.Fatalf("%s expected(nil) got (%v)", test.testName, err)
+			}
+			if got := books["TEST-ISBN"].Finished; !test.want(got) {
+				t.Errorf("%s unexpected finished got (%d)", test.testName, got)
+			}
+			if got := books["TEST-ISBN"].Status; got != strings.ToUpper(test.book.Status) {
+				t.Errorf("%s expected(%s) got (%s)", test.testName, strings.ToUpper(test.book.Status), got)
+			}
+		})
+	}
+}
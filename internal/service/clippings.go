@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const (
+	clippingSeparator = "=========="
+	clippingHighlight = "highlight"
+	clippingNote      = "note"
+	clippingBookmark  = "bookmark"
+
+	// a title is matched on its own above titleMatch, or together with the author above titleAuthorMatch
+	titleMatch       = 0.9
+	titleAuthorMatch = 0.75
+	authorMatch      = 0.8
+	maxClippingLine  = 1024 * 1024
+)
+
+var (
+	clippingKind     = regexp.MustCompile(`(?i)^-\s*your\s+(\w+)`)
+	clippingPage     = regexp.MustCompile(`(?i)\bpage\s+(\d+)`)
+	clippingLocation = regexp.MustCompile(`(?i)\blocation\s+(\d+(?:-\d+)?)`)
+	clippingAdded    = regexp.MustCompile(`(?i)added on\s+(.+)$`)
+	clippingAuthor   = regexp.MustCompile(`^(.*)\(([^()]*)\)\s*$`)
+)
+
+// ParseClippings - parses a Kindle "My Clippings.txt" file. Entries are separated by a line of "=" and made of
+// a "Title (Author)" line, a "- Your Highlight on page 12 | Location 170-172 | Added on ..." line and the text
+func ParseClippings(r io.Reader) ([]entity.Clipping, error) {
+	var clippings []entity.Clipping
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxClippingLine)
+	for scanner.Scan() {
+		// every entry written by the device may start with a byte order mark
+		line := strings.TrimPrefix(strings.TrimRight(scanner.Text(), "\r"), "\ufeff")
+		if strings.TrimSpace(line) == clippingSeparator {
+			if clipping, ok := parseClipping(lines); ok {
+				clippings = append(clippings, clipping)
+			}
+			lines = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if clipping, ok := parseClipping(lines); ok {
+		clippings = append(clippings, clipping)
+	}
+	return clippings, scanner.Err()
+}
+
+func parseClipping(lines []string) (entity.Clipping, bool) {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) < 2 {
+		return entity.Clipping{}, false
+	}
+	kind := clippingKind.FindStringSubmatch(lines[1])
+	if kind == nil {
+		return entity.Clipping{}, false
+	}
+
+	clipping := entity.Clipping{Kind: strings.ToLower(kind[1]), Text: strings.TrimSpace(strings.Join(lines[2:], "\n"))}
+	clipping.Title, clipping.Author = splitTitleAuthor(lines[0])
+	if page := clippingPage.FindStringSubmatch(lines[1]); page != nil {
+		clipping.Page, _ = strconv.Atoi(page[1])
+	}
+	if location := clippingLocation.FindStringSubmatch(lines[1]); location != nil {
+		clipping.Location = location[1]
+	}
+	if added := clippingAdded.FindStringSubmatch(lines[1]); added != nil {
+		clipping.Added = strings.TrimSpace(added[1])
+	}
+	return clipping, true
+}
+
+// splitTitleAuthor - the author is the last parenthesised part of the title line, when there is one
+func splitTitleAuthor(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if parts := clippingAuthor.FindStringSubmatch(line); parts != nil && strings.TrimSpace(parts[1]) != "" {
+		return strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+	}
+	return line, ""
+}
+
+// locationRange - "170-172" is 170 to 172. Older devices shorten the end, "1406-07" is 1406 to 1407
+func locationRange(location string) (int, int, bool) {
+	from, to, found := strings.Cut(location, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !found {
+		return start, start, true
+	}
+	if len(to) < len(from) {
+		to = from[:len(from)-len(to)] + to
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || end < start {
+		return start, start, true
+	}
+	return start, end, true
+}
+
+// bookMatcher - fuzzy matches clipping titles and authors to the tracked books
+type bookMatcher struct {
+	books  []entity.Book
+	titles []string
+}
+
+func newBookMatcher(books []entity.Book) *bookMatcher {
+	matcher := &bookMatcher{books: books}
+	for _, book := range books {
+		matcher.titles = append(matcher.titles, normalizeTitle(book.Title))
+	}
+	return matcher
+}
+
+// match - the best matching book, nil when no book is close enough
+func (m *bookMatcher) match(title string, author string) *entity.Book {
+	var best *entity.Book
+	bestScore := 0.0
+	normalized := normalizeTitle(title)
+
+	for i := range m.books {
+		score := similarity(normalized, m.titles[i])
+		authorMatches := author != "" && m.authorMatches(m.books[i], author)
+		if score < titleMatch && !(score >= titleAuthorMatch && authorMatches) {
+			continue
+		}
+		if authorMatches {
+			score += 1 - authorMatch
+		}
+		if score > bestScore {
+			best, bestScore = &m.books[i], score
+		}
+	}
+	return best
+}
+
+func (m *bookMatcher) authorMatches(book entity.Book, author string) bool {
+	names := []string{book.Author}
+	for _, contributor := range book.Authors {
+		names = append(names, contributor.Name)
+	}
+	for _, clipped := range strings.Split(author, ";") {
+		clipped = normalizeAuthor(clipped)
+		for _, name := range names {
+			if name != "" && similarity(clipped, normalizeAuthor(name)) >= authorMatch {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeTitle - lower case letters and digits of the title without its subtitle or parenthesised series
+func normalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	if i := strings.IndexAny(title, ":("); i > 0 {
+		title = title[:i]
+	}
+	return normalizeWords(title)
+}
+
+// normalizeAuthor - "Last, First" is "first last"
+func normalizeAuthor(name string) string {
+	if last, first, found := strings.Cut(name, ","); found {
+		name = first + " " + last
+	}
+	return normalizeWords(strings.ToLower(name))
+}
+
+func normalizeWords(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// similarity - 1 for equal strings, 0 for completely different ones(levenshtein distance over the longest length)
+func similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// minInt - the lowest of the values, the min builtin needs go 1.21
+func minInt(values ...int) int {
+	lowest := values[0]
+	for _, value := range values[1:] {
+		if value < lowest {
+			lowest = value
+		}
+	}
+	return lowest
+}
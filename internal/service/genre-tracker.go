@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+type GenreTracker interface {
+	CreateGenre(context.Context, entity.Genre) error
+	ListGenres(context.Context) ([]entity.Genre, error)
+	UpdateGenre(context.Context, string, entity.Genre) error
+	DeleteGenre(context.Context, string) error
+	RemapGenre(context.Context, entity.GenreRemap) (int, error)
+}
+
+type GenreRepository interface {
+	UpsertGenre(context.Context, string, interface{}) error
+	GetGenre(context.Context, string) (*entity.Genre, error)
+	GetAllGenres(context.Context) ([]entity.Genre, error)
+	RemoveGenre(context.Context, string) error
+	RemapGenre(context.Context, []string, string) (int, error)
+}
+
+// TaxonomyRepository is implemented by book repositories that also keep the genre taxonomy. Books of other
+// repositories are grouped by their genre regardless of case
+type TaxonomyRepository interface {
+	GetAllGenres(context.Context) ([]entity.Genre, error)
+}
+
+// GenreAggregator is implemented by repositories that can count and page the books of a genre natively(N1QL for
+// couchbase). Repositories without it fall back to a single pass over GetAll
+type GenreAggregator interface {
+	GenreCounts(context.Context) ([]entity.NamedCount, error)
+	GenreBooks(context.Context, []string, entity.Page) ([]entity.Book, int, error)
+}
+
+// StatusAggregator is implemented by repositories that can count the books of each status natively(N1QL for
+// couchbase). Repositories without it fall back to a single pass over GetAll
+type StatusAggregator interface {
+	StatusCounts(context.Context) ([]entity.NamedCount, error)
+}
+
+type genreTracker struct {
+	storage GenreRepository
+}
+
+func NewGenreTracker(gr GenreRepository) GenreTracker {
+	return &genreTracker{storage: gr}
+}
+
+func (svc *genreTracker) CreateGenre(ctx context.Context, genre entity.Genre) error {
+	genres, err := svc.storage.GetAllGenres(ctx)
+	if err != nil {
+		return err
+	}
+	genre = cleanGenre(genre)
+	if err = validateGenre(genre, "", genres); err != nil {
+		return err
+	}
+
+	genre.Parent = entity.NewGenreTaxonomy(genres).Canonical(genre.Parent)
+	genre.SetTrackingDetails()
+	if err = svc.storage.UpsertGenre(ctx, genre.Key(), genre); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField(logging.FieldGenre, genre.Name).Info("genre created")
+	return nil
+}
+
+// ListGenres - the taxonomy ordered by name
+func (svc *genreTracker) ListGenres(ctx context.Context) ([]entity.Genre, error) {
+	return svc.storage.GetAllGenres(ctx)
+}
+
+// UpdateGenre - replaces the aliases and parent of the genre. A renamed genre keeps its old name as an alias, so the
+// books are not lost, and its sub-genres follow it
+func (svc *genreTracker) UpdateGenre(ctx context.Context, name string, genre entity.Genre) error {
+	existing, err := svc.getGenre(ctx, name)
+	if err != nil {
+		return err
+	}
+	genres, err := svc.storage.GetAllGenres(ctx)
+	if err != nil {
+		return err
+	}
+
+	genre = cleanGenre(genre)
+	renamed := genre.Key() != existing.Key()
+	if renamed {
+		genre.Aliases = append(genre.Aliases, existing.Name)
+	}
+	if err = validateGenre(genre, existing.Key(), genres); err != nil {
+		return err
+	}
+
+	genre.Parent = entity.NewGenreTaxonomy(genres).Canonical(genre.Parent)
+	genre.Created = existing.Created
+	genre.CreatedBy = existing.CreatedBy
+	genre.UpdatedBy = existing.UpdatedBy
+	genre.Updated = time.Now().Unix()
+	if err = svc.storage.UpsertGenre(ctx, genre.Key(), genre); err != nil {
+		return err
+	}
+	if !renamed {
+		return nil
+	}
+
+	for _, child := range genres {
+		if entity.GenreKey(child.Parent) != existing.Key() || child.Key() == existing.Key() {
+			continue
+		}
+		child.Parent = genre.Name
+		child.Updated = time.Now().Unix()
+		if err = svc.storage.UpsertGenre(ctx, child.Key(), child); err != nil {
+			return err
+		}
+	}
+	return svc.storage.RemoveGenre(ctx, existing.Key())
+}
+
+// DeleteGenre - deletes a genre without sub-genres. Its books keep their genre, which is no longer managed
+func (svc *genreTracker) DeleteGenre(ctx context.Context, name string) error {
+	genre, err := svc.getGenre(ctx, name)
+	if err != nil {
+		return err
+	}
+	genres, err := svc.storage.GetAllGenres(ctx)
+	if err != nil {
+		return err
+	}
+	for _, child := range genres {
+		if child.Key() != genre.Key() && entity.GenreKey(child.Parent) == genre.Key() {
+			return entity.ConflictError{Message: fmt.Sprintf("genre %s has sub-genre %s", genre.Name, child.Name)}
+		}
+	}
+
+	if err = svc.storage.RemoveGenre(ctx, genre.Key()); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField(logging.FieldGenre, genre.Name).Info("genre deleted")
+	return nil
+}
+
+// RemapGenre - moves the books of every spelling of `from` to the canonical name of `to`
+func (svc *genreTracker) RemapGenre(ctx context.Context, remap entity.GenreRemap) (int, error) {
+	genres, err := svc.storage.GetAllGenres(ctx)
+	if err != nil {
+		return 0, err
+	}
+	taxonomy := entity.NewGenreTaxonomy(genres)
+
+	spellings := []string{entity.GenreKey(remap.From)}
+	for _, genre := range genres {
+		if genre.Key() == entity.GenreKey(taxonomy.Canonical(remap.From)) {
+			spellings = genre.Spellings()
+		}
+	}
+	to := taxonomy.Canonical(remap.To)
+
+	count, err := svc.storage.RemapGenre(ctx, spellings, to)
+	if err != nil {
+		return 0, err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{logging.FieldGenre: remap.From, "to": to, "books": count}).Info("genre remapped")
+	return count, nil
+}
+
+func (svc *genreTracker) getGenre(ctx context.Context, name string) (*entity.Genre, error) {
+	genre, err := svc.storage.GetGenre(ctx, entity.GenreKey(name))
+	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("genre %s not found", name)}
+	}
+	return genre, err
+}
+
+// cleanGenre - trims the names and drops empty aliases and aliases repeating the name
+func cleanGenre(genre entity.Genre) entity.Genre {
+	genre.Name = strings.TrimSpace(genre.Name)
+	genre.Parent = strings.TrimSpace(genre.Parent)
+	aliases := genre.Aliases
+	genre.Aliases = nil
+	seen := map[string]bool{genre.Key(): true}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if key := entity.GenreKey(alias); key != "" && !seen[key] {
+			seen[key] = true
+			genre.Aliases = append(genre.Aliases, alias)
+		}
+	}
+	return genre
+}
+
+// validateGenre - the name and aliases must not be spellings of another genre, and the parent must exist without
+// making the genre its own ancestor. `replaces` is the key of the genre being updated
+func validateGenre(genre entity.Genre, replaces string, genres []entity.Genre) error {
+	var others []entity.Genre
+	for _, other := range genres {
+		if other.Key() != "" && other.Key() != replaces {
+			others = append(others, other)
+		}
+	}
+
+	taken := map[string]string{}
+	for _, other := range others {
+		for _, spelling := range other.Spellings() {
+			taken[spelling] = other.Name
+		}
+	}
+	for _, spelling := range genre.Spellings() {
+		if owner, ok := taken[spelling]; ok {
+			return entity.ConflictError{Message: fmt.Sprintf("genre %s is already a spelling of genre %s", spelling, owner)}
+		}
+	}
+
+	if genre.Parent == "" {
+		return nil
+	}
+	taxonomy := entity.NewGenreTaxonomy(append(others, genre))
+	if _, ok := taken[entity.GenreKey(genre.Parent)]; !ok {
+		return entity.NotFoundError{Message: fmt.Sprintf("parent genre %s not found", genre.Parent)}
+	}
+	for _, ancestor := range append(taxonomy.Ancestors(genre.Parent), taxonomy.Canonical(genre.Parent)) {
+		if entity.GenreKey(ancestor) == genre.Key() {
+			return entity.ConflictError{Message: fmt.Sprintf("genre %s can not be an ancestor of itself", genre.Name)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+type GoalTracker interface {
+	SetGoal(context.Context, entity.Goal) error
+	ListGoals(context.Context) ([]entity.Goal, error)
+	GoalProgress(context.Context, int) (*entity.GoalProgress, error)
+}
+
+type GoalRepository interface {
+	UpsertGoal(context.Context, string, interface{}) error
+	GetGoal(context.Context, string) (*entity.Goal, error)
+	GetAllGoals(context.Context) ([]entity.Goal, error)
+}
+
+type goalTracker struct {
+	storage GoalRepository
+	books   BookTracker
+	now     func() time.Time
+}
+
+// NewGoalTracker - progress is computed from the reading stats of the books tracked by the BookTracker
+func NewGoalTracker(gr GoalRepository, books BookTracker) GoalTracker {
+	return &goalTracker{storage: gr, books: books, now: time.Now}
+}
+
+func (svc *goalTracker) SetGoal(ctx context.Context, goal entity.Goal) error {
+	id := strconv.Itoa(goal.Year)
+
+	existing, err := svc.getGoal(ctx, goal.Year)
+	switch err.(type) {
+	case nil:
+		goal.Created = existing.Created
+		goal.CreatedBy = existing.CreatedBy
+		goal.UpdatedBy = existing.UpdatedBy
+		goal.Updated = time.Now().Unix()
+	case entity.NotFoundError:
+		goal.SetTrackingDetails()
+	default:
+		return err
+	}
+
+	err = svc.storage.UpsertGoal(ctx, id, goal)
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField(logging.FieldYear, goal.Year).Info("reading goal saved")
+	return nil
+}
+
+func (svc *goalTracker) ListGoals(ctx context.Context) ([]entity.Goal, error) {
+	return svc.storage.GetAllGoals(ctx)
+}
+
+func (svc *goalTracker) GoalProgress(ctx context.Context, year int) (*entity.GoalProgress, error) {
+	goal, err := svc.getGoal(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	stats, err := svc.books.ReadingStats(ctx, entity.StatsFilter{From: start.Unix(), To: end.Unix() - 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return projectProgress(*goal, stats.BooksFinished, stats.PagesRead, start, end, svc.now()), nil
+}
+
+func (svc *goalTracker) getGoal(ctx context.Context, year int) (*entity.Goal, error) {
+	goal, err := svc.storage.GetGoal(ctx, strconv.Itoa(year))
+	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("goal for year %d not found", year)}
+	}
+	return goal, err
+}
+
+// projectProgress - compares the actual numbers against a linear pace over the year
+func projectProgress(goal entity.Goal, books, pages int, start, end, now time.Time) *entity.GoalProgress {
+	totalDays := end.Sub(start).Hours() / 24
+	elapsedDays := math.Min(math.Max(now.Sub(start).Hours()/24, 0), totalDays)
+	fraction := elapsedDays / totalDays
+
+	progress := &entity.GoalProgress{
+		Goal:          goal,
+		DaysElapsed:   int(elapsedDays),
+		DaysRemaining: int(math.Ceil(totalDays - elapsedDays)),
+	}
+	if goal.Books > 0 {
+		progress.Books = targetProgress(goal.Books, books, fraction)
+	}
+	if goal.Pages > 0 {
+		progress.Pages = targetProgress(goal.Pages, pages, fraction)
+	}
+	progress.Status = overallStatus(progress.Books, progress.Pages)
+
+	return progress
+}
+
+func targetProgress(target, actual int, fraction float64) *entity.TargetProgress {
+	progress := &entity.TargetProgress{
+		Target:    target,
+		Actual:    actual,
+		Percent:   math.Round(float64(actual)*1000/float64(target)) / 10,
+		Expected:  int(math.Round(float64(target) * fraction)),
+		Projected: actual,
+	}
+	if fraction > 0 {
+		progress.Projected = int(math.Round(float64(actual) / fraction))
+	}
+
+	switch {
+	case actual >= target:
+		progress.Status = entity.GoalCompleted
+	case fraction >= 1:
+		progress.Status = entity.GoalMissed
+	case actual >= progress.Expected:
+		progress.Status = entity.GoalOnTrack
+	default:
+		progress.Status = entity.GoalBehind
+	}
+	return progress
+}
+
+// overallStatus - the goal is only as good as its worst target
+func overallStatus(targets ...*entity.TargetProgress) string {
+	rank := map[string]int{entity.GoalCompleted: 0, entity.GoalOnTrack: 1, entity.GoalBehind: 2, entity.GoalMissed: 3}
+	status := entity.GoalCompleted
+	for _, target := range targets {
+		if target != nil && rank[target.Status] > rank[status] {
+			status = target.Status
+		}
+	}
+	return status
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+// hierarchy resolves group names to their canonical name and parents. Only genres have one(the genre taxonomy)
+type hierarchy interface {
+	Canonical(string) string
+	Parent(string) string
+	Ancestors(string) []string
+}
+
+// flat - the hierarchy of groupings without parents
+type flat struct{}
+
+func (flat) Canonical(name string) string { return strings.TrimSpace(name) }
+func (flat) Parent(string) string         { return "" }
+func (flat) Ancestors(string) []string    { return nil }
+
+// grouping - names lists the groups of a book, several for tags and co-authored books, none leaves it out
+type grouping struct {
+	names     func(entity.Book) []string
+	hierarchy hierarchy
+}
+
+// newGrouping - the grouping for one of entity.Groupings, false when it is not one of them
+func newGrouping(by string, taxonomy *entity.GenreTaxonomy) (grouping, bool) {
+	switch by {
+	case entity.GroupByGenre:
+		// books without a genre are grouped too, as the genre listing always did
+		return grouping{names: func(b entity.Book) []string { return []string{b.Genre} }, hierarchy: taxonomy}, true
+	case entity.GroupByAuthor:
+		return grouping{names: authorNames, hierarchy: flat{}}, true
+	case entity.GroupByStatus:
+		return grouping{names: func(b entity.Book) []string { return nonEmpty(strings.ToUpper(b.Status)) }, hierarchy: flat{}}, true
+	case entity.GroupByYearFinished:
+		return grouping{names: yearFinished, hierarchy: flat{}}, true
+	case entity.GroupByLanguage:
+		return grouping{names: func(b entity.Book) []string { return nonEmpty(b.Language) }, hierarchy: flat{}}, true
+	case entity.GroupByTag:
+		return grouping{names: func(b entity.Book) []string { return b.Tags }, hierarchy: flat{}}, true
+	}
+	return grouping{}, false
+}
+
+// authorNames - the contributors credited as author or co-author, the author string for books without them
+func authorNames(book entity.Book) []string {
+	var authors []string
+	for _, contributor := range book.Authors {
+		if contributor.Role == entity.RoleAuthor || contributor.Role == entity.RoleCoAuthor {
+			authors = append(authors, contributor.Name)
+		}
+	}
+	if len(authors) == 0 {
+		return nonEmpty(book.Author)
+	}
+	return authors
+}
+
+func yearFinished(book entity.Book) []string {
+	if book.Finished <= 0 {
+		return nil
+	}
+	return []string{strconv.Itoa(time.Unix(book.Finished, 0).UTC().Year())}
+}
+
+func nonEmpty(name string) []string {
+	if strings.TrimSpace(name) == "" {
+		return nil
+	}
+	return []string{name}
+}
+
+// bookGroups - accumulates book counts into canonical groups, rolling them up to the ancestors. Names missing from
+// the hierarchy are grouped regardless of case under the first spelling seen
+type bookGroups struct {
+	hierarchy hierarchy
+	options   entity.GroupOptions
+	groups    []entity.BookGroup
+	index     map[string]int
+}
+
+func newBookGroups(hierarchy hierarchy, options entity.GroupOptions) *bookGroups {
+	return &bookGroups{hierarchy: hierarchy, options: options, index: map[string]int{}}
+}
+
+func (g *bookGroups) group(name string) int {
+	key := entity.GenreKey(name)
+	i, ok := g.index[key]
+	if !ok {
+		i = len(g.groups)
+		g.index[key] = i
+		g.groups = append(g.groups, entity.BookGroup{Group: name, Parent: g.hierarchy.Parent(name)})
+	}
+	return i
+}
+
+// add - count books of the group. The book is kept in the group unless only counts or a full sample are wanted
+func (g *bookGroups) add(name string, count int, book *entity.Book) {
+	i := g.group(g.hierarchy.Canonical(name))
+	g.groups[i].Count += count
+	g.groups[i].Total += count
+	if book != nil && !g.options.CountsOnly && (g.options.Sample <= 0 || len(g.groups[i].Books) < g.options.Sample) {
+		g.groups[i].Books = append(g.groups[i].Books, *book)
+	}
+	for _, ancestor := range g.hierarchy.Ancestors(name) {
+		g.groups[g.group(ancestor)].Total += count
+	}
+}
+
+// sorted - the groups ordered by name regardless of case
+func (g *bookGroups) sorted() []entity.BookGroup {
+	sort.SliceStable(g.groups, func(i, j int) bool {
+		return entity.GenreKey(g.groups[i].Group) < entity.GenreKey(g.groups[j].Group)
+	})
+	return g.groups
+}
+
+// groupBooks - single pass over the books
+func groupBooks(books []entity.Book, grouping grouping, options entity.GroupOptions) []entity.BookGroup {
+	groups := newBookGroups(grouping.hierarchy, options)
+	for i := range books {
+		for _, name := range grouping.names(books[i]) {
+			groups.add(name, 1, &books[i])
+		}
+	}
+	return groups.sorted()
+}
+
+// groupByGenre - the book groups in the shape of the genre listing
+func groupByGenre(books []entity.Book, taxonomy *entity.GenreTaxonomy, options entity.GroupOptions) []entity.BooksByGenre {
+	genres, _ := newGrouping(entity.GroupByGenre, taxonomy)
+	return booksByGenre(groupBooks(books, genres, options))
+}
+
+// countByGenre - folds the per spelling counts of the repository into the canonical genres
+func countByGenre(counts []entity.NamedCount, taxonomy *entity.GenreTaxonomy) []entity.BooksByGenre {
+	groups := newBookGroups(taxonomy, entity.GroupOptions{CountsOnly: true})
+	for _, count := range counts {
+		groups.add(count.Name, count.Count, nil)
+	}
+	return booksByGenre(groups.sorted())
+}
+
+// countByStatus - the status groups of the per status counts of the repository
+func countByStatus(counts []entity.NamedCount) []entity.BookGroup {
+	groups := newBookGroups(flat{}, entity.GroupOptions{CountsOnly: true})
+	for _, count := range counts {
+		groups.add(count.Name, count.Count, nil)
+	}
+	return groups.sorted()
+}
+
+// groupByTag - the book groups in the shape of the tag listing
+func groupByTag(books []entity.Book) []entity.BooksByTag {
+	tags, _ := newGrouping(entity.GroupByTag, nil)
+	var byTag []entity.BooksByTag
+	for _, group := range groupBooks(books, tags, entity.GroupOptions{}) {
+		byTag = append(byTag, entity.BooksByTag{Tag: group.Group, Count: group.Count, Books: group.Books})
+	}
+	return byTag
+}
+
+func booksByGenre(groups []entity.BookGroup) []entity.BooksByGenre {
+	var genres []entity.BooksByGenre
+	for _, group := range groups {
+		genres = append(genres, entity.BooksByGenre{Genre: group.Group, Parent: group.Parent, Count: group.Count,
+			Total: group.Total, Books: group.Books})
+	}
+	return genres
+}
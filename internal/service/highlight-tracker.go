@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+const kindleKeyPrefix = "kindle::"
+
+type HighlightTracker interface {
+	AddHighlight(context.Context, string, entity.Highlight) (*entity.Highlight, error)
+	ListHighlights(context.Context, string) ([]entity.Highlight, error)
+	UpdateHighlight(context.Context, string, string, entity.Highlight) (*entity.Highlight, error)
+	DeleteHighlight(context.Context, string, string) error
+	ImportClippings(context.Context, io.Reader) (*entity.ImportResult, error)
+	ExportMarkdown(context.Context) ([]byte, error)
+}
+
+type HighlightRepository interface {
+	UpsertHighlight(context.Context, string, interface{}) error
+	GetHighlight(context.Context, string) (*entity.Highlight, error)
+	GetHighlights(context.Context, string) ([]entity.Highlight, error)
+	GetAllHighlights(context.Context) ([]entity.Highlight, error)
+	RemoveHighlight(context.Context, string) error
+}
+
+type highlightTracker struct {
+	storage HighlightRepository
+	books   BookTracker
+}
+
+// NewHighlightTracker - highlights belong to the books tracked by the BookTracker
+func NewHighlightTracker(hr HighlightRepository, books BookTracker) HighlightTracker {
+	return &highlightTracker{storage: hr, books: books}
+}
+
+func (svc *highlightTracker) AddHighlight(ctx context.Context, id string, highlight entity.Highlight) (*entity.Highlight, error) {
+	if _, err := svc.books.GetBook(ctx, id); err != nil {
+		return nil, err
+	}
+
+	highlight.ID = uuid.New().String()
+	highlight.ISBN = id
+	highlight.Color = strings.ToUpper(highlight.Color)
+	highlight.Source = entity.SourceManual
+	highlight.SetTrackingDetails()
+	if err := svc.storage.UpsertHighlight(ctx, highlight.ID, highlight); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{logging.FieldISBN: id, logging.FieldHighlightID: highlight.ID}).Info("highlight added")
+	return &highlight, nil
+}
+
+// ListHighlights - lists the highlights of the book ordered by page and location
+func (svc *highlightTracker) ListHighlights(ctx context.Context, id string) ([]entity.Highlight, error) {
+	if _, err := svc.books.GetBook(ctx, id); err != nil {
+		return nil, err
+	}
+	highlights, err := svc.storage.GetHighlights(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sortHighlights(highlights)
+	return highlights, nil
+}
+
+func (svc *highlightTracker) UpdateHighlight(ctx context.Context, id string, highlightID string, highlight entity.Highlight) (*entity.Highlight, error) {
+	existing, err := svc.getHighlight(ctx, id, highlightID)
+	if err != nil {
+		return nil, err
+	}
+
+	highlight.ID = highlightID
+	highlight.ISBN = id
+	highlight.Color = strings.ToUpper(highlight.Color)
+	highlight.Source = existing.Source
+	highlight.Created = existing.Created
+	highlight.CreatedBy = existing.CreatedBy
+	highlight.UpdatedBy = existing.UpdatedBy
+	highlight.Updated = time.Now().Unix()
+	if err = svc.storage.UpsertHighlight(ctx, highlightID, highlight); err != nil {
+		return nil, err
+	}
+	return &highlight, nil
+}
+
+func (svc *highlightTracker) DeleteHighlight(ctx context.Context, id string, highlightID string) error {
+	if _, err := svc.getHighlight(ctx, id, highlightID); err != nil {
+		return err
+	}
+	return svc.storage.RemoveHighlight(ctx, highlightID)
+}
+
+// ImportClippings - imports the highlights of a Kindle clippings file into the matching books. Notes are attached
+// to the highlight they were written on. Highlights are keyed on book, location and text, so importing the same
+// file again only reports duplicates
+func (svc *highlightTracker) ImportClippings(ctx context.Context, r io.Reader) (*entity.ImportResult, error) {
+	clippings, err := ParseClippings(r)
+	if err != nil {
+		return nil, err
+	}
+	books, err := svc.books.ListBooks(ctx, "", entity.BookFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.ImportResult{}
+	highlights := importHighlights(clippings, newBookMatcher(books), result)
+	seen := map[string]bool{}
+	for _, highlight := range highlights {
+		if seen[highlight.ID] {
+			result.Duplicates++
+			continue
+		}
+		seen[highlight.ID] = true
+
+		_, err = svc.storage.GetHighlight(ctx, highlight.ID)
+		if err == nil {
+			result.Duplicates++
+			continue
+		}
+		if !strings.Contains(err.Error(), documentNotFoundError) {
+			return nil, err
+		}
+		highlight.SetTrackingDetails()
+		if err = svc.storage.UpsertHighlight(ctx, highlight.ID, highlight); err != nil {
+			return nil, err
+		}
+		result.Imported++
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{"imported": result.Imported, "duplicates": result.Duplicates,
+		"skipped": result.Skipped, "unmatched_books": len(result.Unmatched)}).Info("clippings imported")
+	return result, nil
+}
+
+// importHighlights - matches the clippings to the books and turns them into highlights. Bookmarks and empty
+// clippings are skipped, clippings of unknown books are reported per title
+func importHighlights(clippings []entity.Clipping, matcher *bookMatcher, result *entity.ImportResult) []entity.Highlight {
+	var highlights, notes []entity.Highlight
+	unmatched := map[string]int{}
+
+	for _, clipping := range clippings {
+		if clipping.Kind == clippingBookmark || clipping.Text == "" {
+			result.Skipped++
+			continue
+		}
+		book := matcher.match(clipping.Title, clipping.Author)
+		if book == nil {
+			key := clipping.Title + "\x00" + clipping.Author
+			if _, ok := unmatched[key]; !ok {
+				unmatched[key] = len(result.Unmatched)
+				result.Unmatched = append(result.Unmatched, entity.UnmatchedBook{Title: clipping.Title, Author: clipping.Author})
+			}
+			result.Unmatched[unmatched[key]].Count++
+			continue
+		}
+
+		highlight := entity.Highlight{
+			ID:       highlightKey(book.ISBN, clipping.Location, clipping.Page, clipping.Text),
+			ISBN:     book.ISBN,
+			Text:     clipping.Text,
+			Page:     clipping.Page,
+			Location: clipping.Location,
+			Color:    entity.ColorYellow,
+			Source:   entity.SourceKindle,
+		}
+		if clipping.Kind == clippingNote {
+			notes = append(notes, highlight)
+			continue
+		}
+		highlights = append(highlights, highlight)
+	}
+
+	for _, note := range notes {
+		if i := highlightAt(highlights, note); i >= 0 {
+			if highlights[i].Note != "" {
+				highlights[i].Note += "\n"
+			}
+			highlights[i].Note += note.Text
+			continue
+		}
+		// a note without a highlight is kept as its own highlight
+		note.Color = ""
+		highlights = append(highlights, note)
+	}
+	return highlights
+}
+
+// highlightAt - the highlight of the same book whose location covers the note(the same page without locations)
+func highlightAt(highlights []entity.Highlight, note entity.Highlight) int {
+	at, _, ok := locationRange(note.Location)
+	for i, highlight := range highlights {
+		if highlight.ISBN != note.ISBN {
+			continue
+		}
+		if !ok {
+			if note.Page > 0 && highlight.Page == note.Page {
+				return i
+			}
+			continue
+		}
+		if start, end, found := locationRange(highlight.Location); found && start <= at && at <= end {
+			return i
+		}
+	}
+	return -1
+}
+
+// highlightKey - deterministic document key of an imported highlight
+func highlightKey(isbn string, location string, page int, text string) string {
+	hash := sha1.Sum([]byte(strings.Join([]string{isbn, location, strconv.Itoa(page), normalizeWords(strings.ToLower(text))}, "|")))
+	return kindleKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+// ExportMarkdown - all highlights as a Markdown document with a section per book, books ordered by title
+func (svc *highlightTracker) ExportMarkdown(ctx context.Context) ([]byte, error) {
+	books, err := svc.books.ListBooks(ctx, consts.Title, entity.BookFilter{})
+	if err != nil {
+		return nil, err
+	}
+	highlights, err := svc.storage.GetAllHighlights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return highlightsMarkdown(books, highlights), nil
+}
+
+func highlightsMarkdown(books []entity.Book, highlights []entity.Highlight) []byte {
+	byBook := map[string][]entity.Highlight{}
+	for _, highlight := range highlights {
+		byBook[highlight.ISBN] = append(byBook[highlight.ISBN], highlight)
+	}
+
+	var md bytes.Buffer
+	md.WriteString("# Highlights\n")
+	for _, book := range books {
+		bookHighlights := byBook[book.ISBN]
+		if len(bookHighlights) == 0 {
+			continue
+		}
+		sortHighlights(bookHighlights)
+
+		md.WriteString("\n## " + book.Title)
+		if book.Author != "" {
+			md.WriteString(" - " + book.Author)
+		}
+		md.WriteString("\n")
+		for _, highlight := range bookHighlights {
+			md.WriteString("\n> " + strings.ReplaceAll(highlight.Text, "\n", "\n> ") + "\n")
+			if position := highlightPosition(highlight); position != "" {
+				md.WriteString("\n" + position + "\n")
+			}
+			if highlight.Note != "" {
+				md.WriteString("\n**Note:** " + strings.ReplaceAll(highlight.Note, "\n", "  \n") + "\n")
+			}
+		}
+	}
+	return md.Bytes()
+}
+
+func highlightPosition(highlight entity.Highlight) string {
+	var parts []string
+	if highlight.Page > 0 {
+		parts = append(parts, fmt.Sprintf("page %d", highlight.Page))
+	}
+	if highlight.Location != "" {
+		parts = append(parts, "location "+highlight.Location)
+	}
+	if highlight.Color != "" {
+		parts = append(parts, strings.ToLower(highlight.Color))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "_" + strings.Join(parts, " · ") + "_"
+}
+
+func sortHighlights(highlights []entity.Highlight) {
+	sort.SliceStable(highlights, func(i, j int) bool {
+		if highlights[i].Page != highlights[j].Page {
+			return highlights[i].Page < highlights[j].Page
+		}
+		a, _, _ := locationRange(highlights[i].Location)
+		b, _, _ := locationRange(highlights[j].Location)
+		return a < b
+	})
+}
+
+// getHighlight - highlights are only reachable through the book they belong to
+func (svc *highlightTracker) getHighlight(ctx context.Context, id string, highlightID string) (*entity.Highlight, error) {
+	highlight, err := svc.storage.GetHighlight(ctx, highlightID)
+	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("highlight %s of book %s not found", highlightID, id)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if highlight.ISBN != "" && highlight.ISBN != id {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("highlight %s of book %s not found", highlightID, id)}
+	}
+	return highlight, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const (
+	unreadStatus = "UNREAD"
+
+	// weights of the scoring model. A pick scores at most genreWeight+authorWeight+seriesWeight+waitingWeight
+	genreWeight   = 3.0
+	authorWeight  = 4.0
+	seriesWeight  = 5.0
+	waitingWeight = 2.0
+
+	// finished books rated above likedRating shape the preferences, unrated ones count for unratedAffinity
+	likedRating     = 3.0
+	unratedAffinity = 0.5
+	minWaitingDays  = 30
+	daysPerYear     = 365
+	defaultPicks    = 10
+)
+
+// moods - keywords looked up in the genre and tags of the books. Any other mood is looked up as it is
+var moods = map[string][]string{
+	"light":       {"comedy", "humor", "humour", "romance", "cozy", "feel-good"},
+	"dark":        {"horror", "thriller", "crime", "noir", "gothic"},
+	"thoughtful":  {"literary", "philosophy", "history", "biography", "memoir", "essays"},
+	"adventurous": {"adventure", "fantasy", "science fiction", "sci-fi", "action"},
+	"curious":     {"science", "non-fiction", "nonfiction", "popular science", "travel"},
+}
+
+type Recommender interface {
+	Recommend(context.Context, entity.RecommendationFilter) ([]entity.Recommendation, error)
+}
+
+type recommender struct {
+	books BookTracker
+	now   func() time.Time
+}
+
+// NewRecommender - ranks the UNREAD books of the BookTracker locally, nothing is sent to other services
+func NewRecommender(books BookTracker) Recommender {
+	return &recommender{books: books, now: time.Now}
+}
+
+func (svc *recommender) Recommend(ctx context.Context, filter entity.RecommendationFilter) ([]entity.Recommendation, error) {
+	books, err := svc.books.ListBooks(ctx, "", entity.BookFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return recommend(books, filter, svc.now()), nil
+}
+
+// readingProfile - affinity to genres and authors learned from the finished books, and the series positions read
+type readingProfile struct {
+	genres      map[string]float64
+	authors     map[string]float64
+	genreCount  map[string]int
+	authorCount map[string]int
+	maxGenre    float64
+	maxAuthor   float64
+	series      map[string][]seriesEntry
+}
+
+type seriesEntry struct {
+	position float64
+	finished bool
+}
+
+// recommend - scores every UNREAD book matching the filter on genre and author affinity, series continuity and the
+// time it has been waiting. Picks are ordered by score, then by the longest waiting
+func recommend(books []entity.Book, filter entity.RecommendationFilter, now time.Time) []entity.Recommendation {
+	profile := newReadingProfile(books)
+	var picks []entity.Recommendation
+
+	for _, book := range books {
+		if strings.ToUpper(book.Status) != unreadStatus || !matchesLength(book, filter.Length) || !matchesMood(book, filter.Mood) {
+			continue
+		}
+		picks = append(picks, profile.score(book, now))
+	}
+
+	sort.SliceStable(picks, func(i, j int) bool {
+		if picks[i].Score != picks[j].Score {
+			return picks[i].Score > picks[j].Score
+		}
+		if picks[i].Book.Created != picks[j].Book.Created {
+			return picks[i].Book.Created < picks[j].Book.Created
+		}
+		return picks[i].Book.ISBN < picks[j].Book.ISBN
+	})
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPicks
+	}
+	if len(picks) > limit {
+		picks = picks[:limit]
+	}
+	return picks
+}
+
+func newReadingProfile(books []entity.Book) *readingProfile {
+	profile := &readingProfile{
+		genres:      map[string]float64{},
+		authors:     map[string]float64{},
+		genreCount:  map[string]int{},
+		authorCount: map[string]int{},
+		series:      map[string][]seriesEntry{},
+	}
+
+	for _, book := range books {
+		finished := strings.ToUpper(book.Status) == finishedStatus
+		if book.Series != nil && book.Series.Position > 0 {
+			key := strings.ToLower(book.Series.Name)
+			profile.series[key] = append(profile.series[key], seriesEntry{position: book.Series.Position, finished: finished})
+		}
+		if !finished {
+			continue
+		}
+
+		affinity := bookAffinity(book.Rating)
+		if affinity <= 0 {
+			continue
+		}
+		genre := strings.ToLower(book.Genre)
+		profile.genres[genre] += affinity
+		profile.genreCount[genre]++
+		profile.maxGenre = math.Max(profile.maxGenre, profile.genres[genre])
+		for _, author := range bookAuthors(book) {
+			profile.authors[author] += affinity
+			profile.authorCount[author]++
+			profile.maxAuthor = math.Max(profile.maxAuthor, profile.authors[author])
+		}
+	}
+	return profile
+}
+
+// bookAffinity - 0.5 to 2 for books rated above 3 stars, a little for unrated ones and nothing for disliked ones
+func bookAffinity(rating float64) float64 {
+	if rating == 0 {
+		return unratedAffinity
+	}
+	return math.Max(rating-likedRating, 0)
+}
+
+func (p *readingProfile) score(book entity.Book, now time.Time) entity.Recommendation {
+	pick := entity.Recommendation{Book: book, Reasons: []string{}}
+
+	genre := strings.ToLower(book.Genre)
+	if affinity := p.genres[genre]; affinity > 0 {
+		pick.Score += genreWeight * affinity / p.maxGenre
+		pick.Reasons = append(pick.Reasons, fmt.Sprintf("you enjoyed %d finished %s book(s)", p.genreCount[genre], book.Genre))
+	}
+
+	bestAuthor, bestAffinity := "", 0.0
+	for _, author := range bookAuthors(book) {
+		if p.authors[author] > bestAffinity {
+			bestAuthor, bestAffinity = author, p.authors[author]
+		}
+	}
+	if bestAffinity > 0 {
+		pick.Score += authorWeight * bestAffinity / p.maxAuthor
+		pick.Reasons = append(pick.Reasons, fmt.Sprintf("you enjoyed %d book(s) by %s", p.authorCount[bestAuthor], authorName(book, bestAuthor)))
+	}
+
+	if book.Series != nil && book.Series.Position > 0 {
+		score, reason := p.seriesContinuity(*book.Series)
+		pick.Score += score
+		if reason != "" {
+			pick.Reasons = append(pick.Reasons, reason)
+		}
+	}
+
+	if book.Created > 0 {
+		days := now.Sub(time.Unix(book.Created, 0)).Hours() / 24
+		if days >= minWaitingDays {
+			pick.Score += waitingWeight * math.Min(days/daysPerYear, 1)
+			pick.Reasons = append(pick.Reasons, fmt.Sprintf("waiting on your list for %d days", int(days)))
+		}
+	}
+
+	pick.Score = math.Round(pick.Score*100) / 100
+	return pick
+}
+
+// seriesContinuity - the next book of a series where all earlier books are finished is favoured, a book with
+// unread earlier books in the series is held back
+func (p *readingProfile) seriesContinuity(series entity.Series) (float64, string) {
+	earlier, finished, last := 0, 0, 0.0
+	for _, entry := range p.series[strings.ToLower(series.Name)] {
+		if entry.position >= series.Position {
+			continue
+		}
+		earlier++
+		if entry.finished {
+			finished++
+			last = math.Max(last, entry.position)
+		}
+	}
+
+	switch {
+	case earlier == 0:
+		return 0, ""
+	case finished == earlier:
+		return seriesWeight, fmt.Sprintf("next in the %s series after #%g", series.Name, last)
+	}
+	return -seriesWeight, fmt.Sprintf("%d earlier book(s) of the %s series are not finished", earlier-finished, series.Name)
+}
+
+func matchesLength(book entity.Book, length string) bool {
+	return length == "" || book.Length() == strings.ToLower(length)
+}
+
+// matchesMood - the genre or a tag of the book contains one of the keywords of the mood
+func matchesMood(book entity.Book, mood string) bool {
+	if mood == "" {
+		return true
+	}
+	mood = strings.ToLower(strings.TrimSpace(mood))
+	keywords, ok := moods[mood]
+	if !ok {
+		keywords = []string{mood}
+	}
+
+	for _, keyword := range keywords {
+		if strings.Contains(strings.ToLower(book.Genre), keyword) {
+			return true
+		}
+		for _, tag := range book.Tags {
+			if strings.Contains(strings.ToLower(tag), keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bookAuthors - lower case names of the authors and co-authors, or the author string
+func bookAuthors(book entity.Book) []string {
+	var authors []string
+	for _, contributor := range book.Authors {
+		if contributor.Role == entity.RoleAuthor || contributor.Role == entity.RoleCoAuthor {
+			authors = append(authors, strings.ToLower(contributor.Name))
+		}
+	}
+	if len(authors) == 0 && book.Author != "" {
+		authors = append(authors, strings.ToLower(book.Author))
+	}
+	return authors
+}
+
+func authorName(book entity.Book, author string) string {
+	for _, contributor := range book.Authors {
+		if strings.ToLower(contributor.Name) == author {
+			return contributor.Name
+		}
+	}
+	return book.Author
+}
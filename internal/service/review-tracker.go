@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/consts"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+// ReviewTracker manages the private review and notes of a book. They are never part of the book resource,
+// only of the export
+type ReviewTracker interface {
+	SetReview(context.Context, string, entity.Review) (*entity.Review, error)
+	GetReview(context.Context, string) (*entity.Review, error)
+	DeleteReview(context.Context, string) error
+	AddNote(context.Context, string, entity.Note) (*entity.Note, error)
+	ListNotes(context.Context, string) ([]entity.Note, error)
+	UpdateNote(context.Context, string, string, entity.Note) (*entity.Note, error)
+	DeleteNote(context.Context, string, string) error
+	ExportBooks(context.Context) ([]entity.BookExport, error)
+}
+
+type ReviewRepository interface {
+	UpsertReview(context.Context, string, interface{}) error
+	GetReview(context.Context, string) (*entity.Review, error)
+	GetAllReviews(context.Context) ([]entity.Review, error)
+	RemoveReview(context.Context, string) error
+	UpsertNote(context.Context, string, interface{}) error
+	GetNote(context.Context, string) (*entity.Note, error)
+	GetNotes(context.Context, string) ([]entity.Note, error)
+	GetAllNotes(context.Context) ([]entity.Note, error)
+	RemoveNote(context.Context, string) error
+}
+
+type reviewTracker struct {
+	storage ReviewRepository
+	books   BookTracker
+}
+
+// NewReviewTracker - the rating of a review is copied to the book through the BookTracker
+func NewReviewTracker(rr ReviewRepository, books BookTracker) ReviewTracker {
+	return &reviewTracker{storage: rr, books: books}
+}
+
+// SetReview - creates or replaces the review of the book
+func (svc *reviewTracker) SetReview(ctx context.Context, id string, review entity.Review) (*entity.Review, error) {
+	if _, err := svc.books.GetBook(ctx, id); err != nil {
+		return nil, err
+	}
+
+	review.ISBN = id
+	review.SetTrackingDetails()
+	existing, err := svc.getReview(ctx, id)
+	switch err.(type) {
+	case nil:
+		review.Created = existing.Created
+		review.CreatedBy = existing.CreatedBy
+	case entity.NotFoundError:
+	default:
+		return nil, err
+	}
+
+	if err = svc.storage.UpsertReview(ctx, id, review); err != nil {
+		return nil, err
+	}
+	if err = svc.books.RateBook(ctx, id, review.Rating); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithField(logging.FieldISBN, id).Info("review saved")
+	return &review, nil
+}
+
+func (svc *reviewTracker) GetReview(ctx context.Context, id string) (*entity.Review, error) {
+	review, err := svc.getReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	review.ISBN = id
+	return review, nil
+}
+
+// DeleteReview - deletes the review and clears the rating of the book
+func (svc *reviewTracker) DeleteReview(ctx context.Context, id string) error {
+	if _, err := svc.getReview(ctx, id); err != nil {
+		return err
+	}
+	if err := svc.storage.RemoveReview(ctx, id); err != nil {
+		return err
+	}
+	return svc.books.RateBook(ctx, id, 0)
+}
+
+func (svc *reviewTracker) AddNote(ctx context.Context, id string, note entity.Note) (*entity.Note, error) {
+	if _, err := svc.books.GetBook(ctx, id); err != nil {
+		return nil, err
+	}
+
+	note.ID = uuid.New().String()
+	note.ISBN = id
+	note.SetTrackingDetails()
+	if err := svc.storage.UpsertNote(ctx, note.ID, note); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{logging.FieldISBN: id, logging.FieldNoteID: note.ID}).Info("note added")
+	return &note, nil
+}
+
+// ListNotes - lists the notes of the book ordered by page
+func (svc *reviewTracker) ListNotes(ctx context.Context, id string) ([]entity.Note, error) {
+	if _, err := svc.books.GetBook(ctx, id); err != nil {
+		return nil, err
+	}
+	return svc.storage.GetNotes(ctx, id)
+}
+
+func (svc *reviewTracker) UpdateNote(ctx context.Context, id string, noteID string, note entity.Note) (*entity.Note, error) {
+	existing, err := svc.getNote(ctx, id, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	note.ID = noteID
+	note.ISBN = id
+	note.Created = existing.Created
+	note.CreatedBy = existing.CreatedBy
+	note.UpdatedBy = existing.UpdatedBy
+	note.Updated = time.Now().Unix()
+	if err = svc.storage.UpsertNote(ctx, noteID, note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (svc *reviewTracker) DeleteNote(ctx context.Context, id string, noteID string) error {
+	if _, err := svc.getNote(ctx, id, noteID); err != nil {
+		return err
+	}
+	return svc.storage.RemoveNote(ctx, noteID)
+}
+
+// ExportBooks - all books(ordered by title) with their reviews and notes
+func (svc *reviewTracker) ExportBooks(ctx context.Context) ([]entity.BookExport, error) {
+	books, err := svc.books.ListBooks(ctx, consts.Title, entity.BookFilter{})
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := svc.storage.GetAllReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := svc.storage.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return exportBooks(books, reviews, notes), nil
+}
+
+func exportBooks(books []entity.Book, reviews []entity.Review, notes []entity.Note) []entity.BookExport {
+	reviewsByBook := map[string]*entity.Review{}
+	for i := range reviews {
+		reviewsByBook[reviews[i].ISBN] = &reviews[i]
+	}
+	notesByBook := map[string][]entity.Note{}
+	for _, note := range notes {
+		notesByBook[note.ISBN] = append(notesByBook[note.ISBN], note)
+	}
+
+	exports := make([]entity.BookExport, 0, len(books))
+	for _, book := range books {
+		exports = append(exports, entity.BookExport{Book: book, Review: reviewsByBook[book.ISBN], Notes: notesByBook[book.ISBN]})
+	}
+	return exports
+}
+
+func (svc *reviewTracker) getReview(ctx context.Context, id string) (*entity.Review, error) {
+	review, err := svc.storage.GetReview(ctx, id)
+	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("review of book %s not found", id)}
+	}
+	return review, err
+}
+
+// getNote - notes are only reachable through the book they belong to
+func (svc *reviewTracker) getNote(ctx context.Context, id string, noteID string) (*entity.Note, error) {
+	note, err := svc.storage.GetNote(ctx, noteID)
+	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("note %s of book %s not found", noteID, id)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if note.ISBN != "" && note.ISBN != id {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("note %s of book %s not found", noteID, id)}
+	}
+	return note, nil
+}
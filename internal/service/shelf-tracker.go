@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/framework/logging"
+)
+
+type ShelfTracker interface {
+	CreateShelf(context.Context, entity.Shelf) error
+	ListShelves(context.Context) ([]entity.Shelf, error)
+	GetShelf(context.Context, string) (*entity.Shelf, []entity.Book, error)
+	UpdateShelf(context.Context, string, entity.Shelf) error
+	DeleteShelf(context.Context, string) error
+	AddBookToShelf(context.Context, string, string) error
+	RemoveBookFromShelf(context.Context, string, string) error
+}
+
+type ShelfRepository interface {
+	UpsertShelf(context.Context, string, interface{}) error
+	GetShelf(context.Context, string) (*entity.Shelf, error)
+	GetAllShelves(context.Context) ([]entity.Shelf, error)
+	RemoveShelf(context.Context, string) error
+	RenameTag(context.Context, string, string) (int, error)
+	RemoveTag(context.Context, string) (int, error)
+}
+
+type shelfTracker struct {
+	storage ShelfRepository
+	books   BookTracker
+}
+
+// NewShelfTracker - shelves are tags on the books tracked by the BookTracker, so shelf changes cascade to them
+func NewShelfTracker(sr ShelfRepository, books BookTracker) ShelfTracker {
+	return &shelfTracker{storage: sr, books: books}
+}
+
+func (svc *shelfTracker) CreateShelf(ctx context.Context, shelf entity.Shelf) error {
+	shelf.Name = strings.TrimSpace(shelf.Name)
+	_, err := svc.getShelf(ctx, shelf.Name)
+	switch err.(type) {
+	case nil:
+		return entity.ConflictError{Message: fmt.Sprintf("shelf %s already exists", shelf.Name)}
+	case entity.NotFoundError:
+	default:
+		return err
+	}
+
+	shelf.Count = 0
+	shelf.SetTrackingDetails()
+	if err = svc.storage.UpsertShelf(ctx, shelf.Key(), shelf); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField(logging.FieldShelf, shelf.Name).Info("shelf created")
+	return nil
+}
+
+// ListShelves - lists the shelves with the number of books on each of them
+func (svc *shelfTracker) ListShelves(ctx context.Context) ([]entity.Shelf, error) {
+	shelves, err := svc.storage.GetAllShelves(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := svc.books.GroupBooksByTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, tag := range tags {
+		counts[entity.ShelfKey(tag.Tag)] += tag.Count
+	}
+	for i := range shelves {
+		shelves[i].Count = counts[shelves[i].Key()]
+	}
+	return shelves, nil
+}
+
+// GetShelf - gets the shelf and the books on it
+func (svc *shelfTracker) GetShelf(ctx context.Context, name string) (*entity.Shelf, []entity.Book, error) {
+	shelf, err := svc.getShelf(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	books, err := svc.books.ListBooks(ctx, "", entity.BookFilter{Tag: shelf.Name})
+	if err != nil {
+		return nil, nil, err
+	}
+	shelf.Count = len(books)
+	return shelf, books, nil
+}
+
+// UpdateShelf - updates the description and renames the shelf. A rename is applied to the tags of every book on it
+func (svc *shelfTracker) UpdateShelf(ctx context.Context, name string, shelf entity.Shelf) error {
+	existing, err := svc.getShelf(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	shelf.Name = strings.TrimSpace(shelf.Name)
+	renamed := shelf.Key() != existing.Key()
+	if renamed {
+		if _, err = svc.getShelf(ctx, shelf.Name); err == nil {
+			return entity.ConflictError{Message: fmt.Sprintf("shelf %s already exists", shelf.Name)}
+		} else if _, ok := err.(entity.NotFoundError); !ok {
+			return err
+		}
+	}
+
+	if shelf.Name != existing.Name {
+		count, err := svc.storage.RenameTag(ctx, existing.Name, shelf.Name)
+		if err != nil {
+			return err
+		}
+		logging.FromContext(ctx).WithFields(logrus.Fields{logging.FieldShelf: existing.Name, "to": shelf.Name, "books": count}).Info("shelf renamed")
+	}
+
+	shelf.Count = 0
+	shelf.Created = existing.Created
+	shelf.CreatedBy = existing.CreatedBy
+	shelf.UpdatedBy = existing.UpdatedBy
+	shelf.Updated = time.Now().Unix()
+	if err = svc.storage.UpsertShelf(ctx, shelf.Key(), shelf); err != nil {
+		return err
+	}
+	if renamed {
+		return svc.storage.RemoveShelf(ctx, existing.Key())
+	}
+	return nil
+}
+
+// DeleteShelf - deletes the shelf and removes its tag from every book on it
+func (svc *shelfTracker) DeleteShelf(ctx context.Context, name string) error {
+	shelf, err := svc.getShelf(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	count, err := svc.storage.RemoveTag(ctx, shelf.Name)
+	if err != nil {
+		return err
+	}
+	if err = svc.storage.RemoveShelf(ctx, shelf.Key()); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{logging.FieldShelf: shelf.Name, "books": count}).Info("shelf deleted")
+	return nil
+}
+
+func (svc *shelfTracker) AddBookToShelf(ctx context.Context, name string, id string) error {
+	return svc.updateBookTags(ctx, name, id, func(book *entity.Book, tag string) bool {
+		return book.AddTag(tag)
+	})
+}
+
+func (svc *shelfTracker) RemoveBookFromShelf(ctx context.Context, name string, id string) error {
+	return svc.updateBookTags(ctx, name, id, func(book *entity.Book, tag string) bool {
+		return book.RemoveTag(tag)
+	})
+}
+
+func (svc *shelfTracker) updateBookTags(ctx context.Context, name string, id string, update func(*entity.Book, string) bool) error {
+	shelf, err := svc.getShelf(ctx, name)
+	if err != nil {
+		return err
+	}
+	book, err := svc.books.GetBook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !update(book, shelf.Name) {
+		return nil
+	}
+	return svc.books.UpdateBook(ctx, *book)
+}
+
+func (svc *shelfTracker) getShelf(ctx context.Context, name string) (*entity.Shelf, error) {
+	shelf, err := svc.storage.GetShelf(ctx, entity.ShelfKey(name))
+	if err != nil && strings.Contains(err.Error(), documentNotFoundError) {
+		return nil, entity.NotFoundError{Message: fmt.Sprintf("shelf %s not found", name)}
+	}
+	return shelf, err
+}
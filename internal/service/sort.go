@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+// BookSorter is implemented by repositories that can order the books natively by the numeric keys(N1QL ORDER BY for
+// couchbase). The string keys, and repositories without it, fall back to sortBooks over GetAll.
+type BookSorter interface {
+	GetAllSorted(context.Context, entity.BookSort) ([]entity.Book, error)
+}
+
+// sortBooks - stable multi-key sort. Strings are compared with the Unicode(CLDR root) collation, so that case and
+// accents do not split the order the way a byte comparison does. The ISBN breaks the remaining ties
+func sortBooks(keys entity.BookSort, books []entity.Book) {
+	if len(keys) == 0 {
+		return
+	}
+	// a collator keeps buffers and is not safe for concurrent use
+	collator := collate.New(language.Und)
+	sort.SliceStable(books, func(i, j int) bool {
+		for _, key := range keys {
+			c := compareBooks(collator, key.Field, &books[i], &books[j])
+			if key.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return books[i].ISBN < books[j].ISBN
+	})
+}
+
+func compareBooks(collator *collate.Collator, field string, a, b *entity.Book) int {
+	switch field {
+	case entity.SortTitle:
+		return collator.CompareString(a.Title, b.Title)
+	case entity.SortAuthor:
+		return collator.CompareString(a.Author, b.Author)
+	case entity.SortStatus:
+		return collator.CompareString(a.Status, b.Status)
+	case entity.SortGenre:
+		return collator.CompareString(a.Genre, b.Genre)
+	case entity.SortCreated:
+		return compareNumbers(float64(a.Created), float64(b.Created))
+	case entity.SortUpdated:
+		return compareNumbers(float64(a.Updated), float64(b.Updated))
+	case entity.SortStarted:
+		return compareNumbers(float64(a.Started), float64(b.Started))
+	case entity.SortFinished:
+		return compareNumbers(float64(a.Finished), float64(b.Finished))
+	case entity.SortRating:
+		return compareNumbers(a.Rating, b.Rating)
+	}
+	return 0
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
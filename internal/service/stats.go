@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/anushasankaranarayanan/book-tracker-service/internal/entity"
+)
+
+const (
+	secondsPerDay = 86400
+	monthLayout   = "2006-01"
+	yearLayout    = "2006"
+)
+
+// StatsAggregator is implemented by repositories that can compute reading statistics natively (N1QL for couchbase).
+// Repositories without it fall back to computeStats over GetAll.
+type StatsAggregator interface {
+	Stats(context.Context, entity.StatsFilter) (*entity.ReadingStats, error)
+}
+
+func (svc *bookTracker) ReadingStats(ctx context.Context, filter entity.StatsFilter) (_ *entity.ReadingStats, err error) {
+	ctx, span := startSpan(ctx, "BookTracker.ReadingStats")
+	defer func() { endSpan(span, err) }()
+
+	if aggregator, ok := svc.storage.(StatsAggregator); ok {
+		return aggregator.Stats(ctx, filter)
+	}
+
+	books, err := svc.storage.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return computeStats(books, filter), nil
+}
+
+// computeStats - in-memory equivalent of the couchbase Stats aggregation
+func computeStats(books []entity.Book, filter entity.StatsFilter) *entity.ReadingStats {
+	stats := &entity.ReadingStats{}
+	perMonth := map[string]int{}
+	perYear := map[string]int{}
+	genres := map[string]int{}
+	authors := map[string]int{}
+	totalDays, timedBooks := 0.0, 0
+
+	for _, book := range books {
+		if !filter.Includes(book.Finished) {
+			continue
+		}
+		stats.BooksFinished++
+		stats.PagesRead += pagesRead(book)
+
+		finished := time.Unix(book.Finished, 0).UTC()
+		perMonth[finished.Format(monthLayout)]++
+		perYear[finished.Format(yearLayout)]++
+		genres[book.Genre]++
+		authors[book.Author]++
+
+		if book.Started <= 0 || book.Finished < book.Started {
+			continue
+		}
+		read := &entity.ReadDuration{ISBN: book.ISBN, Title: book.Title, Days: float64(book.Finished-book.Started) / secondsPerDay}
+		totalDays += read.Days
+		timedBooks++
+		if stats.LongestRead == nil || read.Days > stats.LongestRead.Days {
+			stats.LongestRead = read
+		}
+		if stats.ShortestRead == nil || read.Days < stats.ShortestRead.Days {
+			stats.ShortestRead = read
+		}
+	}
+
+	if timedBooks > 0 {
+		stats.AverageDaysToFinish = totalDays / float64(timedBooks)
+	}
+	stats.FinishedPerMonth = periodCounts(perMonth)
+	stats.FinishedPerYear = periodCounts(perYear)
+	stats.Genres = namedCounts(genres)
+	stats.Authors = namedCounts(authors)
+
+	return stats
+}
+
+// pagesRead - a finished book counts all its pages, books without a page count fall back to the bookmark
+func pagesRead(book entity.Book) int {
+	if book.PageCount > 0 {
+		return book.PageCount
+	}
+	return book.Bookmark
+}
+
+func periodCounts(counts map[string]int) []entity.PeriodCount {
+	var periods []entity.PeriodCount
+	for period, count := range counts {
+		periods = append(periods, entity.PeriodCount{Period: period, Count: count})
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Period < periods[j].Period
+	})
+	return periods
+}
+
+func namedCounts(counts map[string]int) []entity.NamedCount {
+	var named []entity.NamedCount
+	for name, count := range counts {
+		named = append(named, entity.NamedCount{Name: name, Count: count})
+	}
+	sort.Slice(named, func(i, j int) bool {
+		if named[i].Count != named[j].Count {
+			return named[i].Count > named[j].Count
+		}
+		return named[i].Name < named[j].Name
+	})
+	return named
+}